@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"net/http"
 	"os"
@@ -12,13 +14,18 @@ import (
 	"github.com/Alexander272/my-portfolio/internal/config"
 	delivery "github.com/Alexander272/my-portfolio/internal/delivery/http"
 	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/internal/scheduler"
 	"github.com/Alexander272/my-portfolio/internal/server"
 	"github.com/Alexander272/my-portfolio/internal/service"
 	"github.com/Alexander272/my-portfolio/pkg/auth"
 	"github.com/Alexander272/my-portfolio/pkg/database/mongodb"
 	"github.com/Alexander272/my-portfolio/pkg/database/redis"
+	"github.com/Alexander272/my-portfolio/pkg/email"
+	"github.com/Alexander272/my-portfolio/pkg/geoip"
 	"github.com/Alexander272/my-portfolio/pkg/hash"
+	"github.com/Alexander272/my-portfolio/pkg/images"
 	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/scanner"
 	"github.com/Alexander272/my-portfolio/pkg/storage"
 	"github.com/joho/godotenv"
 )
@@ -67,16 +74,180 @@ func main() {
 		logger.Fatalf("failed to initialize token manager: %s", err.Error())
 	}
 
-	storage, err := storage.NewFileStorage(conf.FileStorage.Bucket, conf.FileStorage.Endpoint)
+	var fileStorage storage.Provider
+	// хранилище на диске раздает файлы само через маршрут в delivery
+	var fileServer http.Handler
+	switch conf.FileStorage.Provider {
+	case "disk":
+		var disk *storage.DiskStorage
+		disk, err = storage.NewDiskStorage(conf.FileStorage.Disk.Root, conf.FileStorage.Disk.Url, conf.FileStorage.Disk.SigningKey)
+		fileStorage, fileServer = disk, disk
+	case "s3":
+		fileStorage, err = storage.NewS3Storage(context.Background(), storage.S3Config{
+			Endpoint:  conf.FileStorage.S3.Endpoint,
+			Region:    conf.FileStorage.S3.Region,
+			AccessKey: conf.FileStorage.S3.AccessKey,
+			SecretKey: conf.FileStorage.S3.SecretKey,
+			Bucket:    conf.FileStorage.S3.Bucket,
+			UseSSL:    conf.FileStorage.S3.UseSSL,
+			PathStyle: conf.FileStorage.S3.PathStyle,
+			Private:   conf.FileStorage.S3.Private,
+			PublicUrl: conf.FileStorage.S3.PublicUrl,
+		})
+	default:
+		fileStorage, err = storage.NewFileStorage(conf.FileStorage.Bucket, conf.FileStorage.Endpoint)
+	}
 	if err != nil {
 		logger.Fatalf("failed to initialize file storage: %s", err.Error())
 	}
 
+	var fileScanner scanner.Scanner
+	switch conf.Uploads.Scanner.Provider {
+	case "clamd":
+		fileScanner = scanner.NewClamd(conf.Uploads.Scanner.Network, conf.Uploads.Scanner.Address, conf.Uploads.Scanner.Timeout)
+		// непроверенный файл не сохраняется, поэтому файлы больше лимита clamd отклоняются еще до загрузки
+		conf.Uploads.MaxFileSize = capSize(conf.Uploads.MaxFileSize, conf.Uploads.Scanner.MaxSize)
+		conf.Uploads.Resumable.MaxSize = capSize(conf.Uploads.Resumable.MaxSize, conf.Uploads.Scanner.MaxSize)
+	case "":
+		logger.Infof("uploads are not scanned for malware")
+	default:
+		logger.Fatalf("unknown malware scanner: %s", conf.Uploads.Scanner.Provider)
+	}
+
+	var geo geoip.Locator
+	if conf.Analytics.GeoIPPath != "" {
+		geoDB, err := geoip.Open(conf.Analytics.GeoIPPath)
+		if err != nil {
+			logger.Fatalf("failed to open geoip database: %s", err.Error())
+		}
+		defer geoDB.Close()
+		geo = geoDB
+	} else {
+		logger.Infof("visitor countries are not detected")
+	}
+	analyticsSalt := conf.Analytics.Salt
+	if analyticsSalt == "" {
+		// без постоянной соли посетители, пришедшие до и после перезапуска, считаются разными
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			logger.Fatalf("failed to generate analytics salt: %s", err.Error())
+		}
+		analyticsSalt = hex.EncodeToString(salt)
+	}
+
+	var mailer email.Sender
+	if smtpConf := conf.Contact.SMTP; smtpConf.Host != "" {
+		smtpMailer, err := email.NewSMTP(email.SMTPConfig{
+			Host:        smtpConf.Host,
+			Port:        smtpConf.Port,
+			Username:    smtpConf.Username,
+			Password:    smtpConf.Password,
+			From:        smtpConf.From,
+			ImplicitTLS: smtpConf.ImplicitTLS,
+			Timeout:     smtpConf.Timeout,
+		})
+		if err != nil {
+			logger.Fatalf("failed to create smtp mailer: %s", err.Error())
+		}
+		mailer = smtpMailer
+	} else {
+		logger.Infof("contact messages are not forwarded by email")
+	}
+
+	var search repository.Search
+	switch conf.Search.Engine {
+	case "bleve":
+		bleveSearch, err := repository.NewSearchBleve(conf.Search.IndexPath)
+		if err != nil {
+			logger.Fatalf("failed to open search index: %s", err.Error())
+		}
+		defer bleveSearch.Close()
+		search = bleveSearch
+	default:
+		search, err = repository.NewSearchMongo(context.Background(), db)
+		if err != nil {
+			logger.Fatalf("failed to create search indexes: %s", err.Error())
+		}
+	}
+
+	variants := make([]images.Preset, 0, len(conf.Images.Variants))
+	for _, v := range conf.Images.Variants {
+		variants = append(variants, images.Preset{Name: v.Name, MaxWidth: v.MaxWidth, MaxHeight: v.MaxHeight, Quality: v.Quality})
+	}
+
+	uploadLimits := service.UploadLimits{
+		MaxFileSize: conf.Uploads.MaxFileSize,
+		MaxPixels:   conf.Uploads.MaxPixels,
+		UserQuota:   conf.Uploads.UserQuota,
+		Concurrency: conf.Uploads.Concurrency,
+	}
+	resumableUploads := service.ResumableUploads{
+		Dir:          conf.Uploads.Resumable.Dir,
+		MaxSize:      conf.Uploads.Resumable.MaxSize,
+		MaxChunkSize: conf.Uploads.Resumable.MaxChunkSize,
+		TTL:          conf.Uploads.Resumable.TTL,
+	}
+
+	contactOptions := service.ContactOptions{
+		RateLimit:  conf.Contact.RateLimit,
+		RateWindow: conf.Contact.RateWindow,
+		DailyLimit: conf.Contact.DailyLimit,
+		Salt:       analyticsSalt,
+	}
+
 	// Services, Repos & API Handlers
 	repos := repository.NewRepositories(db, client)
+	if err := repository.NewFilesRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create files indexes: %s", err.Error())
+	}
+	if err := repository.NewQuarantineRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create quarantine indexes: %s", err.Error())
+	}
+	if err := repository.NewInvitationsRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create invitations indexes: %s", err.Error())
+	}
+	if err := repository.NewViewsRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create views indexes: %s", err.Error())
+	}
+	if err := repository.NewReactionsRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create reactions indexes: %s", err.Error())
+	}
+	projectsRepo := repository.NewProjectsRepo(db)
+	if err := projectsRepo.CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create projects indexes: %s", err.Error())
+	}
+	if err := projectsRepo.FillDefaults(context.Background()); err != nil {
+		logger.Fatalf("failed to fill project defaults: %s", err.Error())
+	}
+	if err := repository.NewCommentsRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create comments indexes: %s", err.Error())
+	}
+	if err := repository.NewNotificationsRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create notifications indexes: %s", err.Error())
+	}
+	if err := repository.NewMessagesRepo(db).CreateIndexes(context.Background()); err != nil {
+		logger.Fatalf("failed to create messages indexes: %s", err.Error())
+	}
 	services := service.NewServices(service.Deps{
-		Repos:                  repos,
-		StorageProvider:        storage,
+		Repos:        repos,
+		SearchEngine: search,
+		RevisionRetention: service.RevisionRetention{
+			MaxCount: conf.Projects.Revisions.MaxCount,
+			MaxAge:   conf.Projects.Revisions.MaxAge,
+		},
+		TrashRetention:         conf.Trash.Retention,
+		StorageProvider:        fileStorage,
+		Scanner:                fileScanner,
+		GeoIP:                  geo,
+		Analytics:              service.AnalyticsOptions{Salt: analyticsSalt, SiteHosts: conf.Analytics.SiteHosts},
+		Comments:               service.CommentOptions{RateLimit: conf.Comments.RateLimit, RateWindow: conf.Comments.RateWindow},
+		Contact:                contactOptions,
+		Mailer:                 mailer,
+		SignedUrlTTL:           conf.FileStorage.SignedUrlTTL,
+		Images:                 service.ImageOptions{Variants: variants, KeepCopyright: conf.Images.KeepCopyright},
+		UploadLimits:           uploadLimits,
+		ResumableUploads:       resumableUploads,
+		FilesGC:                service.FilesGCOptions{GracePeriod: conf.FileStorage.GC.GracePeriod, DryRun: conf.FileStorage.GC.DryRun},
 		Hasher:                 hasher,
 		TokenManager:           tokenManager,
 		AccessTokenTTL:         conf.Auth.JWT.AccessTokenTTL,
@@ -84,7 +255,21 @@ func main() {
 		Domain:                 conf.Http.Host,
 		VerificationCodeLength: conf.Auth.VerificationCodeLength,
 	})
-	handlers := delivery.NewHandler(services)
+	handlers := delivery.NewHandler(services, fileServer)
+
+	if bleveSearch, ok := search.(*repository.SearchBleve); ok && bleveSearch.Created() {
+		if err := services.Search.Reindex(context.Background()); err != nil {
+			logger.Errorf("failed to fill search index: %s", err.Error())
+		}
+	}
+
+	// Background Jobs
+	jobs := scheduler.NewScheduler()
+	jobs.Add("publish scheduled projects", conf.Projects.PublishInterval, services.Project.PublishScheduled)
+	jobs.Add("purge trash", conf.Trash.PurgeInterval, services.Trash.Purge)
+	jobs.Add("purge expired uploads", conf.Uploads.Resumable.PurgeInterval, services.Uploads.PurgeExpired)
+	jobs.Add("collect orphaned files", conf.FileStorage.GC.Interval, services.FilesGC.Collect)
+	jobs.Start()
 
 	// HTTP Server
 	srv := server.NewServer(conf, handlers.Init(conf))
@@ -108,8 +293,17 @@ func main() {
 	if err := srv.Stop(ctx); err != nil {
 		logger.Errorf("failed to stop server: %v", err)
 	}
+	jobs.Stop()
 
 	if err := mongoClient.Disconnect(context.Background()); err != nil {
 		logger.Errorf("error occured on db connection close: %s", err.Error())
 	}
 }
+
+// capSize уменьшает размер size до limit. Нулевые значения означают отсутствие ограничения.
+func capSize(size, limit int64) int64 {
+	if limit > 0 && (size == 0 || size > limit) {
+		return limit
+	}
+	return size
+}
@@ -16,6 +16,14 @@ type (
 		Auth        AuthConfig
 		Http        HttpConfig
 		FileStorage FileStorageConfig
+		Images      ImagesConfig
+		Uploads     UploadsConfig
+		Search      SearchConfig
+		Projects    ProjectsConfig
+		Trash       TrashConfig
+		Analytics   AnalyticsConfig
+		Comments    CommentsConfig
+		Contact     ContactConfig
 		// CacheTTL    time.Duration `mapstructure:"ttl"`
 	}
 
@@ -52,8 +60,144 @@ type (
 	}
 
 	FileStorageConfig struct {
+		// Provider выбирает хранилище: firebase, disk или s3
+		Provider string `mapstructure:"provider"`
 		Endpoint string
 		Bucket   string
+		// SignedUrlTTL - срок действия ссылок на приватные файлы
+		SignedUrlTTL time.Duration     `mapstructure:"signedUrlTTL" split_words:"true"`
+		Disk         DiskStorageConfig `mapstructure:"disk"`
+		S3           S3StorageConfig   `mapstructure:"s3"`
+		GC           FilesGCConfig     `mapstructure:"gc"`
+	}
+
+	// FilesGCConfig настраивает удаление файлов, на которые ничего не ссылается
+	FilesGCConfig struct {
+		Interval    time.Duration `mapstructure:"interval"`
+		GracePeriod time.Duration `mapstructure:"gracePeriod" split_words:"true"`
+		DryRun      bool          `mapstructure:"dryRun" split_words:"true"`
+	}
+
+	DiskStorageConfig struct {
+		Root       string        `mapstructure:"root"`
+		Url        string        `mapstructure:"url"`
+		CacheTTL   time.Duration `mapstructure:"cacheTTL" split_words:"true"`
+		SigningKey string        `split_words:"true"`
+	}
+
+	S3StorageConfig struct {
+		Endpoint  string `mapstructure:"endpoint"`
+		Region    string `mapstructure:"region"`
+		Bucket    string `mapstructure:"bucket"`
+		AccessKey string `split_words:"true"`
+		SecretKey string `split_words:"true"`
+		UseSSL    bool   `mapstructure:"useSSL" split_words:"true"`
+		PathStyle bool   `mapstructure:"pathStyle" split_words:"true"`
+		Private   bool   `mapstructure:"private"`
+		PublicUrl string `mapstructure:"publicUrl" split_words:"true"`
+	}
+
+	ImagesConfig struct {
+		// Variants - пресеты уменьшенных копий, которые создаются при загрузке изображения
+		Variants []ImageVariantConfig `mapstructure:"variants"`
+		// KeepCopyright оставляет в загруженных фото поля EXIF Artist и Copyright
+		KeepCopyright bool `mapstructure:"keepCopyright"`
+	}
+
+	ImageVariantConfig struct {
+		Name      string  `mapstructure:"name"`
+		MaxWidth  int     `mapstructure:"maxWidth"`
+		MaxHeight int     `mapstructure:"maxHeight"`
+		Quality   float32 `mapstructure:"quality"`
+	}
+
+	UploadsConfig struct {
+		// MaxFileSize и UserQuota задаются в байтах, MaxPixels - площадью изображения в пикселях
+		MaxFileSize int64 `mapstructure:"maxFileSize" split_words:"true"`
+		MaxPixels   int   `mapstructure:"maxPixels" split_words:"true"`
+		UserQuota   int64 `mapstructure:"userQuota" split_words:"true"`
+		// Concurrency - сколько изображений обрабатывается одновременно, 0 - по числу процессоров
+		Concurrency int             `mapstructure:"concurrency"`
+		Resumable   ResumableConfig `mapstructure:"resumable"`
+		Scanner     ScannerConfig   `mapstructure:"scanner"`
+	}
+
+	// ScannerConfig настраивает проверку загрузок антивирусом. Provider clamd - демон ClamAV,
+	// пустое значение отключает проверку.
+	ScannerConfig struct {
+		Provider string `mapstructure:"provider"`
+		// Network - tcp или unix, Address - адрес демона или путь к сокету
+		Network string        `mapstructure:"network"`
+		Address string        `mapstructure:"address"`
+		Timeout time.Duration `mapstructure:"timeout"`
+		// MaxSize должен совпадать с StreamMaxLength в clamd.conf: файлы больше этого размера демон не проверяет,
+		// поэтому они не принимаются ни обычной загрузкой, ни частями
+		MaxSize int64 `mapstructure:"maxSize" split_words:"true"`
+	}
+
+	// ResumableConfig настраивает загрузку больших файлов частями
+	ResumableConfig struct {
+		Dir           string        `mapstructure:"dir"`
+		MaxSize       int64         `mapstructure:"maxSize" split_words:"true"`
+		MaxChunkSize  int64         `mapstructure:"maxChunkSize" split_words:"true"`
+		TTL           time.Duration `mapstructure:"ttl"`
+		PurgeInterval time.Duration `mapstructure:"purgeInterval"`
+	}
+
+	SearchConfig struct {
+		Engine    string `mapstructure:"engine"`
+		IndexPath string `mapstructure:"indexPath"`
+	}
+
+	ProjectsConfig struct {
+		PublishInterval time.Duration   `mapstructure:"publishInterval"`
+		Revisions       RevisionsConfig `mapstructure:"revisions"`
+	}
+
+	RevisionsConfig struct {
+		MaxCount int           `mapstructure:"maxCount"`
+		MaxAge   time.Duration `mapstructure:"maxAge"`
+	}
+
+	TrashConfig struct {
+		Retention     time.Duration `mapstructure:"retention"`
+		PurgeInterval time.Duration `mapstructure:"purgeInterval"`
+	}
+
+	// AnalyticsConfig настраивает учет просмотров проектов и профилей
+	AnalyticsConfig struct {
+		// GeoIPPath - файл базы стран в формате MaxMind DB, пустое значение отключает определение страны
+		GeoIPPath string `mapstructure:"geoipPath" split_words:"true"`
+		// SiteHosts - хосты сайта, переходы с них не считаются источниками
+		SiteHosts []string `mapstructure:"siteHosts" split_words:"true"`
+		// Salt - секрет для хеширования IP адресов посетителей
+		Salt string
+	}
+
+	// CommentsConfig ограничивает частоту комментариев: не больше RateLimit от пользователя за RateWindow
+	CommentsConfig struct {
+		RateLimit  int           `mapstructure:"rateLimit"`
+		RateWindow time.Duration `mapstructure:"rateWindow"`
+	}
+
+	// ContactConfig ограничивает частоту сообщений из формы обратной связи: не больше RateLimit с одного адреса
+	// за RateWindow и не больше DailyLimit одному пользователю за сутки
+	ContactConfig struct {
+		RateLimit  int           `mapstructure:"rateLimit"`
+		RateWindow time.Duration `mapstructure:"rateWindow"`
+		DailyLimit int           `mapstructure:"dailyLimit"`
+		SMTP       SMTPConfig    `mapstructure:"smtp"`
+	}
+
+	// SMTPConfig - почтовый сервер для пересылки сообщений, пустой Host отключает пересылку
+	SMTPConfig struct {
+		Host        string        `mapstructure:"host"`
+		Port        int           `mapstructure:"port"`
+		Username    string        `mapstructure:"username"`
+		Password    string        `mapstructure:"password"`
+		From        string        `mapstructure:"from"`
+		ImplicitTLS bool          `mapstructure:"implicitTLS" split_words:"true"`
+		Timeout     time.Duration `mapstructure:"timeout"`
 	}
 
 	HttpConfig struct {
@@ -111,9 +255,33 @@ func unmarhal(conf *Config) error {
 	if err := viper.UnmarshalKey("auth.verificationCodeLength", &conf.Auth.VerificationCodeLength); err != nil {
 		return err
 	}
-	// if err := viper.UnmarshalKey("fileStorage", &conf.FileStorage); err != nil {
-	// 	return err
-	// }
+	if err := viper.UnmarshalKey("search", &conf.Search); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("projects", &conf.Projects); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("trash", &conf.Trash); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("fileStorage", &conf.FileStorage); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("images", &conf.Images); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("uploads", &conf.Uploads); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("analytics", &conf.Analytics); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("comments", &conf.Comments); err != nil {
+		return err
+	}
+	if err := viper.UnmarshalKey("contact", &conf.Contact); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -138,6 +306,18 @@ func setFromEnv(conf *Config) error {
 	if err := envconfig.Process("storage", &conf.FileStorage); err != nil {
 		return err
 	}
+	if err := envconfig.Process("search", &conf.Search); err != nil {
+		return err
+	}
+	if err := envconfig.Process("uploads", &conf.Uploads); err != nil {
+		return err
+	}
+	if err := envconfig.Process("analytics", &conf.Analytics); err != nil {
+		return err
+	}
+	if err := envconfig.Process("smtp", &conf.Contact.SMTP); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -3,6 +3,8 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/Alexander272/my-portfolio/internal/config"
 	v1 "github.com/Alexander272/my-portfolio/internal/delivery/http/v1"
@@ -16,11 +18,14 @@ import (
 
 type Handler struct {
 	services *service.Services
+	// files раздает файлы хранилищ, которые не имеют своего адреса (disk). Для остальных nil.
+	files http.Handler
 }
 
-func NewHandler(services *service.Services) *Handler {
+func NewHandler(services *service.Services, files http.Handler) *Handler {
 	return &Handler{
 		services: services,
+		files:    files,
 	}
 }
 
@@ -53,11 +58,29 @@ func (h *Handler) Init(conf *config.Config) *gin.Engine {
 		c.String(http.StatusOK, "pong")
 	})
 
+	if h.files != nil {
+		url := strings.TrimSuffix(conf.FileStorage.Disk.Url, "/")
+		files := router.Group(url, staticCache(conf.FileStorage.Disk.CacheTTL))
+		files.GET("/*filepath", gin.WrapH(http.StripPrefix(url, h.files)))
+		files.HEAD("/*filepath", gin.WrapH(http.StripPrefix(url, h.files)))
+	}
+
 	h.initAPI(router)
 
 	return router
 }
 
+// staticCache задает заголовки для файлов из локального хранилища. Тип содержимого определяется
+// по расширению, а сверка по Last-Modified выполняется при отдаче файла. Ответы по подписанным ссылкам
+// хранилище помечает приватными само.
+func staticCache(ttl time.Duration) gin.HandlerFunc {
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(ttl.Seconds()))
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", cacheControl)
+		c.Header("X-Content-Type-Options", "nosniff")
+	}
+}
+
 func (h *Handler) initAPI(router *gin.Engine) {
 	handlerV1 := v1.NewHandler(h.services)
 	api := router.Group("/api")
@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	statsDateLayout   = "2006-01-02"
+	recordViewTimeout = 5 * time.Second
+)
+
+// recordView учитывает просмотр в статистике. Запись идет в фоне, чтобы не задерживать ответ,
+// ошибки только пишутся в лог. Источник перехода берется только из заголовка Referer: значение из параметров
+// запроса любой мог бы подставить в чужую статистику.
+func (h *Handler) recordView(c *gin.Context, target domain.ViewTarget, targetId, ownerId primitive.ObjectID) {
+	view := domain.View{
+		Target:    target,
+		TargetId:  targetId,
+		OwnerId:   ownerId,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Referrer:  c.GetHeader("Referer"),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), recordViewTimeout)
+		defer cancel()
+		if err := h.services.Analytics.RecordView(ctx, view); err != nil {
+			logger.Errorf("failed to record view of %s %s: %s", view.Target, view.TargetId.Hex(), err.Error())
+		}
+	}()
+}
+
+// @Summary Get Project Stats
+// @Security ApiKeyAuth
+// @Tags analytics
+// @Description статистика просмотров проекта по дням, источники переходов и страны посетителей.
+// @Description Доступна только владельцу. По умолчанию - за последние 30 дней, период не длиннее 366 дней.
+// @ModuleID getProjectStats
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param from query string false "first day, YYYY-MM-DD"
+// @Param to query string false "last day, YYYY-MM-DD"
+// @Success 200 {object} domain.ViewStats
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/stats [get]
+func (h *Handler) getProjectStats(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	from, to, ok := getStatsPeriod(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.services.Analytics.GetProjectStats(c, projectId, userId, from, to)
+	if err != nil {
+		newErrorResponse(c, statsErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary Get Profile Stats
+// @Security ApiKeyAuth
+// @Tags analytics
+// @Description статистика просмотров профиля текущего пользователя по дням, источники переходов и страны посетителей.
+// @Description По умолчанию - за последние 30 дней, период не длиннее 366 дней.
+// @ModuleID getProfileStats
+// @Accept  json
+// @Produce  json
+// @Param from query string false "first day, YYYY-MM-DD"
+// @Param to query string false "last day, YYYY-MM-DD"
+// @Success 200 {object} domain.ViewStats
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/stats [get]
+func (h *Handler) getProfileStats(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	from, to, ok := getStatsPeriod(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.services.Analytics.GetProfileStats(c, userId, from, to)
+	if err != nil {
+		newErrorResponse(c, statsErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// getStatsPeriod читает период статистики из запроса, при ошибке отвечает сам. Пропущенная граница - нулевое время.
+func getStatsPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	var period [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(statsDateLayout, value)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid "+name+" param")
+			return time.Time{}, time.Time{}, false
+		}
+		period[i] = t
+	}
+	return period[0], period[1], true
+}
+
+func statsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProjectForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidStatsPeriod):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,291 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) initContactRoutes(user *gin.RouterGroup) {
+	user.POST("/:id/contact", h.sendContactMessage)
+
+	inbox := user.Group("/inbox", h.userIdentity)
+	{
+		inbox.GET("", h.getMessages)
+		inbox.GET("/unread", h.getUnreadMessages)
+		inbox.POST("/:id/read", h.readMessage)
+		inbox.POST("/:id/unread", h.unreadMessage)
+		inbox.POST("/:id/archive", h.archiveMessage)
+		inbox.POST("/:id/spam", h.spamMessage)
+		inbox.POST("/:id/restore", h.restoreMessage)
+		inbox.DELETE("/:id", h.removeMessage)
+	}
+}
+
+// @Summary Send Contact Message
+// @Tags contact
+// @Description сообщение владельцу портфолио из формы обратной связи, email владельца посетителю не раскрывается.
+// @Description Поле website - ловушка для ботов, форма должна его скрывать. Ответ одинаковый, даже если сообщение
+// @Description отброшено или попало в спам.
+// @ModuleID sendContactMessage
+// @Accept  json
+// @Produce  json
+// @Param id path string true "user id"
+// @Param input body domain.ContactInput true "message"
+// @Success 202 {object} statusResponse
+// @Failure 400,404,429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/{id}/contact [post]
+func (h *Handler) sendContactMessage(c *gin.Context) {
+	userId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var input domain.ContactInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Contact.Send(c, userId, input, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		newErrorResponse(c, contactErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, statusResponse{"Sent"})
+}
+
+// @Summary Get Messages
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description сообщения из формы обратной связи текущему пользователю, новые - первыми
+// @ModuleID getMessages
+// @Accept  json
+// @Produce  json
+// @Param folder query string false "folder" Enums(inbox, archive, spam) default(inbox)
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Success 200 {object} pageResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox [get]
+func (h *Handler) getMessages(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+	folder := domain.MessageFolder(c.DefaultQuery("folder", string(domain.FolderInbox)))
+
+	messages, page, err := h.services.Contact.GetMessages(c, userId, folder, params)
+	if err != nil {
+		newErrorResponse(c, contactErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, messages, page))
+}
+
+// @Summary Get Unread Messages
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description число непрочитанных сообщений во входящих текущего пользователя
+// @ModuleID getUnreadMessages
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} domain.UnreadMessages
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox/unread [get]
+func (h *Handler) getUnreadMessages(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	unread, err := h.services.Contact.GetUnread(c, userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, unread)
+}
+
+// @Summary Read Message
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description отметка сообщения прочитанным
+// @ModuleID readMessage
+// @Accept  json
+// @Produce  json
+// @Param id path string true "message id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox/{id}/read [post]
+func (h *Handler) readMessage(c *gin.Context) {
+	h.markMessage(c, true, "Read")
+}
+
+// @Summary Unread Message
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description отметка сообщения непрочитанным
+// @ModuleID unreadMessage
+// @Accept  json
+// @Produce  json
+// @Param id path string true "message id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox/{id}/unread [post]
+func (h *Handler) unreadMessage(c *gin.Context) {
+	h.markMessage(c, false, "Unread")
+}
+
+// @Summary Archive Message
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description перенос сообщения в архив
+// @ModuleID archiveMessage
+// @Accept  json
+// @Produce  json
+// @Param id path string true "message id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox/{id}/archive [post]
+func (h *Handler) archiveMessage(c *gin.Context) {
+	h.moveMessage(c, domain.FolderArchive, "Archived")
+}
+
+// @Summary Spam Message
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description перенос сообщения в спам
+// @ModuleID spamMessage
+// @Accept  json
+// @Produce  json
+// @Param id path string true "message id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox/{id}/spam [post]
+func (h *Handler) spamMessage(c *gin.Context) {
+	h.moveMessage(c, domain.FolderSpam, "Moved to spam")
+}
+
+// @Summary Restore Message
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description возврат сообщения из архива или спама во входящие
+// @ModuleID restoreMessage
+// @Accept  json
+// @Produce  json
+// @Param id path string true "message id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox/{id}/restore [post]
+func (h *Handler) restoreMessage(c *gin.Context) {
+	h.moveMessage(c, domain.FolderInbox, "Restored")
+}
+
+// @Summary Remove Message
+// @Security ApiKeyAuth
+// @Tags contact
+// @Description удаление сообщения без возможности восстановления
+// @ModuleID removeMessage
+// @Accept  json
+// @Produce  json
+// @Param id path string true "message id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/inbox/{id} [delete]
+func (h *Handler) removeMessage(c *gin.Context) {
+	userId, id, ok := getMessageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Contact.Remove(c, userId, id); err != nil {
+		newErrorResponse(c, contactErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Deleted"})
+}
+
+func (h *Handler) markMessage(c *gin.Context, read bool, status string) {
+	userId, id, ok := getMessageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Contact.MarkRead(c, userId, id, read); err != nil {
+		newErrorResponse(c, contactErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{status})
+}
+
+func (h *Handler) moveMessage(c *gin.Context, folder domain.MessageFolder, status string) {
+	userId, id, ok := getMessageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Contact.Move(c, userId, id, folder); err != nil {
+		newErrorResponse(c, contactErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{status})
+}
+
+// getMessageParams читает пользователя и id сообщения из запроса, при ошибке отвечает сам
+func getMessageParams(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return userId, id, true
+}
+
+func contactErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, domain.ErrMessageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrMessageEmpty) || errors.Is(err, domain.ErrInvalidFolder) || isPaginationError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrContactRateLimited):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
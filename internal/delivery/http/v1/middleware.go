@@ -1,17 +1,21 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/Alexander272/my-portfolio/internal/service"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
 	authorizationHeader = "Authorization"
 	userIdCtx           = "userId"
 	userRoleCtx         = "role"
+
+	adminRole = "admin"
 )
 
 func (h *Handler) userIdentity(c *gin.Context) {
@@ -46,3 +50,30 @@ func (h *Handler) userIdentity(c *gin.Context) {
 	c.Set(userIdCtx, userId)
 	c.Set(userRoleCtx, role)
 }
+
+func getUserId(c *gin.Context) (primitive.ObjectID, error) {
+	id, ok := c.Get(userIdCtx)
+	if !ok {
+		return primitive.NilObjectID, errors.New("user id not found")
+	}
+	idStr, ok := id.(string)
+	if !ok {
+		return primitive.NilObjectID, errors.New("user id is of invalid type")
+	}
+	return primitive.ObjectIDFromHex(idStr)
+}
+
+func (h *Handler) adminAccess(c *gin.Context) {
+	if c.GetString(userRoleCtx) != adminRole {
+		newErrorResponse(c, http.StatusForbidden, "access forbidden")
+		return
+	}
+}
+
+// selfAccess пропускает запросы к пользователю :id только от него самого и от администратора
+func (h *Handler) selfAccess(c *gin.Context) {
+	if c.GetString(userIdCtx) != c.Param("id") && c.GetString(userRoleCtx) != adminRole {
+		newErrorResponse(c, http.StatusForbidden, "access forbidden")
+		return
+	}
+}
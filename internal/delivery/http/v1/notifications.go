@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) initNotificationsRoutes(user *gin.RouterGroup) {
+	notifications := user.Group("/notifications", h.userIdentity)
+	{
+		notifications.GET("", h.getNotifications)
+		notifications.GET("/unread", h.getUnreadNotifications)
+		notifications.POST("/read", h.readAllNotifications)
+		notifications.POST("/:id/read", h.readNotification)
+	}
+}
+
+// @Summary Get Notifications
+// @Security ApiKeyAuth
+// @Tags notifications
+// @Description получение уведомлений текущего пользователя о комментариях к его проектам, новые - первыми.
+// @Description Уведомления хранятся 90 дней.
+// @ModuleID getNotifications
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Success 200 {object} pageResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/notifications [get]
+func (h *Handler) getNotifications(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	notifications, page, err := h.services.Notification.GetNotifications(c, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, notifications, page))
+}
+
+// @Summary Get Unread Notifications
+// @Security ApiKeyAuth
+// @Tags notifications
+// @Description число непрочитанных уведомлений текущего пользователя
+// @ModuleID getUnreadNotifications
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} domain.UnreadNotifications
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/notifications/unread [get]
+func (h *Handler) getUnreadNotifications(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	unread, err := h.services.Notification.GetUnread(c, userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, unread)
+}
+
+// @Summary Read All Notifications
+// @Security ApiKeyAuth
+// @Tags notifications
+// @Description отметка всех уведомлений текущего пользователя прочитанными
+// @ModuleID readAllNotifications
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} statusResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/notifications/read [post]
+func (h *Handler) readAllNotifications(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.services.Notification.MarkAllRead(c, userId); err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Read"})
+}
+
+// @Summary Read Notification
+// @Security ApiKeyAuth
+// @Tags notifications
+// @Description отметка уведомления прочитанным
+// @ModuleID readNotification
+// @Accept  json
+// @Produce  json
+// @Param id path string true "notification id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/notifications/{id}/read [post]
+func (h *Handler) readNotification(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Notification.MarkRead(c, userId, id); err != nil {
+		if errors.Is(err, domain.ErrNotificationNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Read"})
+}
@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func getPagination(c *gin.Context) (pagination.Params, bool) {
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return pagination.Params{}, false
+	}
+	return params, true
+}
+
+func isPaginationError(err error) bool {
+	return errors.Is(err, pagination.ErrInvalidCursor) || errors.Is(err, pagination.ErrInvalidSort)
+}
@@ -0,0 +1,354 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) initProjectCollaboratorsRoutes(self *gin.RouterGroup) {
+	self.GET("/shared", h.getSharedProjects)
+	self.GET("/invitations", h.getInvitations)
+	self.POST("/invitations/:id/accept", h.acceptInvitation)
+	self.POST("/invitations/:id/decline", h.declineInvitation)
+	self.GET("/:id/collaborators", h.getCollaborators)
+	self.PUT("/:id/collaborators/:userId", h.setCollaboratorRole)
+	self.DELETE("/:id/collaborators/:userId", h.removeCollaborator)
+	self.GET("/:id/invitations", h.getProjectInvitations)
+	self.POST("/:id/invitations", h.inviteCollaborator)
+	self.DELETE("/:id/invitations/:invitationId", h.revokeInvitation)
+}
+
+// @Summary Get Shared Projects
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description получение списка чужих проектов, в которых текущий пользователь соавтор
+// @ModuleID getSharedProjects
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt, updatedAt, name, order)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/shared [get]
+func (h *Handler) getSharedProjects(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	projects, page, err := h.services.Collaborator.GetShared(c, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, projects, page))
+}
+
+// @Summary Get Invitations
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description получение приглашений в соавторы, на которые текущий пользователь еще не ответил
+// @ModuleID getInvitations
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} domain.Invitation
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/invitations [get]
+func (h *Handler) getInvitations(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	invitations, err := h.services.Collaborator.GetInvitations(c, userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// @Summary Accept Invitation
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description принятие приглашения: текущий пользователь становится соавтором проекта
+// @ModuleID acceptInvitation
+// @Accept  json
+// @Produce  json
+// @Param id path string true "invitation id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/invitations/{id}/accept [post]
+func (h *Handler) acceptInvitation(c *gin.Context) {
+	userId, invitationId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Collaborator.AcceptInvitation(c, userId, invitationId); err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Accepted"})
+}
+
+// @Summary Decline Invitation
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description отказ от приглашения в соавторы
+// @ModuleID declineInvitation
+// @Accept  json
+// @Produce  json
+// @Param id path string true "invitation id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/invitations/{id}/decline [post]
+func (h *Handler) declineInvitation(c *gin.Context) {
+	userId, invitationId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Collaborator.DeclineInvitation(c, userId, invitationId); err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Declined"})
+}
+
+// @Summary Get Collaborators
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description получение владельца и соавторов проекта с их ролями, доступно владельцу и соавторам
+// @ModuleID getCollaborators
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {array} domain.ProjectAuthor
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/collaborators [get]
+func (h *Handler) getCollaborators(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	authors, err := h.services.Collaborator.GetCollaborators(c, projectId, userId)
+	if err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, authors)
+}
+
+// @Summary Set Collaborator Role
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description изменение роли соавтора: editor меняет содержимое проекта, viewer только просматривает его
+// @ModuleID setCollaboratorRole
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param userId path string true "collaborator id"
+// @Param input body domain.CollaboratorRoleInput true "role"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/collaborators/{userId} [put]
+func (h *Handler) setCollaboratorRole(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	collaboratorId, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid userId param")
+		return
+	}
+	var input domain.CollaboratorRoleInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Collaborator.SetRole(c, projectId, userId, collaboratorId, input.Role); err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Updated"})
+}
+
+// @Summary Remove Collaborator
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description удаление соавтора из проекта. Владелец удаляет любого соавтора, соавтор может выйти из проекта сам.
+// @ModuleID removeCollaborator
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param userId path string true "collaborator id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/collaborators/{userId} [delete]
+func (h *Handler) removeCollaborator(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	collaboratorId, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid userId param")
+		return
+	}
+
+	if err := h.services.Collaborator.RemoveCollaborator(c, projectId, userId, collaboratorId); err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Removed"})
+}
+
+// @Summary Get Project Invitations
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description получение приглашений в проект, на которые еще не ответили
+// @ModuleID getProjectInvitations
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {array} domain.Invitation
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/invitations [get]
+func (h *Handler) getProjectInvitations(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.services.Collaborator.GetProjectInvitations(c, projectId, userId)
+	if err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// @Summary Invite Collaborator
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description приглашение пользователя с указанным email в соавторы проекта
+// @ModuleID inviteCollaborator
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body domain.InvitationInput true "user email and role"
+// @Success 201 {object} domain.Invitation
+// @Failure 400,403,404,409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/invitations [post]
+func (h *Handler) inviteCollaborator(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	var input domain.InvitationInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	invitation, err := h.services.Collaborator.Invite(c, projectId, userId, input)
+	if err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// @Summary Revoke Invitation
+// @Security ApiKeyAuth
+// @Tags collaborators
+// @Description отзыв приглашения, на которое еще не ответили
+// @ModuleID revokeInvitation
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param invitationId path string true "invitation id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/invitations/{invitationId} [delete]
+func (h *Handler) revokeInvitation(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	invitationId, err := primitive.ObjectIDFromHex(c.Param("invitationId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid invitationId param")
+		return
+	}
+
+	if err := h.services.Collaborator.RevokeInvitation(c, projectId, userId, invitationId); err != nil {
+		newErrorResponse(c, collaboratorErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Revoked"})
+}
+
+func collaboratorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProjectForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrUserNotFound) ||
+		errors.Is(err, domain.ErrCollaboratorNotFound) || errors.Is(err, domain.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidRole) || errors.Is(err, domain.ErrSelfInvitation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrCollaboratorExists) || errors.Is(err, domain.ErrInvitationExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
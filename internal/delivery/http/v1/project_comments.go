@@ -0,0 +1,325 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) initProjectCommentsRoutes(project, self *gin.RouterGroup) {
+	project.GET("/:id/comments", h.getComments)
+	project.GET("/:id/comments/:commentId/replies", h.getCommentReplies)
+
+	self.POST("/:id/comments", h.createComment)
+	self.DELETE("/:id/comments/:commentId", h.removeComment)
+	self.GET("/:id/comments/moderation", h.getCommentsForModeration)
+	self.POST("/:id/comments/:commentId/approve", h.approveComment)
+	self.POST("/:id/comments/:commentId/hide", h.hideComment)
+	self.PUT("/:id/comments/mode", h.setCommentsMode)
+}
+
+// @Summary Get Comments
+// @Tags comments
+// @Description получение видимых комментариев верхнего уровня к опубликованному проекту, новые - первыми.
+// @Description Число видимых ответов на комментарий - в поле replies, сами ответы отдаются отдельным запросом.
+// @ModuleID getComments
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments [get]
+func (h *Handler) getComments(c *gin.Context) {
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	comments, page, err := h.services.Comment.GetThreads(c, projectId, params)
+	if err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, comments, page))
+}
+
+// @Summary Get Comment Replies
+// @Tags comments
+// @Description получение видимых ответов в ветке комментария, по умолчанию в порядке написания
+// @ModuleID getCommentReplies
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param commentId path string true "top-level comment id"
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments/{commentId}/replies [get]
+func (h *Handler) getCommentReplies(c *gin.Context) {
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	commentId, err := primitive.ObjectIDFromHex(c.Param("commentId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid commentId param")
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	comments, page, err := h.services.Comment.GetReplies(c, projectId, commentId, params)
+	if err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, comments, page))
+}
+
+// @Summary Create Comment
+// @Security ApiKeyAuth
+// @Tags comments
+// @Description комментарий к опубликованному проекту или ответ на комментарий (parentId). Текст до 2000 символов,
+// @Description поддерживаются **жирный**, *курсив*, `код` и ссылки [текст](https://...), HTML выводится как текст.
+// @Description Если у проекта включена премодерация, комментарий получает статус pending и виден всем после одобрения владельцем.
+// @ModuleID createComment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body domain.CommentInput true "comment text and optional parent comment id"
+// @Success 201 {object} domain.Comment
+// @Failure 400,403,404,429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments [post]
+func (h *Handler) createComment(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	var input domain.CommentInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	comment, err := h.services.Comment.Create(c, projectId, userId, input)
+	if err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// @Summary Remove Comment
+// @Security ApiKeyAuth
+// @Tags comments
+// @Description удаление комментария автором или владельцем проекта. Комментарий верхнего уровня удаляется вместе с ответами.
+// @ModuleID removeComment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param commentId path string true "comment id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments/{commentId} [delete]
+func (h *Handler) removeComment(c *gin.Context) {
+	userId, projectId, commentId, ok := getCommentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Comment.Remove(c, projectId, userId, commentId); err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Removed"})
+}
+
+// @Summary Get Comments For Moderation
+// @Security ApiKeyAuth
+// @Tags comments
+// @Description получение владельцем всех комментариев и ответов проекта, новые - первыми. status отбирает комментарии
+// @Description с одним статусом, например pending - ожидающие одобрения.
+// @ModuleID getCommentsForModeration
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param status query string false "comment status" Enums(approved, pending, hidden)
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments/moderation [get]
+func (h *Handler) getCommentsForModeration(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	status := domain.CommentStatus(c.Query("status"))
+	comments, page, err := h.services.Comment.GetForModeration(c, projectId, userId, status, params)
+	if err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, comments, page))
+}
+
+// @Summary Approve Comment
+// @Security ApiKeyAuth
+// @Tags comments
+// @Description одобрение владельцем комментария, который ждет модерации или был скрыт
+// @ModuleID approveComment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param commentId path string true "comment id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments/{commentId}/approve [post]
+func (h *Handler) approveComment(c *gin.Context) {
+	userId, projectId, commentId, ok := getCommentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Comment.Approve(c, projectId, userId, commentId); err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Approved"})
+}
+
+// @Summary Hide Comment
+// @Security ApiKeyAuth
+// @Tags comments
+// @Description скрытие комментария владельцем. Скрытый комментарий верхнего уровня скрывает всю ветку, одобрение возвращает ее.
+// @ModuleID hideComment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param commentId path string true "comment id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments/{commentId}/hide [post]
+func (h *Handler) hideComment(c *gin.Context) {
+	userId, projectId, commentId, ok := getCommentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Comment.Hide(c, projectId, userId, commentId); err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Hidden"})
+}
+
+// @Summary Set Comments Mode
+// @Security ApiKeyAuth
+// @Tags comments
+// @Description настройка комментариев проекта: open - комментарии видны сразу, premoderated - после одобрения владельцем,
+// @Description closed - новые комментарии не принимаются. Уже написанные комментарии остаются.
+// @ModuleID setCommentsMode
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body domain.CommentsModeInput true "comments mode"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/comments/mode [put]
+func (h *Handler) setCommentsMode(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	var input domain.CommentsModeInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Comment.SetMode(c, projectId, userId, input.Mode); err != nil {
+		newErrorResponse(c, commentErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Updated"})
+}
+
+// getCommentParams читает пользователя, id проекта и комментария из запроса, при ошибке отвечает сам
+func getCommentParams(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, primitive.ObjectID, bool) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	commentId, err := primitive.ObjectIDFromHex(c.Param("commentId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid commentId param")
+		return primitive.NilObjectID, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return userId, projectId, commentId, true
+}
+
+func commentErrorStatus(err error) int {
+	switch {
+	case isPaginationError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrCommentEmpty) || errors.Is(err, domain.ErrCommentTooLong) ||
+		errors.Is(err, domain.ErrInvalidCommentsMode) || errors.Is(err, domain.ErrInvalidCommentStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrCommentsClosed) || errors.Is(err, domain.ErrCommentForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrCommentRateLimited):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
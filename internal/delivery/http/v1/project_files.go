@@ -0,0 +1,270 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// @Summary Add Project File
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description загрузка изображения (PNG, JPEG, WebP, GIF, BMP, AVIF, SVG) или PDF в проект.
+// @Description Файл добавляется в конец галереи. Файлы проектов, доступных не всем, отдаются по подписанным ссылкам.
+// @ModuleID addProjectFile
+// @Accept  mpfd
+// @Produce  json
+// @Param id path string true "project id"
+// @Param file formData file true "file"
+// @Param caption formData string false "caption"
+// @Param alt formData string false "alt text"
+// @Success 201 {object} domain.File
+// @Failure 400,403,404,413,422 {object} errorResponse
+// @Failure 500,503 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/files [post]
+func (h *Handler) addProjectFile(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "empty file")
+		return
+	}
+	defer file.Close()
+	var input domain.GalleryFileInput
+	if caption, ok := c.GetPostForm("caption"); ok {
+		input.Caption = &caption
+	}
+	if alt, ok := c.GetPostForm("alt"); ok {
+		input.Alt = &alt
+	}
+
+	uploaded, err := h.services.Project.AddFile(c, projectId, userId, file, header, input)
+	if err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrGalleryTextLong) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, uploadErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, uploaded)
+}
+
+// uploadErrorStatus возвращает код ответа для ошибки загрузки файла (файлов проекта и аватара)
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedFileType) || errors.Is(err, domain.ErrInvalidFile):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrFileTooLarge) || errors.Is(err, domain.ErrImageTooLarge) || errors.Is(err, domain.ErrQuotaExceeded):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrFileInfected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrScanFailed):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary Remove Project File
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description удаление файла из проекта
+// @ModuleID removeProjectFile
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param name path string true "file name"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/files/{name} [delete]
+func (h *Handler) removeProjectFile(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Project.RemoveFile(c, projectId, userId, c.Param("name")); err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrFileNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Removed"})
+}
+
+// @Summary Update Project File
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description изменение подписи и альтернативного текста файла галереи. Поля, которых нет в запросе, не меняются.
+// @ModuleID updateProjectFile
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param name path string true "file name"
+// @Param input body domain.GalleryFileInput true "caption and alt text"
+// @Success 200 {object} domain.File
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/files/{name} [patch]
+func (h *Handler) updateProjectFile(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	var input domain.GalleryFileInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	file, err := h.services.Project.UpdateFile(c, projectId, userId, c.Param("name"), input)
+	if err != nil {
+		newErrorResponse(c, galleryErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, file)
+}
+
+// @Summary Reorder Project Files
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description изменение порядка файлов галереи: names перечисляет имена всех файлов проекта в новом порядке
+// @ModuleID reorderProjectFiles
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body domain.GalleryOrderInput true "file names"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/files/order [put]
+func (h *Handler) reorderProjectFiles(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	var input domain.GalleryOrderInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Project.ReorderFiles(c, projectId, userId, input.Names); err != nil {
+		newErrorResponse(c, galleryErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Reordered"})
+}
+
+// @Summary Set Project Cover
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description выбор изображения галереи обложкой проекта, она показывается в списках проектов
+// @ModuleID setProjectCover
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body domain.CoverInput true "image name"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/cover [put]
+func (h *Handler) setProjectCover(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	var input domain.CoverInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Project.SetCover(c, projectId, userId, input.Name); err != nil {
+		newErrorResponse(c, galleryErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Updated"})
+}
+
+// @Summary Reset Project Cover
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description сброс выбранной обложки: обложкой становится первое изображение галереи
+// @ModuleID resetProjectCover
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/cover [delete]
+func (h *Handler) resetProjectCover(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Project.SetCover(c, projectId, userId, ""); err != nil {
+		newErrorResponse(c, galleryErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Updated"})
+}
+
+func galleryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProjectForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrFileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidFileOrder) || errors.Is(err, domain.ErrCoverNotImage) || errors.Is(err, domain.ErrGalleryTextLong):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
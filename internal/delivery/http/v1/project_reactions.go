@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initProjectReactionsRoutes(self *gin.RouterGroup) {
+	self.GET("/bookmarks", h.getBookmarks)
+	self.GET("/:id/reactions", h.getProjectReactions)
+	self.PUT("/:id/like", h.likeProject)
+	self.DELETE("/:id/like", h.unlikeProject)
+	self.PUT("/:id/bookmark", h.bookmarkProject)
+	self.DELETE("/:id/bookmark", h.unbookmarkProject)
+}
+
+// @Summary Get Bookmarks
+// @Security ApiKeyAuth
+// @Tags reactions
+// @Description получение проектов из закладок текущего пользователя, последние добавленные - первыми.
+// @Description Скрытые и удаленные проекты пропускаются, поэтому страница может быть короче limit.
+// @ModuleID getBookmarks
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Success 200 {object} pageResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/bookmarks [get]
+func (h *Handler) getBookmarks(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	projects, page, err := h.services.Reaction.GetBookmarks(c, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, projects, page))
+}
+
+// @Summary Get Project Reactions
+// @Security ApiKeyAuth
+// @Tags reactions
+// @Description отметки текущего пользователя на проекте: лайк и закладка
+// @ModuleID getProjectReactions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} domain.ProjectReactions
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/reactions [get]
+func (h *Handler) getProjectReactions(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	reactions, err := h.services.Reaction.GetReactions(c, projectId, userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, reactions)
+}
+
+// @Summary Like Project
+// @Security ApiKeyAuth
+// @Tags reactions
+// @Description лайк чужого опубликованного проекта, повторный лайк ничего не меняет
+// @ModuleID likeProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/like [put]
+func (h *Handler) likeProject(c *gin.Context) {
+	h.setReaction(c, domain.ReactionLike, true)
+}
+
+// @Summary Unlike Project
+// @Security ApiKeyAuth
+// @Tags reactions
+// @Description снятие лайка, если лайка не было, ничего не меняется
+// @ModuleID unlikeProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/like [delete]
+func (h *Handler) unlikeProject(c *gin.Context) {
+	h.setReaction(c, domain.ReactionLike, false)
+}
+
+// @Summary Bookmark Project
+// @Security ApiKeyAuth
+// @Tags reactions
+// @Description добавление чужого опубликованного проекта в закладки, повторное добавление ничего не меняет
+// @ModuleID bookmarkProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/bookmark [put]
+func (h *Handler) bookmarkProject(c *gin.Context) {
+	h.setReaction(c, domain.ReactionBookmark, true)
+}
+
+// @Summary Unbookmark Project
+// @Security ApiKeyAuth
+// @Tags reactions
+// @Description удаление проекта из закладок, если его там не было, ничего не меняется
+// @ModuleID unbookmarkProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/bookmark [delete]
+func (h *Handler) unbookmarkProject(c *gin.Context) {
+	h.setReaction(c, domain.ReactionBookmark, false)
+}
+
+// setReaction ставит (on) или снимает отметку kind с проекта из запроса
+func (h *Handler) setReaction(c *gin.Context, kind domain.ReactionKind, on bool) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	var err error
+	if on {
+		err = h.services.Reaction.React(c, projectId, userId, kind)
+	} else {
+		err = h.services.Reaction.Unreact(c, projectId, userId, kind)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrProjectNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, domain.ErrOwnProjectReaction):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if on {
+		c.JSON(http.StatusOK, statusResponse{"Added"})
+		return
+	}
+	c.JSON(http.StatusOK, statusResponse{"Removed"})
+}
@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// заголовки протокола загрузки частями, названия совпадают с протоколом tus
+const (
+	uploadOffsetHeader = "Upload-Offset"
+	uploadLengthHeader = "Upload-Length"
+)
+
+func (h *Handler) initProjectUploadsRoutes(self *gin.RouterGroup) {
+	self.POST("/:id/uploads", h.createUpload)
+	self.GET("/:id/uploads/:uploadId", h.getUpload)
+	self.PATCH("/:id/uploads/:uploadId", h.writeUploadChunk)
+	self.POST("/:id/uploads/:uploadId/complete", h.completeUpload)
+	self.DELETE("/:id/uploads/:uploadId", h.cancelUpload)
+}
+
+// @Summary Create Upload
+// @Security ApiKeyAuth
+// @Tags uploads
+// @Description начало загрузки большого файла в проект частями. Кроме изображений и PDF принимаются видео (MP4, WebM)
+// @Description и архивы ZIP. Части отправляются запросами PATCH, после последней части загрузка завершается запросом complete.
+// @ModuleID createUpload
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body domain.UploadSessionInput true "file name and size"
+// @Success 201 {object} domain.UploadSession
+// @Failure 400,403,404,413 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/uploads [post]
+func (h *Handler) createUpload(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	var input domain.UploadSessionInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	session, err := h.services.Uploads.Create(c, projectId, userId, input)
+	if err != nil {
+		newErrorResponse(c, uploadSessionErrorStatus(err), err.Error())
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+session.Id)
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusCreated, session)
+}
+
+// @Summary Get Upload
+// @Security ApiKeyAuth
+// @Tags uploads
+// @Description состояние загрузки: offset - с какой позиции отправлять следующую часть
+// @ModuleID getUpload
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param uploadId path string true "upload id"
+// @Success 200 {object} domain.UploadSession
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/uploads/{uploadId} [get]
+func (h *Handler) getUpload(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.services.Uploads.Get(c, projectId, userId, c.Param("uploadId"))
+	if err != nil {
+		newErrorResponse(c, uploadSessionErrorStatus(err), err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusOK, session)
+}
+
+// @Summary Write Upload Chunk
+// @Security ApiKeyAuth
+// @Tags uploads
+// @Description отправка части файла. Тело запроса - байты части, заголовок Upload-Offset - ее позиция в файле,
+// @Description она должна совпадать с offset загрузки. Если запрос оборвался, полученные байты сохраняются:
+// @Description узнайте offset запросом GET и продолжите с него.
+// @ModuleID writeUploadChunk
+// @Accept  octet-stream
+// @Produce  json
+// @Param id path string true "project id"
+// @Param uploadId path string true "upload id"
+// @Param Upload-Offset header int true "chunk offset"
+// @Success 200 {object} domain.UploadSession
+// @Failure 400,404,409,413 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/uploads/{uploadId} [patch]
+func (h *Handler) writeUploadChunk(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		newErrorResponse(c, http.StatusBadRequest, "invalid Upload-Offset header")
+		return
+	}
+
+	session, err := h.services.Uploads.WriteChunk(c, projectId, userId, c.Param("uploadId"), offset, c.Request.Body)
+	if session != nil {
+		setUploadHeaders(c, session)
+	}
+	if err != nil {
+		newErrorResponse(c, uploadSessionErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// @Summary Complete Upload
+// @Security ApiKeyAuth
+// @Tags uploads
+// @Description завершение загрузки: файл сохраняется в хранилище и добавляется в проект
+// @ModuleID completeUpload
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param uploadId path string true "upload id"
+// @Success 201 {object} domain.File
+// @Failure 400,403,404,409,413,422 {object} errorResponse
+// @Failure 500,503 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/uploads/{uploadId}/complete [post]
+func (h *Handler) completeUpload(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	uploaded, err := h.services.Uploads.Complete(c, projectId, userId, c.Param("uploadId"))
+	if err != nil {
+		newErrorResponse(c, uploadSessionErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, uploaded)
+}
+
+// @Summary Cancel Upload
+// @Security ApiKeyAuth
+// @Tags uploads
+// @Description отмена загрузки и удаление полученных частей
+// @ModuleID cancelUpload
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param uploadId path string true "upload id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404,409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/uploads/{uploadId} [delete]
+func (h *Handler) cancelUpload(c *gin.Context) {
+	userId, projectId, ok := getProjectParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Uploads.Cancel(c, projectId, userId, c.Param("uploadId")); err != nil {
+		newErrorResponse(c, uploadSessionErrorStatus(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Canceled"})
+}
+
+// getProjectParams читает пользователя и id проекта из запроса, при ошибке отвечает сам
+func getProjectParams(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return userId, projectId, true
+}
+
+func setUploadHeaders(c *gin.Context, session *domain.UploadSession) {
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(session.Size, 10))
+}
+
+func uploadSessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProjectForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrUploadOffsetMismatch) || errors.Is(err, domain.ErrUploadBusy) || errors.Is(err, domain.ErrUploadIncomplete):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrUploadChunkTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return uploadErrorStatus(err)
+}
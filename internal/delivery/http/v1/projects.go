@@ -1,14 +1,581 @@
 package v1
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 func (h *Handler) initProjectsRoutes(api *gin.RouterGroup) {
 	project := api.Group("/projects")
 	{
-		project.GET("/", h.notImplemented)
-		project.POST("/", h.notImplemented)
-		project.GET("/:id", h.notImplemented)
-		project.PUT("/:id", h.notImplemented)
-		project.DELETE("/:id", h.notImplemented)
+		project.GET("/", h.getProjects)
+		project.GET("/:id", h.getProjectById)
+
+		self := project.Group("/", h.userIdentity)
+		{
+			self.POST("/", h.createProject)
+			self.PUT("/:id", h.updateProject)
+			self.DELETE("/:id", h.removeProject)
+			self.GET("/self", h.getSelfProjects)
+			self.GET("/self/:id", h.getSelfProjectById)
+			self.GET("/drafts", h.getDrafts)
+			self.GET("/trash", h.getTrashProjects)
+			self.POST("/trash/:id/restore", h.restoreProject)
+			self.POST("/:id/publish", h.publishProject)
+			self.POST("/:id/unpublish", h.unpublishProject)
+			self.PUT("/:id/schedule", h.schedulePublish)
+			self.DELETE("/:id/schedule", h.cancelScheduledPublish)
+			self.POST("/:id/files", h.addProjectFile)
+			self.DELETE("/:id/files/:name", h.removeProjectFile)
+			self.PATCH("/:id/files/:name", h.updateProjectFile)
+			self.PUT("/:id/files/order", h.reorderProjectFiles)
+			self.PUT("/:id/cover", h.setProjectCover)
+			self.DELETE("/:id/cover", h.resetProjectCover)
+			h.initProjectUploadsRoutes(self)
+			h.initProjectCollaboratorsRoutes(self)
+			h.initProjectReactionsRoutes(self)
+			h.initProjectCommentsRoutes(project, self)
+			self.GET("/:id/stats", h.getProjectStats)
+			h.initRevisionsRoutes(self)
+		}
+	}
+}
+
+// @Summary Get Projects
+// @Tags projects
+// @Description получение списка публичных проектов пользователя
+// @ModuleID getProjects
+// @Accept  json
+// @Produce  json
+// @Param userId query string true "user id"
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key, likes - most liked first with order=desc" Enums(createdAt, updatedAt, name, order, likes)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/ [get]
+func (h *Handler) getProjects(c *gin.Context) {
+	userId, err := primitive.ObjectIDFromHex(c.Query("userId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid userId param")
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	projects, page, err := h.services.Project.GetProjects(c, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, projects, page))
+}
+
+// @Summary Get Project By Id
+// @Tags projects
+// @Description получение проекта, просмотр учитывается в статистике проекта
+// @ModuleID getProjectById
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} domain.Project
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id} [get]
+func (h *Handler) getProjectById(c *gin.Context) {
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	project, err := h.services.Project.GetProjectById(c, projectId)
+	if err != nil {
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordView(c, domain.ViewProject, project.Id, project.UserId)
+	c.JSON(http.StatusOK, project)
+}
+
+// @Summary Get Self Projects
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description получение списка всех проектов текущего пользователя
+// @ModuleID getSelfProjects
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt, updatedAt, name, order)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/self [get]
+func (h *Handler) getSelfProjects(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	projects, page, err := h.services.Project.GetSelfProjects(c, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, projects, page))
+}
+
+// @Summary Get Drafts
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description получение списка черновиков текущего пользователя
+// @ModuleID getDrafts
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt, updatedAt, name, order)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/drafts [get]
+func (h *Handler) getDrafts(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	projects, page, err := h.services.Project.GetDrafts(c, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, projects, page))
+}
+
+type ProjectInput struct {
+	Name        string            `json:"name" binding:"required,min=2,max=128"`
+	Description string            `json:"description" binding:"max=10000"`
+	Tags        []string          `json:"tags" binding:"max=20,dive,min=1,max=32"`
+	Access      domain.AccessType `json:"access" binding:"omitempty,oneof=all link nobody"`
+	Order       int               `json:"order"`
+}
+
+type idResponse struct {
+	Id string `json:"id"`
+}
+
+// @Summary Create Project
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description создание проекта
+// @ModuleID createProject
+// @Accept  json
+// @Produce  json
+// @Param input body ProjectInput true "project info"
+// @Success 201 {object} idResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/ [post]
+func (h *Handler) createProject(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
 	}
+	var input ProjectInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	id, err := h.services.Project.CreateProject(c, domain.ProjectInput{
+		UserId:      userId,
+		Name:        input.Name,
+		Description: input.Description,
+		Tags:        input.Tags,
+		Files:       []domain.File{},
+		Access:      input.Access,
+		Order:       input.Order,
+	})
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, idResponse{id.Hex()})
+}
+
+type ProjectUpdateInput struct {
+	Name        string            `json:"name" binding:"omitempty,min=2,max=128"`
+	Description string            `json:"description" binding:"max=10000"`
+	Tags        []string          `json:"tags" binding:"max=20,dive,min=1,max=32"`
+	Access      domain.AccessType `json:"access" binding:"omitempty,oneof=all link nobody"`
+	Order       int               `json:"order"`
+}
+
+// @Summary Update Project
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description обновление проекта
+// @ModuleID updateProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body ProjectUpdateInput true "project info"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id} [put]
+func (h *Handler) updateProject(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var input ProjectUpdateInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	err = h.services.Project.UpdateProject(c, projectId, userId, domain.SelfProject{
+		Name:        input.Name,
+		Description: input.Description,
+		Tags:        input.Tags,
+		Access:      input.Access,
+		Order:       input.Order,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Updated"})
+}
+
+// @Summary Remove Project
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description перемещение проекта в корзину
+// @ModuleID removeProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id} [delete]
+func (h *Handler) removeProject(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Project.RemoveProject(c, projectId, userId); err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Removed"})
+}
+
+// @Summary Get Self Project By Id
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description получение проекта текущего пользователя вместе с черновиком
+// @ModuleID getSelfProjectById
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} domain.SelfProject
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/self/{id} [get]
+func (h *Handler) getSelfProjectById(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	project, err := h.services.Project.GetSelfProjectById(c, projectId, userId)
+	if err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// @Summary Publish Project
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description публикация проекта или его черновика. Опубликованный проект без черновика не публикуется повторно.
+// @ModuleID publishProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/publish [post]
+func (h *Handler) publishProject(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Project.PublishProject(c, projectId, userId); err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNothingToPublish) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Published"})
+}
+
+// @Summary Unpublish Project
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description снятие проекта с публикации
+// @ModuleID unpublishProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/unpublish [post]
+func (h *Handler) unpublishProject(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Project.UnpublishProject(c, projectId, userId); err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Unpublished"})
+}
+
+type ScheduleInput struct {
+	PublishAt time.Time `json:"publishAt" binding:"required"`
+}
+
+// @Summary Schedule Publish
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description планирование публикации проекта
+// @ModuleID schedulePublish
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param input body ScheduleInput true "publish time"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/schedule [put]
+func (h *Handler) schedulePublish(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var input ScheduleInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Project.SchedulePublish(c, projectId, userId, &input.PublishAt); err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidPublishAt) || errors.Is(err, domain.ErrNothingToPublish) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Scheduled"})
+}
+
+// @Summary Cancel Scheduled Publish
+// @Security ApiKeyAuth
+// @Tags projects
+// @Description отмена запланированной публикации
+// @ModuleID cancelScheduledPublish
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/schedule [delete]
+func (h *Handler) cancelScheduledPublish(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Project.SchedulePublish(c, projectId, userId, nil); err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Canceled"})
 }
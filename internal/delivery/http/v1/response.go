@@ -1,7 +1,10 @@
 package v1
 
 import (
+	"net/url"
+
 	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,6 +16,26 @@ type statusResponse struct {
 	Status string `json:"status"`
 }
 
+type pageResponse struct {
+	Data interface{} `json:"data"`
+	Next string      `json:"next,omitempty"`
+	Prev string      `json:"prev,omitempty"`
+}
+
+func newPageResponse(c *gin.Context, data interface{}, page *pagination.Page) pageResponse {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	u := url.URL{Scheme: scheme, Host: c.Request.Host, Path: c.Request.URL.Path, RawQuery: c.Request.URL.RawQuery}
+
+	next, prev := page.Links(&u)
+	return pageResponse{Data: data, Next: next, Prev: prev}
+}
+
 func newErrorResponse(c *gin.Context, statusCode int, message string) {
 	logger.Errorf("Url: %s | ClientIp: %s | ErrorResponse: %s", c.Request.URL, c.ClientIP(), message)
 	c.AbortWithStatusJSON(statusCode, errorResponse{message})
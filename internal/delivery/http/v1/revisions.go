@@ -0,0 +1,219 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) initRevisionsRoutes(project *gin.RouterGroup) {
+	project.GET("/:id/revisions", h.getRevisions)
+	project.GET("/:id/revisions/diff", h.diffRevisions)
+	project.GET("/:id/revisions/:revisionId", h.getRevision)
+	project.POST("/:id/revisions/:revisionId/restore", h.restoreRevision)
+}
+
+// @Summary Get Revisions
+// @Security ApiKeyAuth
+// @Tags revisions
+// @Description получение истории изменений проекта
+// @ModuleID getRevisions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/revisions [get]
+func (h *Handler) getRevisions(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	revisions, page, err := h.services.Project.GetRevisions(c, projectId, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, revisions, page))
+}
+
+// @Summary Get Revision
+// @Security ApiKeyAuth
+// @Tags revisions
+// @Description получение ревизии проекта
+// @ModuleID getRevision
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param revisionId path string true "revision id"
+// @Success 200 {object} domain.Revision
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/revisions/{revisionId} [get]
+func (h *Handler) getRevision(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	revisionId, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid revisionId param")
+		return
+	}
+
+	revision, err := h.services.Project.GetRevision(c, projectId, userId, revisionId)
+	if err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrRevisionNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// @Summary Diff Revisions
+// @Security ApiKeyAuth
+// @Tags revisions
+// @Description сравнение двух ревизий проекта по полям
+// @ModuleID diffRevisions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param from query string true "revision id"
+// @Param to query string true "revision id"
+// @Success 200 {object} domain.RevisionDiff
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/revisions/diff [get]
+func (h *Handler) diffRevisions(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	fromId, err := primitive.ObjectIDFromHex(c.Query("from"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid from param")
+		return
+	}
+	toId, err := primitive.ObjectIDFromHex(c.Query("to"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid to param")
+		return
+	}
+
+	diff, err := h.services.Project.DiffRevisions(c, projectId, userId, fromId, toId)
+	if err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrRevisionNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// @Summary Restore Revision
+// @Security ApiKeyAuth
+// @Tags revisions
+// @Description восстановление проекта из ревизии
+// @ModuleID restoreRevision
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Param revisionId path string true "revision id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/{id}/revisions/{revisionId}/restore [post]
+func (h *Handler) restoreRevision(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	revisionId, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid revisionId param")
+		return
+	}
+
+	if err := h.services.Project.RestoreRevision(c, projectId, userId, revisionId); err != nil {
+		if errors.Is(err, domain.ErrProjectForbidden) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrRevisionNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Restored"})
+}
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) initSearchRoutes(api *gin.RouterGroup) {
+	api.GET("/search", h.search)
+}
+
+// @Summary Search
+// @Tags search
+// @Description полнотекстовый поиск по публичным проектам и пользователям
+// @ModuleID search
+// @Accept  json
+// @Produce  json
+// @Param q query string true "search query"
+// @Param type query string false "comma separated result types" Enums(project, user)
+// @Param tags query string false "comma separated project tags"
+// @Param userId query string false "only results of this user"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset, up to 1000"
+// @Success 200 {object} domain.SearchResults
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /search [get]
+func (h *Handler) search(c *gin.Context) {
+	query := domain.SearchQuery{Query: c.Query("q")}
+	if query.Query == "" {
+		newErrorResponse(c, http.StatusBadRequest, "empty q param")
+		return
+	}
+
+	for _, t := range splitQuery(c.Query("type")) {
+		searchType := domain.SearchType(t)
+		if searchType != domain.SearchProject && searchType != domain.SearchUser {
+			newErrorResponse(c, http.StatusBadRequest, "invalid type param")
+			return
+		}
+		query.Types = append(query.Types, searchType)
+	}
+	query.Tags = splitQuery(c.Query("tags"))
+
+	if userId := c.Query("userId"); userId != "" {
+		id, err := primitive.ObjectIDFromHex(userId)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid userId param")
+			return
+		}
+		query.UserId = id
+	}
+	if limit := c.Query("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid limit param")
+			return
+		}
+		query.Limit = l
+	}
+	if offset := c.Query("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid offset param")
+			return
+		}
+		query.Offset = o
+	}
+
+	results, err := h.services.Search.Search(c, query)
+	if err != nil {
+		if errors.Is(err, domain.ErrEmptySearchQuery) || errors.Is(err, domain.ErrSearchOffsetTooLarge) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func splitQuery(value string) []string {
+	var res []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res
+}
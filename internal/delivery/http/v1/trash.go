@@ -0,0 +1,162 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// @Summary Get Trash Projects
+// @Security ApiKeyAuth
+// @Tags trash
+// @Description получение списка удаленных проектов текущего пользователя
+// @ModuleID getTrashProjects
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(deletedAt, createdAt, updatedAt, name, order)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/trash [get]
+func (h *Handler) getTrashProjects(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	projects, page, err := h.services.Trash.GetProjects(c, userId, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, projects, page))
+}
+
+// @Summary Restore Project
+// @Security ApiKeyAuth
+// @Tags trash
+// @Description восстановление проекта из корзины
+// @ModuleID restoreProject
+// @Accept  json
+// @Produce  json
+// @Param id path string true "project id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404,410 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /projects/trash/{id}/restore [post]
+func (h *Handler) restoreProject(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Trash.RestoreProject(c, projectId, userId); err != nil {
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTrashExpired) {
+			newErrorResponse(c, http.StatusGone, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Restored"})
+}
+
+// @Summary Get Trash Users
+// @Security ApiKeyAuth
+// @Tags trash
+// @Description получение списка удаленных пользователей
+// @ModuleID getTrashUsers
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(deletedAt, createdAt, updatedAt, name)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/trash [get]
+func (h *Handler) getTrashUsers(c *gin.Context) {
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	users, page, err := h.services.Trash.GetUsers(c, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, users, page))
+}
+
+// @Summary Restore User
+// @Security ApiKeyAuth
+// @Tags trash
+// @Description восстановление пользователя и удаленных вместе с ним проектов
+// @ModuleID restoreUser
+// @Accept  json
+// @Produce  json
+// @Param id path string true "user id"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404,410 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/trash/{id}/restore [post]
+func (h *Handler) restoreUser(c *gin.Context) {
+	userId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Trash.RestoreUser(c, userId); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTrashExpired) {
+			newErrorResponse(c, http.StatusGone, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Restored"})
+}
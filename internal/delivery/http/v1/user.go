@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"mime/multipart"
 	"net/http"
 
@@ -13,31 +14,69 @@ func (h *Handler) initUserRoutes(api *gin.RouterGroup) {
 	user := api.Group("/user")
 	{
 		user.GET("/all", h.getAllUsers)
+		user.GET("/stats", h.userIdentity, h.getProfileStats)
+		user.GET("/storage", h.userIdentity, h.getStorageUsage)
+		user.GET("/storage/orphans", h.userIdentity, h.adminAccess, h.getOrphanFiles)
+		user.GET("/storage/quarantine", h.userIdentity, h.adminAccess, h.getQuarantine)
+		user.DELETE("/storage/quarantine/:id", h.userIdentity, h.adminAccess, h.removeQuarantinedFile)
 		user.GET("/:id", h.getUserById)
-		user.PUT("/:id", h.updateUserById)
-		user.DELETE("/:id", h.removeUserById)
+		user.PUT("/:id", h.userIdentity, h.selfAccess, h.updateUserById)
+		user.DELETE("/:id", h.userIdentity, h.selfAccess, h.removeUserById)
+
+		h.initNotificationsRoutes(user)
+		h.initContactRoutes(user)
+
+		trash := user.Group("/trash", h.userIdentity, h.adminAccess)
+		{
+			trash.GET("", h.getTrashUsers)
+			trash.POST("/:id/restore", h.restoreUser)
+		}
 	}
 }
 
+// @Summary Get All Users
+// @Tags user
+// @Description получение списка публичных профилей пользователей
+// @ModuleID getAllUsers
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt, updatedAt, name)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/all [get]
 func (h *Handler) getAllUsers(c *gin.Context) {
-	users, err := h.services.User.GetAllUsers(c)
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	users, page, err := h.services.User.GetAllUsers(c, params)
 	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, newPageResponse(c, users, page))
 }
 
 // @Summary Get User By Id
 // @Security ApiKeyAuth
 // @Tags user
-// @Description получение данных пользователя
+// @Description публичный профиль пользователя без email, просмотр учитывается в статистике профиля
 // @ModuleID getUserById
 // @Accept  json
 // @Produce  json
 // @Param id path string true "user id"
-// @Success 200 {object} domain.User
+// @Success 200 {object} domain.PublicUser
 // @Failure 400,404 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
@@ -60,6 +99,7 @@ func (h *Handler) getUserById(c *gin.Context) {
 		return
 	}
 
+	h.recordView(c, domain.ViewProfile, user.Id, user.Id)
 	c.JSON(http.StatusOK, user)
 }
 
@@ -73,18 +113,137 @@ type UserUpdateInput struct {
 	Avatar      *multipart.FileHeader `form:"avatar" json:"avatar"`
 }
 
+// @Summary Get Storage Usage
+// @Security ApiKeyAuth
+// @Tags user
+// @Description место в хранилище, занятое файлами текущего пользователя, и его квота (0 - без ограничений)
+// @ModuleID getStorageUsage
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} domain.StorageUsage
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/storage [get]
+func (h *Handler) getStorageUsage(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	usage, err := h.services.File.Usage(c, userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// @Summary Get Orphaned Files
+// @Security ApiKeyAuth
+// @Tags user
+// @Description файлы в хранилище, на которые не ссылаются ни пользователи, ни проекты, ни ревизии. Ничего не удаляет:
+// @Description удаляет их фоновая задача по истечении fileStorage.gc.gracePeriod.
+// @ModuleID getOrphanFiles
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} domain.OrphanReport
+// @Failure 401,403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/storage/orphans [get]
+func (h *Handler) getOrphanFiles(c *gin.Context) {
+	report, err := h.services.FilesGC.Report(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Get Quarantine
+// @Security ApiKeyAuth
+// @Tags user
+// @Description файлы, в которых антивирус нашел угрозу при загрузке. Такие файлы не сохраняются в проекты и профили.
+// @ModuleID getQuarantine
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "page size"
+// @Param cursor query string false "page cursor"
+// @Param sort query string false "sort key" Enums(createdAt, size)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Success 200 {object} pageResponse
+// @Failure 400,401,403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/storage/quarantine [get]
+func (h *Handler) getQuarantine(c *gin.Context) {
+	params, ok := getPagination(c)
+	if !ok {
+		return
+	}
+
+	files, page, err := h.services.Quarantine.GetAll(c, params)
+	if err != nil {
+		if isPaginationError(err) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(c, files, page))
+}
+
+// @Summary Remove Quarantined File
+// @Security ApiKeyAuth
+// @Tags user
+// @Description удаление файла из карантина вместе с объектом в хранилище
+// @ModuleID removeQuarantinedFile
+// @Accept  json
+// @Produce  json
+// @Param id path string true "quarantined file id"
+// @Success 200 {object} statusResponse
+// @Failure 400,401,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /user/storage/quarantine/{id} [delete]
+func (h *Handler) removeQuarantinedFile(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Quarantine.Remove(c, id); err != nil {
+		if errors.Is(err, domain.ErrQuarantinedFileNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"Removed"})
+}
+
 // @Summary Update User By Id
 // @Security ApiKeyAuth
 // @Tags user
-// @Description обновление данных пользователя по его id
+// @Description обновление данных пользователя по его id. Пользователь меняет только себя, администратор - любого.
+// @Description Роль меняет только администратор.
 // @ModuleID updateUserById
 // @Accept  json
 // @Produce  json
 // @Param id path string true "user id"
 // @Param input body UserUpdateInput true "user info"
 // @Success 200 {object} statusResponse
-// @Failure 400,404 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400,401,403,404,413,422 {object} errorResponse
+// @Failure 500,503 {object} errorResponse
 // @Failure default {object} errorResponse
 // @Router /user/{id} [put]
 func (h *Handler) updateUserById(c *gin.Context) {
@@ -103,21 +262,27 @@ func (h *Handler) updateUserById(c *gin.Context) {
 		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	if input.Role != "" && c.GetString(userRoleCtx) != adminRole {
+		newErrorResponse(c, http.StatusForbidden, "access forbidden")
+		return
+	}
 
-	var avatar *domain.File
+	var avatar domain.File
 	if input.IsDelAvatar {
-		if err := h.services.File.Remove(c, id+"/avatar", "avatar"); err != nil {
+		if err := h.services.File.Remove(c, id+"/avatar/", ""); err != nil {
 			newErrorResponse(c, http.StatusBadRequest, err.Error())
 			return
 		}
 	} else {
 		file, header, err := c.Request.FormFile("avatar")
 		if err == nil {
-			avatar, err = h.services.File.Upload(c, file, header, id+"/avatar", "avatar")
+			defer file.Close()
+			uploaded, err := h.services.File.Upload(c, userId, file, header, id+"/avatar", "avatar", false)
 			if err != nil {
-				newErrorResponse(c, http.StatusBadRequest, err.Error())
+				newErrorResponse(c, uploadErrorStatus(err), err.Error())
 				return
 			}
+			avatar = *uploaded
 		}
 	}
 
@@ -126,7 +291,7 @@ func (h *Handler) updateUserById(c *gin.Context) {
 		Email:    input.Email,
 		Password: input.Password,
 		Role:     input.Role,
-		Avatar:   *avatar,
+		Avatar:   avatar,
 	})
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
@@ -139,13 +304,13 @@ func (h *Handler) updateUserById(c *gin.Context) {
 // @Summary Remove User By Id
 // @Security ApiKeyAuth
 // @Tags user
-// @Description удаление пользователя и всех его данных
+// @Description перемещение пользователя и всех его проектов в корзину. Пользователь удаляет только себя, администратор - любого.
 // @ModuleID removeUserById
 // @Accept  json
 // @Produce  json
 // @Param id path string true "user id"
 // @Success 200 {object} statusResponse
-// @Failure 400,404 {object} errorResponse
+// @Failure 400,401,403,404 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
 // @Router /user/{id} [delete]
@@ -161,11 +326,11 @@ func (h *Handler) removeUserById(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.File.Remove(c, id, ""); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
-		return
-	}
 	if err = h.services.User.RemoveById(c, userId); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
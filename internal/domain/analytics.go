@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ViewTarget - что посмотрел посетитель: публичный проект или профиль пользователя
+type ViewTarget string
+
+const (
+	ViewProject ViewTarget = "project"
+	ViewProfile ViewTarget = "profile"
+)
+
+// разрезы дневной статистики, пустой разрез - итог за день
+const (
+	ViewTotal    = ""
+	ViewReferrer = "referrer"
+	ViewCountry  = "country"
+)
+
+// View - просмотр, каким его видит обработчик запроса. IP и User-Agent не сохраняются,
+// из них считается только обезличенный идентификатор посетителя на один день.
+type View struct {
+	Target    ViewTarget
+	TargetId  primitive.ObjectID
+	OwnerId   primitive.ObjectID
+	IP        string
+	UserAgent string
+	Referrer  string
+}
+
+// DailyView - счетчики просмотров за день в одном разрезе: всего, по источнику перехода или по стране
+type DailyView struct {
+	Id        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	Target    ViewTarget         `json:"-" bson:"target"`
+	TargetId  primitive.ObjectID `json:"-" bson:"targetId"`
+	OwnerId   primitive.ObjectID `json:"-" bson:"ownerId"`
+	Day       time.Time          `json:"day" bson:"day"`
+	Dimension string             `json:"-" bson:"dimension"`
+	Value     string             `json:"-" bson:"value"`
+	Views     int64              `json:"views" bson:"views"`
+	Visitors  int64              `json:"visitors" bson:"visitors"`
+}
+
+// ViewCount - просмотры из одного источника или одной страны за период
+type ViewCount struct {
+	Value    string `json:"value" bson:"_id"`
+	Views    int64  `json:"views" bson:"views"`
+	Visitors int64  `json:"visitors" bson:"visitors"`
+}
+
+// ViewStats - статистика просмотров за период с From по To включительно. Посетитель, заходивший
+// в разные дни, учитывается в Visitors каждого дня.
+type ViewStats struct {
+	From      time.Time   `json:"from"`
+	To        time.Time   `json:"to"`
+	Views     int64       `json:"views"`
+	Visitors  int64       `json:"visitors"`
+	Series    []DailyView `json:"series"`
+	Referrers []ViewCount `json:"referrers"`
+	Countries []ViewCount `json:"countries"`
+}
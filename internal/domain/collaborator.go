@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CollaboratorRole - права соавтора на проект. Редактор меняет содержимое и файлы, наблюдатель видит
+// черновики, ревизии и файлы закрытого проекта. Публикация, доступ, удаление и соавторы остаются за владельцем.
+type CollaboratorRole string
+
+const (
+	RoleEditor CollaboratorRole = "editor"
+	RoleViewer CollaboratorRole = "viewer"
+)
+
+// Valid сообщает, что роль можно выдать соавтору
+func (r CollaboratorRole) Valid() bool {
+	return r == RoleEditor || r == RoleViewer
+}
+
+// Collaborator - соавтор проекта. Проект показывается в портфолио каждого соавтора.
+type Collaborator struct {
+	UserId  primitive.ObjectID `json:"userId" bson:"userId"`
+	Role    CollaboratorRole   `json:"role" bson:"role"`
+	AddedAt time.Time          `json:"addedAt" bson:"addedAt"`
+}
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+)
+
+// Invitation - приглашение в соавторы. Соавтором пользователь становится, только приняв его.
+type Invitation struct {
+	Id          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ProjectId   primitive.ObjectID `json:"projectId" bson:"projectId"`
+	ProjectName string             `json:"projectName" bson:"projectName"`
+	InviterId   primitive.ObjectID `json:"inviterId" bson:"inviterId"`
+	UserId      primitive.ObjectID `json:"userId" bson:"userId"`
+	Role        CollaboratorRole   `json:"role" bson:"role"`
+	Status      InvitationStatus   `json:"status" bson:"status"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	RespondedAt *time.Time         `json:"respondedAt,omitempty" bson:"respondedAt,omitempty"`
+}
+
+type InvitationInput struct {
+	Email string           `json:"email" binding:"required"`
+	Role  CollaboratorRole `json:"role" binding:"required"`
+}
+
+type CollaboratorRoleInput struct {
+	Role CollaboratorRole `json:"role" binding:"required"`
+}
+
+// ProjectAuthor - автор проекта для публичной страницы: владелец или соавтор
+type ProjectAuthor struct {
+	Id      primitive.ObjectID `json:"id" bson:"_id"`
+	Name    string             `json:"name" bson:"name"`
+	UserUrl string             `json:"userUrl" bson:"userUrl"`
+	Avatar  File               `json:"avatar" bson:"avatar"`
+	// Role - роль соавтора, у владельца пустая
+	Role CollaboratorRole `json:"role,omitempty" bson:"-"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CommentsMode - настройка комментариев проекта. В режиме premoderated новые комментарии видны всем
+// только после одобрения владельцем, в режиме closed новые комментарии не принимаются.
+type CommentsMode string
+
+const (
+	CommentsOpen         CommentsMode = "open"
+	CommentsPremoderated CommentsMode = "premoderated"
+	CommentsClosed       CommentsMode = "closed"
+)
+
+func (m CommentsMode) Valid() bool {
+	return m == CommentsOpen || m == CommentsPremoderated || m == CommentsClosed
+}
+
+type CommentStatus string
+
+const (
+	CommentApproved CommentStatus = "approved"
+	CommentPending  CommentStatus = "pending"
+	CommentHidden   CommentStatus = "hidden"
+)
+
+// Comment - комментарий к проекту. Обсуждение двухуровневое: у комментария верхнего уровня ThreadId пустой,
+// ответы на него и на другие ответы в той же ветке хранят его id в ThreadId, а в ParentId - id комментария,
+// на который отвечают.
+type Comment struct {
+	Id        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ProjectId primitive.ObjectID  `json:"projectId" bson:"projectId"`
+	UserId    primitive.ObjectID  `json:"userId" bson:"userId"`
+	ThreadId  *primitive.ObjectID `json:"threadId,omitempty" bson:"threadId"`
+	ParentId  *primitive.ObjectID `json:"parentId,omitempty" bson:"parentId,omitempty"`
+	// Body - текст в том виде, как его ввел автор, Html - его безопасная разметка для показа
+	Body   string        `json:"body" bson:"body"`
+	Html   string        `json:"html" bson:"html"`
+	Status CommentStatus `json:"status" bson:"status"`
+	// Replies - число видимых ответов в ветке, считается только у комментариев верхнего уровня
+	Replies   int64          `json:"replies" bson:"replies"`
+	CreatedAt time.Time      `json:"createdAt" bson:"createdAt"`
+	Author    *ProjectAuthor `json:"author,omitempty" bson:"-"`
+}
+
+type CommentInput struct {
+	Body     string `json:"body" binding:"required"`
+	ParentId string `json:"parentId"`
+}
+
+type CommentsModeInput struct {
+	Mode CommentsMode `json:"mode" binding:"required"`
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MessageFolder - папка входящих сообщений. В spam попадают сообщения, похожие на рассылку,
+// о них владелец не получает писем.
+type MessageFolder string
+
+const (
+	FolderInbox   MessageFolder = "inbox"
+	FolderArchive MessageFolder = "archive"
+	FolderSpam    MessageFolder = "spam"
+)
+
+func (f MessageFolder) Valid() bool {
+	return f == FolderInbox || f == FolderArchive || f == FolderSpam
+}
+
+// ContactMessage - сообщение посетителя владельцу портфолио из формы обратной связи.
+// Email владельца посетителю не показывается, а email посетителя виден только владельцу.
+type ContactMessage struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserId    primitive.ObjectID `json:"userId" bson:"userId"`
+	Name      string             `json:"name" bson:"name"`
+	Email     string             `json:"email" bson:"email"`
+	Subject   string             `json:"subject" bson:"subject"`
+	Body      string             `json:"body" bson:"body"`
+	Folder    MessageFolder      `json:"folder" bson:"folder"`
+	Read      bool               `json:"read" bson:"read"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type ContactInput struct {
+	Name    string `json:"name" binding:"required,max=100"`
+	Email   string `json:"email" binding:"required,email,max=254"`
+	Subject string `json:"subject" binding:"max=200"`
+	Message string `json:"message" binding:"required,max=5000"`
+	// Website - поле-ловушка: форма его скрывает, заполняют его только боты
+	Website string `json:"website"`
+}
+
+type UnreadMessages struct {
+	Count int64 `json:"count"`
+}
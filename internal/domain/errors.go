@@ -6,7 +6,65 @@ var (
 	ErrUserNotFound      = errors.New("user doesn't exists")
 	ErrUserAlreadyExists = errors.New("user with such email already exists")
 
-	ErrProjectNotFound = errors.New("project doesn't exists")
+	ErrProjectNotFound  = errors.New("project doesn't exists")
+	ErrInvalidPublishAt = errors.New("publish time must be in the future")
+	ErrNothingToPublish = errors.New("project is already published and has no draft")
+	ErrInvalidFileOrder = errors.New("order must list every project file exactly once")
+	ErrCoverNotImage    = errors.New("only an image can be a project cover")
+	ErrGalleryTextLong  = errors.New("caption or alt text is too long")
+	ErrProjectForbidden = errors.New("collaborator role doesn't allow this action")
+
+	ErrCollaboratorNotFound = errors.New("collaborator doesn't exists")
+	ErrCollaboratorExists   = errors.New("user is already a project collaborator")
+	ErrInvalidRole          = errors.New("role must be editor or viewer")
+	ErrInvitationNotFound   = errors.New("invitation doesn't exists or was already answered")
+	ErrInvitationExists     = errors.New("user is already invited to the project")
+	ErrSelfInvitation       = errors.New("project owner can't be invited to own project")
+
+	ErrRevisionNotFound = errors.New("revision doesn't exists")
+
+	ErrEmptySearchQuery     = errors.New("empty search query")
+	ErrSearchOffsetTooLarge = errors.New("search offset is too large, refine the query")
+
+	ErrFileNotFound        = errors.New("file doesn't exists")
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrInvalidFile         = errors.New("file is corrupted or does not match its type")
+	ErrFileTooLarge        = errors.New("file is too large")
+	ErrImageTooLarge       = errors.New("image dimensions are too large")
+	ErrQuotaExceeded       = errors.New("storage quota exceeded")
+	ErrStoredFileExists    = errors.New("file with such content is already stored")
+	ErrFileInfected        = errors.New("file contains malware")
+	ErrScanFailed          = errors.New("failed to scan file for malware")
+
+	ErrQuarantinedFileNotFound = errors.New("quarantined file doesn't exists")
+
+	ErrUploadNotFound       = errors.New("upload session doesn't exists or has expired")
+	ErrUploadOffsetMismatch = errors.New("chunk offset doesn't match upload offset")
+	ErrUploadChunkTooLarge  = errors.New("chunk is too large")
+	ErrUploadBusy           = errors.New("upload session is busy with another request")
+	ErrUploadIncomplete     = errors.New("upload is not complete")
 
 	ErrVerificationCodeInvalid = errors.New("verification code is invalid")
+
+	ErrTrashExpired = errors.New("restore period has expired")
+
+	ErrInvalidStatsPeriod = errors.New("invalid stats period")
+
+	ErrOwnProjectReaction = errors.New("can't like or bookmark own project")
+
+	ErrCommentNotFound      = errors.New("comment doesn't exists")
+	ErrCommentEmpty         = errors.New("comment is empty")
+	ErrCommentTooLong       = errors.New("comment is too long")
+	ErrCommentsClosed       = errors.New("comments on this project are closed")
+	ErrInvalidCommentsMode  = errors.New("mode must be open, premoderated or closed")
+	ErrCommentRateLimited   = errors.New("too many comments, try again later")
+	ErrCommentForbidden     = errors.New("only the author or the project owner can delete a comment")
+	ErrInvalidCommentStatus = errors.New("status must be approved, pending or hidden")
+
+	ErrNotificationNotFound = errors.New("notification doesn't exists")
+
+	ErrMessageNotFound    = errors.New("message doesn't exists")
+	ErrMessageEmpty       = errors.New("message is empty")
+	ErrInvalidFolder      = errors.New("folder must be inbox, archive or spam")
+	ErrContactRateLimited = errors.New("too many messages, try again later")
 )
@@ -1,8 +1,133 @@
 package domain
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// типы файлов: изображения показываются в галерее, документы, видео и архивы прикладываются к проектам ссылкой
+const (
+	FileImage    = "Image"
+	FileDocument = "Document"
+	FileVideo    = "Video"
+	FileArchive  = "Archive"
+)
+
 type File struct {
-	FileType string `json:"type" bson:"type, omitempty"`
-	Name     string `json:"name" bson:"name"`
-	OrigName string `json:"origName" bson:"origName"`
-	Url      string `json:"url" bson:"url"`
+	FileType    string `json:"type" bson:"type, omitempty"`
+	ContentType string `json:"contentType,omitempty" bson:"contentType,omitempty"`
+	Name        string `json:"name" bson:"name"`
+	OrigName    string `json:"origName" bson:"origName"`
+	Url         string `json:"url" bson:"url"`
+	Width       int    `json:"width,omitempty" bson:"width,omitempty"`
+	Height      int    `json:"height,omitempty" bson:"height,omitempty"`
+	Size        int64  `json:"size,omitempty" bson:"size,omitempty"`
+	// Blurhash и DominantColor - заглушка, которую фронтенд показывает, пока изображение загружается
+	Blurhash      string `json:"blurhash,omitempty" bson:"blurhash,omitempty"`
+	DominantColor string `json:"dominantColor,omitempty" bson:"dominantColor,omitempty"`
+	// Caption, Alt и Cover задаются в галерее проекта: подпись, альтернативный текст и выбор обложкой
+	Caption string `json:"caption,omitempty" bson:"caption,omitempty"`
+	Alt     string `json:"alt,omitempty" bson:"alt,omitempty"`
+	Cover   bool   `json:"cover,omitempty" bson:"cover,omitempty"`
+	// Variants - уменьшенные копии изображения для srcset
+	Variants []FileVariant `json:"variants,omitempty" bson:"variants,omitempty"`
+	// Key - путь файла в хранилище, по нему меняется доступ и выдаются подписанные ссылки
+	Key string `json:"-" bson:"key,omitempty"`
+}
+
+type FileVariant struct {
+	Name   string `json:"name" bson:"name"`
+	Url    string `json:"url" bson:"url"`
+	Width  int    `json:"width" bson:"width"`
+	Height int    `json:"height" bson:"height"`
+	Key    string `json:"-" bson:"key,omitempty"`
+}
+
+// Upload - запись об объекте в хранилище. По этим записям считается место, занятое пользователем.
+type Upload struct {
+	Key       string             `bson:"_id"`
+	UserId    primitive.ObjectID `bson:"userId"`
+	Size      int64              `bson:"size"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// StoredFile - объект в хранилище, общий для одинаковых файлов, загруженных пользователем. Id составлен
+// из id пользователя и SHA-256 загруженного файла, Refs - файлы проектов и пользователя, которые на него
+// указывают. Объект удаляется из хранилища, когда ссылок на него не остается.
+type StoredFile struct {
+	Id     string             `bson:"_id"`
+	UserId primitive.ObjectID `bson:"userId"`
+	Hash   string             `bson:"hash"`
+	// File - сохраненный файл с вариантами, Name и OrigName в нем - как у первой загрузки
+	File      File      `bson:"file"`
+	Refs      []FileRef `bson:"refs"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// FileRef - ссылка на общий объект: файл Name в каталоге Dir (каталог проекта или пользователя).
+// Объект приватный, только если приватны все ссылки на него.
+type FileRef struct {
+	Dir     string `bson:"dir"`
+	Name    string `bson:"name"`
+	Private bool   `bson:"private"`
+	// CreatedAt нужен сборщику мусора: ссылка появляется раньше, чем файл попадает в проект или к пользователю
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// OrphanReport - файлы в хранилище, на которые не ссылаются ни пользователи, ни проекты, ни ревизии
+type OrphanReport struct {
+	// DryRun - файлы только найдены, но не удалены
+	DryRun bool `json:"dryRun"`
+	// Refs - ссылки на общие объекты (каталог/имя), файлов с которыми нигде нет
+	Refs []string `json:"refs"`
+	// Objects - объекты хранилища, которые удалены (будут удалены) вместе с записями о занятом месте
+	Objects []OrphanObject `json:"objects"`
+	Size    int64          `json:"size"`
+}
+
+type OrphanObject struct {
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type StorageUsage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
+
+// UploadSession - возобновляемая загрузка файла в проект. Клиент отправляет файл частями, каждая часть
+// пишется с позиции Offset, после обрыва загрузка продолжается с последней сохраненной позиции.
+type UploadSession struct {
+	Id        string             `json:"id"`
+	UserId    primitive.ObjectID `json:"userId"`
+	ProjectId primitive.ObjectID `json:"projectId"`
+	Name      string             `json:"name"`
+	Size      int64              `json:"size"`
+	Offset    int64              `json:"offset"`
+	CreatedAt time.Time          `json:"createdAt"`
+	// ExpiresAt сдвигается с каждой полученной частью
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type UploadSessionInput struct {
+	Name string `json:"name" binding:"required"`
+	Size int64  `json:"size" binding:"required,min=1"`
+}
+
+// QuarantinedFile - загруженный файл, в котором антивирус нашел угрозу. В проект или профиль он не попадает,
+// а хранится приватным объектом в карантине, пока его не удалит администратор.
+type QuarantinedFile struct {
+	Id          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserId      primitive.ObjectID `json:"userId" bson:"userId"`
+	Hash        string             `json:"hash" bson:"hash"`
+	OrigName    string             `json:"origName" bson:"origName"`
+	ContentType string             `json:"contentType" bson:"contentType"`
+	Size        int64              `json:"size" bson:"size"`
+	// Signature - название угрозы, которую нашел антивирус
+	Signature string `json:"signature" bson:"signature"`
+	// Key - путь объекта в хранилище, пустой, если сохранить файл не удалось
+	Key       string    `json:"-" bson:"key,omitempty"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 }
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationKind string
+
+const (
+	// NotifyComment - новый комментарий к проекту, NotifyCommentPending - комментарий ждет одобрения
+	NotifyComment        NotificationKind = "comment"
+	NotifyCommentPending NotificationKind = "commentPending"
+)
+
+// Notification - уведомление пользователя о событии в его проектах. Название проекта и начало
+// комментария сохраняются в уведомлении, чтобы показать список без дополнительных запросов.
+type Notification struct {
+	Id          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserId      primitive.ObjectID `json:"userId" bson:"userId"`
+	Kind        NotificationKind   `json:"kind" bson:"kind"`
+	ActorId     primitive.ObjectID `json:"actorId" bson:"actorId"`
+	ProjectId   primitive.ObjectID `json:"projectId" bson:"projectId"`
+	ProjectName string             `json:"projectName" bson:"projectName"`
+	CommentId   primitive.ObjectID `json:"commentId" bson:"commentId"`
+	Excerpt     string             `json:"excerpt" bson:"excerpt"`
+	Read        bool               `json:"read" bson:"read"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type UnreadNotifications struct {
+	Count int64 `json:"count"`
+}
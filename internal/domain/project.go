@@ -18,16 +18,33 @@ type ProjectMin struct {
 	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserId    primitive.ObjectID `json:"userId" bson:"userId,omitempty"`
 	Name      string             `json:"name" bson:"name,omitempty"`
+	Tags      []string           `json:"tags" bson:"tags"`
+	Order     int                `json:"order" bson:"order"`
+	Likes     int64              `json:"likes" bson:"likes"`
 	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+	// Cover - обложка для списка, Files и Access нужны только для ее выбора
+	Cover  *File      `json:"cover,omitempty" bson:"-"`
+	Files  []File     `json:"-" bson:"files"`
+	Access AccessType `json:"-" bson:"access"`
 }
 type SelfProjectMin struct {
 	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserId    primitive.ObjectID `json:"userId" bson:"userId,omitempty"`
 	Name      string             `json:"name" bson:"name,omitempty"`
 	Access    AccessType         `json:"access" bson:"access"`
+	Order     int                `json:"order" bson:"order"`
+	Views     int64              `json:"views" bson:"views"`
+	Likes     int64              `json:"likes" bson:"likes"`
+	Published bool               `json:"published" bson:"published"`
+	PublishAt *time.Time         `json:"publishAt" bson:"publishAt,omitempty"`
 	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	// Cover - обложка рабочего содержимого (черновика, если он есть), Files и Draft нужны только для ее выбора
+	Cover *File         `json:"cover,omitempty" bson:"-"`
+	Files []File        `json:"-" bson:"files"`
+	Draft *ProjectDraft `json:"-" bson:"draft,omitempty"`
 }
 
 type Project struct {
@@ -35,30 +52,85 @@ type Project struct {
 	UserId      primitive.ObjectID `json:"userId" bson:"userId,omitempty"`
 	Name        string             `json:"name" bson:"name,omitempty"`
 	Description string             `json:"description" bson:"description"`
+	Tags        []string           `json:"tags" bson:"tags"`
 	Files       []File             `json:"files" bson:"files"`
 	Access      AccessType         `json:"-" bson:"access"`
+	Order       int                `json:"order" bson:"order"`
+	Likes       int64              `json:"likes" bson:"likes"`
+	Bookmarks   int64              `json:"bookmarks" bson:"bookmarks"`
+	Published   bool               `json:"-" bson:"published"`
+	PublishedAt time.Time          `json:"publishedAt" bson:"publishedAt"`
 	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	// Authors - владелец и соавторы, заполняются по Collaborators
+	Authors       []ProjectAuthor `json:"authors" bson:"-"`
+	Collaborators []Collaborator  `json:"-" bson:"collaborators"`
+	// CommentsMode нужен фронтенду, чтобы скрыть форму комментария
+	CommentsMode CommentsMode `json:"commentsMode" bson:"commentsMode"`
 }
 type SelfProject struct {
 	Id          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserId      primitive.ObjectID `json:"userId" bson:"userId,omitempty"`
 	Name        string             `json:"name" bson:"name,omitempty"`
 	Description string             `json:"description" bson:"description"`
+	Tags        []string           `json:"tags" bson:"tags"`
 	Files       []File             `json:"files" bson:"files"`
 	Access      AccessType         `json:"access" bson:"access"`
+	Order       int                `json:"order" bson:"order"`
+	Views       int64              `json:"views" bson:"views,omitempty"`
+	Likes       int64              `json:"likes" bson:"likes,omitempty"`
+	Bookmarks   int64              `json:"bookmarks" bson:"bookmarks,omitempty"`
 	Published   bool               `json:"published" bson:"published,omitempty"`
+	PublishedAt time.Time          `json:"publishedAt" bson:"publishedAt,omitempty"`
+	PublishAt   *time.Time         `json:"publishAt" bson:"publishAt,omitempty"`
+	Draft       *ProjectDraft      `json:"draft" bson:"draft,omitempty"`
 	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt   *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	// Collaborators не входят в содержимое проекта и не попадают в черновик и ревизии
+	Collaborators []Collaborator `json:"collaborators" bson:"collaborators,omitempty"`
+	// CommentsMode меняется отдельным запросом и тоже не входит в содержимое проекта
+	CommentsMode CommentsMode `json:"commentsMode" bson:"commentsMode,omitempty"`
+}
+
+// ProjectDraft - рабочая копия опубликованного проекта, которая заменяет живую версию только при повторной публикации
+type ProjectDraft struct {
+	Name        string    `json:"name" bson:"name"`
+	Description string    `json:"description" bson:"description"`
+	Tags        []string  `json:"tags" bson:"tags"`
+	Files       []File    `json:"files" bson:"files"`
+	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt"`
 }
 
 type ProjectInput struct {
 	UserId      primitive.ObjectID `json:"userId" bson:"userId"`
 	Name        string             `json:"name" bson:"name"`
 	Description string             `json:"description" bson:"description"`
+	Tags        []string           `json:"tags" bson:"tags"`
 	Files       []File             `json:"files" bson:"files"`
 	Access      AccessType         `json:"access" bson:"access"`
+	Order       int                `json:"order" bson:"order"`
 	Published   bool               `json:"published" bson:"published"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	// счетчики отметок заводятся сразу, по ним сортируется список проектов
+	Likes     int64     `json:"likes" bson:"likes"`
+	Bookmarks int64     `json:"bookmarks" bson:"bookmarks"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	// CommentsMode новых проектов - open
+	CommentsMode CommentsMode `json:"commentsMode" bson:"commentsMode"`
+}
+
+// GalleryFileInput меняет подпись и альтернативный текст файла галереи, nil оставляет значение прежним
+type GalleryFileInput struct {
+	Caption *string `json:"caption"`
+	Alt     *string `json:"alt"`
+}
+
+// GalleryOrderInput - новый порядок файлов галереи, перечисляет имена всех файлов ровно по разу
+type GalleryOrderInput struct {
+	Names []string `json:"names" binding:"required"`
+}
+
+type CoverInput struct {
+	Name string `json:"name" binding:"required"`
 }
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReactionKind - отметка пользователя на чужом проекте: лайк виден всем в счетчике проекта,
+// закладка нужна только самому пользователю
+type ReactionKind string
+
+const (
+	ReactionLike     ReactionKind = "like"
+	ReactionBookmark ReactionKind = "bookmark"
+)
+
+type Reaction struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ProjectId primitive.ObjectID `json:"projectId" bson:"projectId"`
+	UserId    primitive.ObjectID `json:"userId" bson:"userId"`
+	Kind      ReactionKind       `json:"kind" bson:"kind"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// ProjectReactions - отметки текущего пользователя на проекте
+type ProjectReactions struct {
+	Liked      bool `json:"liked"`
+	Bookmarked bool `json:"bookmarked"`
+}
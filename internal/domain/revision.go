@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RevisionAction string
+
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionRestore RevisionAction = "restore"
+)
+
+// Revision - неизменяемый снимок содержимого проекта после очередного изменения
+type Revision struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ProjectId primitive.ObjectID `json:"projectId" bson:"projectId"`
+	UserId    primitive.ObjectID `json:"userId" bson:"userId"`
+	Action    RevisionAction     `json:"action" bson:"action"`
+	Changes   []string           `json:"changes" bson:"changes"`
+	Snapshot  ProjectSnapshot    `json:"snapshot" bson:"snapshot"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type ProjectSnapshot struct {
+	Name        string     `json:"name" bson:"name"`
+	Description string     `json:"description" bson:"description"`
+	Tags        []string   `json:"tags" bson:"tags"`
+	Files       []File     `json:"files" bson:"files"`
+	Access      AccessType `json:"access" bson:"access"`
+	Order       int        `json:"order" bson:"order"`
+}
+
+type FieldDiff struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type RevisionDiff struct {
+	From   primitive.ObjectID `json:"from"`
+	To     primitive.ObjectID `json:"to"`
+	Fields []FieldDiff        `json:"fields"`
+}
+
+// Snapshot возвращает текущее рабочее содержимое проекта: черновик, если он есть, иначе живую версию
+func (p SelfProject) Snapshot() ProjectSnapshot {
+	snapshot := ProjectSnapshot{
+		Name:        p.Name,
+		Description: p.Description,
+		Tags:        p.Tags,
+		Files:       p.Files,
+		Access:      p.Access,
+		Order:       p.Order,
+	}
+	if p.Draft != nil {
+		snapshot.Name = p.Draft.Name
+		snapshot.Description = p.Draft.Description
+		snapshot.Tags = p.Draft.Tags
+		snapshot.Files = p.Draft.Files
+	}
+	return snapshot
+}
+
+// Diff сравнивает снимки поле за полем и возвращает только изменившиеся поля
+func (s ProjectSnapshot) Diff(to ProjectSnapshot) []FieldDiff {
+	diff := []FieldDiff{}
+	add := func(field string, from, to interface{}) {
+		if !reflect.DeepEqual(from, to) {
+			diff = append(diff, FieldDiff{Field: field, From: from, To: to})
+		}
+	}
+
+	add("name", s.Name, to.Name)
+	add("description", s.Description, to.Description)
+	add("tags", emptyIfNil(s.Tags), emptyIfNil(to.Tags))
+	add("files", emptyFilesIfNil(s.Files), emptyFilesIfNil(to.Files))
+	add("access", s.Access, to.Access)
+	add("order", s.Order, to.Order)
+	return diff
+}
+
+func emptyIfNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func emptyFilesIfNil(f []File) []File {
+	if f == nil {
+		return []File{}
+	}
+	return f
+}
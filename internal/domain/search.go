@@ -0,0 +1,46 @@
+package domain
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type SearchType string
+
+const (
+	SearchProject SearchType = "project"
+	SearchUser    SearchType = "user"
+)
+
+type SearchQuery struct {
+	Query  string
+	Types  []SearchType
+	Tags   []string
+	UserId primitive.ObjectID
+	Limit  int
+	Offset int
+}
+
+type SearchResult struct {
+	Type       SearchType          `json:"type"`
+	Id         primitive.ObjectID  `json:"id"`
+	UserId     primitive.ObjectID  `json:"userId,omitempty"`
+	Name       string              `json:"name"`
+	Tags       []string            `json:"tags,omitempty"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+type SearchResults struct {
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
+
+func (q SearchQuery) HasType(t SearchType) bool {
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, qt := range q.Types {
+		if qt == t {
+			return true
+		}
+	}
+	return false
+}
@@ -11,12 +11,26 @@ type User struct {
 	UserUrl      string             `json:"userUrl" bson:"userUrl"`
 	Name         string             `json:"name" bson:"name,omitempty"`
 	Email        string             `json:"email" bson:"email"`
-	Password     string             `json:"password" bson:"password"`
+	Password     string             `json:"-" bson:"password"`
 	Role         string             `json:"role" bson:"role"`
 	Avatar       File               `json:"avatar" bson:"avatar"`
 	RegisteredAt time.Time          `json:"-" bson:"registeredAt"`
 	LastVisitAt  time.Time          `json:"-" bson:"lastVisitAt"`
 	Verification Verification       `json:"-" bson:"verification"`
+	DeletedAt    *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// PublicUser - профиль пользователя, который видят все. Email, роли и пароля в нем нет:
+// посетители пишут владельцу портфолио через форму обратной связи.
+type PublicUser struct {
+	Id      primitive.ObjectID `json:"id"`
+	UserUrl string             `json:"userUrl"`
+	Name    string             `json:"name"`
+	Avatar  File               `json:"avatar"`
+}
+
+func (u User) Public() PublicUser {
+	return PublicUser{Id: u.Id, UserUrl: u.UserUrl, Name: u.Name, Avatar: u.Avatar}
 }
 
 type Verification struct {
@@ -1,6 +1,16 @@
 package repository
 
 const (
-	usersCollection   = "users"
-	projectCollection = "projects"
+	usersCollection         = "users"
+	projectCollection       = "projects"
+	revisionCollection      = "revisions"
+	uploadsCollection       = "uploads"
+	filesCollection         = "files"
+	quarantineCollection    = "quarantine"
+	invitationsCollection   = "invitations"
+	viewsCollection         = "views"
+	reactionsCollection     = "reactions"
+	commentsCollection      = "comments"
+	notificationsCollection = "notifications"
+	messagesCollection      = "messages"
 )
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentsRepo struct {
+	db *mongo.Collection
+}
+
+func NewCommentsRepo(db *mongo.Database) *CommentsRepo {
+	return &CommentsRepo{
+		db: db.Collection(commentsCollection),
+	}
+}
+
+// CreateIndexes создает индексы для веток проекта, ответов в ветке и комментариев пользователя
+func (r *CommentsRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "projectId", Value: 1}, {Key: "threadId", Value: 1}, {Key: "status", Value: 1},
+				{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetName("comments_threads"),
+		},
+		{
+			Keys:    bson.D{{Key: "threadId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+			Options: options.Index().SetName("comments_replies"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetName("comments_user"),
+		},
+	})
+	return err
+}
+
+func (r *CommentsRepo) Create(ctx context.Context, comment domain.Comment) (primitive.ObjectID, error) {
+	res, err := r.db.InsertOne(ctx, comment)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return res.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *CommentsRepo) GetById(ctx context.Context, projectId, id primitive.ObjectID) (*domain.Comment, error) {
+	var comment domain.Comment
+	if err := r.db.FindOne(ctx, bson.M{"_id": id, "projectId": projectId}).Decode(&comment); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetThreads возвращает видимые комментарии верхнего уровня
+func (r *CommentsRepo) GetThreads(ctx context.Context, projectId primitive.ObjectID, params pagination.Params) ([]domain.Comment, *pagination.Page, error) {
+	filter := bson.M{"projectId": projectId, "threadId": nil, "status": domain.CommentApproved}
+	return r.findPage(ctx, filter, params, pagination.Desc)
+}
+
+// GetReplies возвращает видимые ответы ветки, по умолчанию в порядке написания
+func (r *CommentsRepo) GetReplies(ctx context.Context, threadId primitive.ObjectID, params pagination.Params) ([]domain.Comment, *pagination.Page, error) {
+	return r.findPage(ctx, bson.M{"threadId": threadId, "status": domain.CommentApproved}, params, pagination.Asc)
+}
+
+// GetByProject возвращает все комментарии проекта со статусом status, пустой статус - с любым
+func (r *CommentsRepo) GetByProject(ctx context.Context, projectId primitive.ObjectID, status domain.CommentStatus,
+	params pagination.Params) ([]domain.Comment, *pagination.Page, error) {
+	filter := bson.M{"projectId": projectId}
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.findPage(ctx, filter, params, pagination.Desc)
+}
+
+// GetAllByUser возвращает все комментарии пользователя, нужны при его окончательном удалении
+func (r *CommentsRepo) GetAllByUser(ctx context.Context, userId primitive.ObjectID) ([]domain.Comment, error) {
+	cursor, err := r.db.Find(ctx, bson.M{"userId": userId})
+	if err != nil {
+		return nil, err
+	}
+	var comments []domain.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// SetStatus меняет статус комментария и возвращает комментарий до изменения: по прежнему статусу
+// видно, нужно ли менять счетчик ответов
+func (r *CommentsRepo) SetStatus(ctx context.Context, projectId, id primitive.ObjectID, status domain.CommentStatus) (*domain.Comment, error) {
+	var comment domain.Comment
+	err := r.db.FindOneAndUpdate(ctx, bson.M{"_id": id, "projectId": projectId}, bson.M{"$set": bson.M{"status": status}}).Decode(&comment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// IncReplies меняет счетчик видимых ответов ветки на delta
+func (r *CommentsRepo) IncReplies(ctx context.Context, threadId primitive.ObjectID, delta int) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": threadId}, bson.M{"$inc": bson.M{"replies": delta}})
+	return err
+}
+
+// Remove удаляет комментарий и возвращает его
+func (r *CommentsRepo) Remove(ctx context.Context, projectId, id primitive.ObjectID) (*domain.Comment, error) {
+	var comment domain.Comment
+	if err := r.db.FindOneAndDelete(ctx, bson.M{"_id": id, "projectId": projectId}).Decode(&comment); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// RemoveThread удаляет ответы ветки
+func (r *CommentsRepo) RemoveThread(ctx context.Context, threadId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"threadId": threadId})
+	return err
+}
+
+func (r *CommentsRepo) RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"projectId": projectId})
+	return err
+}
+
+func (r *CommentsRepo) findPage(ctx context.Context, filter bson.M, params pagination.Params,
+	defaultOrder pagination.Order) ([]domain.Comment, *pagination.Page, error) {
+	var comments []domain.Comment
+	page, err := findPage(ctx, r.db, filter, params, commentSorts, "createdAt", defaultOrder, &comments)
+	if err != nil {
+		return nil, nil, err
+	}
+	return comments, page, nil
+}
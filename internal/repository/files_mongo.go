@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/database/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FilesRepo хранит общие объекты хранилища и ссылки на них. Число ссылок - длина refs, ссылки добавляются
+// и удаляются атомарно, поэтому объект без ссылок удаляется только если за это время на него никто не сослался.
+type FilesRepo struct {
+	db *mongo.Collection
+}
+
+func NewFilesRepo(db *mongo.Database) *FilesRepo {
+	return &FilesRepo{
+		db: db.Collection(filesCollection),
+	}
+}
+
+// CreateIndexes создает индекс для поиска объекта по ссылке
+func (r *FilesRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "refs.dir", Value: 1}, {Key: "refs.name", Value: 1}},
+		Options: options.Index().SetName("files_refs"),
+	})
+	return err
+}
+
+func (r *FilesRepo) Create(ctx context.Context, file domain.StoredFile) error {
+	_, err := r.db.InsertOne(ctx, file)
+	if mongodb.IsDuplicate(err) {
+		return domain.ErrStoredFileExists
+	}
+	return err
+}
+
+func (r *FilesRepo) GetById(ctx context.Context, id string) (*domain.StoredFile, error) {
+	var file domain.StoredFile
+	if err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&file); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrFileNotFound
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetAll возвращает все объекты, их немного: по одному на каждый уникальный файл
+func (r *FilesRepo) GetAll(ctx context.Context) ([]domain.StoredFile, error) {
+	cursor, err := r.db.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var files []domain.StoredFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// AddRef добавляет ссылку на объект и возвращает его. Повторная ссылка с тем же каталогом и именем не добавляется.
+func (r *FilesRepo) AddRef(ctx context.Context, id string, ref domain.FileRef) (*domain.StoredFile, error) {
+	filter := bson.M{"_id": id, "refs": bson.M{"$not": bson.M{"$elemMatch": bson.M{"dir": ref.Dir, "name": ref.Name}}}}
+	file, err := r.findAndUpdate(ctx, filter, bson.M{"$push": bson.M{"refs": ref}})
+	if errors.Is(err, domain.ErrFileNotFound) {
+		// ссылка уже есть, либо объекта нет
+		return r.GetById(ctx, id)
+	}
+	return file, err
+}
+
+// RemoveOtherRef удаляет ссылку dir/name с любого объекта, кроме id, и возвращает этот объект
+func (r *FilesRepo) RemoveOtherRef(ctx context.Context, id, dir, name string) (*domain.StoredFile, error) {
+	filter := refFilter(dir, name)
+	filter["_id"] = bson.M{"$ne": id}
+	return r.findAndUpdate(ctx, filter, bson.M{"$pull": bson.M{"refs": bson.M{"dir": dir, "name": name}}})
+}
+
+// RemoveRef удаляет ссылку dir/name и возвращает объект, на который она указывала
+func (r *FilesRepo) RemoveRef(ctx context.Context, dir, name string) (*domain.StoredFile, error) {
+	return r.findAndUpdate(ctx, refFilter(dir, name), bson.M{"$pull": bson.M{"refs": bson.M{"dir": dir, "name": name}}})
+}
+
+// SetRefPrivate меняет доступ по ссылке dir/name и возвращает объект, на который она указывает
+func (r *FilesRepo) SetRefPrivate(ctx context.Context, dir, name string, private bool) (*domain.StoredFile, error) {
+	return r.findAndUpdate(ctx, refFilter(dir, name), bson.M{"$set": bson.M{"refs.$.private": private}})
+}
+
+// GetByDirPrefix возвращает объекты, на которые есть ссылки из каталогов с префиксом prefix
+func (r *FilesRepo) GetByDirPrefix(ctx context.Context, prefix string) ([]domain.StoredFile, error) {
+	cursor, err := r.db.Find(ctx, bson.M{"refs.dir": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}})
+	if err != nil {
+		return nil, err
+	}
+	var files []domain.StoredFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// RemoveUnreferenced удаляет запись об объекте, если ссылок на него нет. false - ссылка успела появиться.
+func (r *FilesRepo) RemoveUnreferenced(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.DeleteOne(ctx, bson.M{"_id": id, "refs": bson.M{"$size": 0}})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func (r *FilesRepo) findAndUpdate(ctx context.Context, filter, update bson.M) (*domain.StoredFile, error) {
+	var file domain.StoredFile
+	err := r.db.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&file)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrFileNotFound
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+func refFilter(dir, name string) bson.M {
+	return bson.M{"refs": bson.M{"$elemMatch": bson.M{"dir": dir, "name": name}}}
+}
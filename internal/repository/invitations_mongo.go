@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/database/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationsRepo struct {
+	db *mongo.Collection
+}
+
+func NewInvitationsRepo(db *mongo.Database) *InvitationsRepo {
+	return &InvitationsRepo{
+		db: db.Collection(invitationsCollection),
+	}
+}
+
+// CreateIndexes создает индекс, который не дает пригласить пользователя в проект дважды, пока он не ответил
+func (r *InvitationsRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "projectId", Value: 1}, {Key: "userId", Value: 1}},
+		Options: options.Index().SetName("invitations_pending").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": domain.InvitationPending}),
+	})
+	return err
+}
+
+func (r *InvitationsRepo) Create(ctx context.Context, invitation domain.Invitation) (primitive.ObjectID, error) {
+	res, err := r.db.InsertOne(ctx, invitation)
+	if err != nil {
+		if mongodb.IsDuplicate(err) {
+			return primitive.NilObjectID, domain.ErrInvitationExists
+		}
+		return primitive.NilObjectID, err
+	}
+	return res.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *InvitationsRepo) GetById(ctx context.Context, id primitive.ObjectID) (*domain.Invitation, error) {
+	var invitation domain.Invitation
+	if err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&invitation); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *InvitationsRepo) GetPendingByUser(ctx context.Context, userId primitive.ObjectID) ([]domain.Invitation, error) {
+	return r.find(ctx, bson.M{"userId": userId, "status": domain.InvitationPending})
+}
+
+func (r *InvitationsRepo) GetPendingByProject(ctx context.Context, projectId primitive.ObjectID) ([]domain.Invitation, error) {
+	return r.find(ctx, bson.M{"projectId": projectId, "status": domain.InvitationPending})
+}
+
+// Answer принимает или отклоняет приглашение, ответить можно только один раз
+func (r *InvitationsRepo) Answer(ctx context.Context, id primitive.ObjectID, status domain.InvitationStatus, answeredAt time.Time) error {
+	res, err := r.db.UpdateOne(ctx, bson.M{"_id": id, "status": domain.InvitationPending},
+		bson.M{"$set": bson.M{"status": status, "respondedAt": answeredAt}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Remove отзывает приглашение в проект, на которое еще не ответили
+func (r *InvitationsRepo) Remove(ctx context.Context, projectId, id primitive.ObjectID) error {
+	res, err := r.db.DeleteOne(ctx, bson.M{"_id": id, "projectId": projectId, "status": domain.InvitationPending})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *InvitationsRepo) RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"projectId": projectId})
+	return err
+}
+
+// RemoveByUser удаляет приглашения, отправленные пользователю и от его имени
+func (r *InvitationsRepo) RemoveByUser(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"$or": bson.A{bson.M{"userId": userId}, bson.M{"inviterId": userId}}})
+	return err
+}
+
+func (r *InvitationsRepo) find(ctx context.Context, filter bson.M) ([]domain.Invitation, error) {
+	cursor, err := r.db.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	invitations := []domain.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
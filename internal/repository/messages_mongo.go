@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MessagesRepo хранит сообщения из формы обратной связи. Все методы, кроме Create, работают
+// только с сообщениями пользователя userId.
+type MessagesRepo struct {
+	db *mongo.Collection
+}
+
+func NewMessagesRepo(db *mongo.Database) *MessagesRepo {
+	return &MessagesRepo{
+		db: db.Collection(messagesCollection),
+	}
+}
+
+// CreateIndexes создает индекс для папок пользователя
+func (r *MessagesRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "folder", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("messages_folder"),
+	})
+	return err
+}
+
+func (r *MessagesRepo) Create(ctx context.Context, message domain.ContactMessage) (primitive.ObjectID, error) {
+	res, err := r.db.InsertOne(ctx, message)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return res.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *MessagesRepo) GetByFolder(ctx context.Context, userId primitive.ObjectID, folder domain.MessageFolder,
+	params pagination.Params) ([]domain.ContactMessage, *pagination.Page, error) {
+	var messages []domain.ContactMessage
+	page, err := findPage(ctx, r.db, bson.M{"userId": userId, "folder": folder}, params, messageSorts, "createdAt", pagination.Desc, &messages)
+	if err != nil {
+		return nil, nil, err
+	}
+	return messages, page, nil
+}
+
+// CountUnread считает непрочитанные сообщения во входящих
+func (r *MessagesRepo) CountUnread(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	return r.db.CountDocuments(ctx, bson.M{"userId": userId, "folder": domain.FolderInbox, "read": false})
+}
+
+func (r *MessagesRepo) SetRead(ctx context.Context, userId, id primitive.ObjectID, read bool) error {
+	return r.update(ctx, userId, id, bson.M{"read": read})
+}
+
+func (r *MessagesRepo) Move(ctx context.Context, userId, id primitive.ObjectID, folder domain.MessageFolder) error {
+	return r.update(ctx, userId, id, bson.M{"folder": folder})
+}
+
+func (r *MessagesRepo) Remove(ctx context.Context, userId, id primitive.ObjectID) error {
+	res, err := r.db.DeleteOne(ctx, bson.M{"_id": id, "userId": userId})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrMessageNotFound
+	}
+	return nil
+}
+
+func (r *MessagesRepo) RemoveByUser(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"userId": userId})
+	return err
+}
+
+func (r *MessagesRepo) update(ctx context.Context, userId, id primitive.ObjectID, set bson.M) error {
+	res, err := r.db.UpdateOne(ctx, bson.M{"_id": id, "userId": userId}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrMessageNotFound
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// сколько хранятся уведомления, старые удаляет сама MongoDB
+const notificationTTL = 90 * 24 * time.Hour
+
+type NotificationsRepo struct {
+	db *mongo.Collection
+}
+
+func NewNotificationsRepo(db *mongo.Database) *NotificationsRepo {
+	return &NotificationsRepo{
+		db: db.Collection(notificationsCollection),
+	}
+}
+
+// CreateIndexes создает индекс для списка уведомлений пользователя и индекс, удаляющий старые уведомления
+func (r *NotificationsRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "read", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("notifications_user"),
+		},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetName("notifications_ttl").SetExpireAfterSeconds(int32(notificationTTL.Seconds())),
+		},
+	})
+	return err
+}
+
+func (r *NotificationsRepo) Create(ctx context.Context, notification domain.Notification) error {
+	_, err := r.db.InsertOne(ctx, notification)
+	return err
+}
+
+func (r *NotificationsRepo) GetByUser(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.Notification, *pagination.Page, error) {
+	var notifications []domain.Notification
+	page, err := findPage(ctx, r.db, bson.M{"userId": userId}, params, notificationSorts, "createdAt", pagination.Desc, &notifications)
+	if err != nil {
+		return nil, nil, err
+	}
+	return notifications, page, nil
+}
+
+func (r *NotificationsRepo) CountUnread(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	return r.db.CountDocuments(ctx, bson.M{"userId": userId, "read": false})
+}
+
+func (r *NotificationsRepo) MarkRead(ctx context.Context, userId, id primitive.ObjectID) error {
+	res, err := r.db.UpdateOne(ctx, bson.M{"_id": id, "userId": userId}, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *NotificationsRepo) MarkAllRead(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.UpdateMany(ctx, bson.M{"userId": userId, "read": false}, bson.M{"$set": bson.M{"read": true}})
+	return err
+}
+
+func (r *NotificationsRepo) RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"projectId": projectId})
+	return err
+}
+
+func (r *NotificationsRepo) RemoveByUser(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"userId": userId})
+	return err
+}
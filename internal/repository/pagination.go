@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ключи сортировки, доступные клиенту, и соответствующие им поля документов
+var (
+	projectSorts = map[string]string{
+		"createdAt": "createdAt",
+		"updatedAt": "updatedAt",
+		"name":      "name",
+		"order":     "order",
+		"likes":     "likes",
+		"deletedAt": "deletedAt",
+	}
+	userSorts = map[string]string{
+		"createdAt": "registeredAt",
+		"updatedAt": "lastVisitAt",
+		"name":      "name",
+		"deletedAt": "deletedAt",
+	}
+	quarantineSorts = map[string]string{
+		"createdAt": "createdAt",
+		"size":      "size",
+	}
+	reactionSorts = map[string]string{
+		"createdAt": "createdAt",
+	}
+	commentSorts = map[string]string{
+		"createdAt": "createdAt",
+	}
+	notificationSorts = map[string]string{
+		"createdAt": "createdAt",
+	}
+	messageSorts = map[string]string{
+		"createdAt": "createdAt",
+	}
+)
+
+// findPage выбирает одну страницу документов коллекции с сортировкой по ключу и _id (keyset pagination).
+// out должен быть указателем на слайс.
+func findPage(ctx context.Context, coll *mongo.Collection, filter bson.M, params pagination.Params, sorts map[string]string,
+	defaultSort string, defaultOrder pagination.Order, out interface{}) (*pagination.Page, error) {
+	var cursor *pagination.Cursor
+	if params.Cursor != "" {
+		c, err := pagination.DecodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+		params.Sort = c.Sort
+		params.Order = c.Order
+	}
+	params, err := params.Normalize(sorts, defaultSort, defaultOrder)
+	if err != nil {
+		return nil, err
+	}
+	field := sorts[params.Sort]
+
+	backward := cursor != nil && cursor.Backward
+	dir := params.Order
+	if backward {
+		dir = -dir
+	}
+
+	if cursor != nil {
+		filter = bson.M{"$and": bson.A{filter, afterCursor(field, cursor, dir)}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: int(dir)}, {Key: "_id", Value: int(dir)}}).
+		SetLimit(int64(params.Limit + 1))
+
+	res, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &pagination.Page{Limit: params.Limit, Sort: params.Sort, Order: params.Order}, decodeRaws(nil, out)
+		}
+		return nil, err
+	}
+
+	var raws []bson.Raw
+	if err := res.All(ctx, &raws); err != nil {
+		return nil, err
+	}
+
+	hasMore := len(raws) > params.Limit
+	if hasMore {
+		raws = raws[:params.Limit]
+	}
+	if backward {
+		for i, j := 0, len(raws)-1; i < j; i, j = i+1, j-1 {
+			raws[i], raws[j] = raws[j], raws[i]
+		}
+	}
+
+	page := &pagination.Page{Limit: params.Limit, Sort: params.Sort, Order: params.Order}
+	if len(raws) > 0 {
+		if hasMore || backward {
+			if page.Next, err = boundaryCursor(raws[len(raws)-1], field, params, false); err != nil {
+				return nil, err
+			}
+		}
+		if (backward && hasMore) || (!backward && cursor != nil) {
+			if page.Prev, err = boundaryCursor(raws[0], field, params, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return page, decodeRaws(raws, out)
+}
+
+// afterCursor отбирает документы, которые при сортировке по field в направлении dir идут после курсора.
+// Пустое значение (null или отсутствующее поле) Mongo ставит раньше любого другого, а $gt и $lt сравнивают
+// только значения одного типа и пустые не находят, поэтому они обрабатываются отдельно.
+func afterCursor(field string, cursor *pagination.Cursor, dir pagination.Order) bson.M {
+	op := "$gt"
+	if dir == pagination.Desc {
+		op = "$lt"
+	}
+
+	if cursor.Value == nil {
+		after := bson.A{bson.M{field: nil, "_id": bson.M{op: cursor.Id}}}
+		if dir == pagination.Asc {
+			after = append(after, bson.M{field: bson.M{"$ne": nil}})
+		}
+		return bson.M{"$or": after}
+	}
+
+	after := bson.A{
+		bson.M{field: bson.M{op: cursor.Value}},
+		bson.M{field: cursor.Value, "_id": bson.M{op: cursor.Id}},
+	}
+	if dir == pagination.Desc {
+		after = append(after, bson.M{field: nil})
+	}
+	return bson.M{"$or": after}
+}
+
+func boundaryCursor(raw bson.Raw, field string, params pagination.Params, backward bool) (string, error) {
+	id, ok := raw.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return "", errors.New("document without object id")
+	}
+
+	var value interface{}
+	if v, err := raw.LookupErr(field); err == nil {
+		value = v
+	}
+
+	return pagination.Cursor{
+		Sort:     params.Sort,
+		Order:    params.Order,
+		Value:    value,
+		Id:       id,
+		Backward: backward,
+	}.Encode()
+}
+
+func decodeRaws(raws []bson.Raw, out interface{}) error {
+	slice := reflect.ValueOf(out).Elem()
+	res := reflect.MakeSlice(slice.Type(), len(raws), len(raws))
+	for i, raw := range raws {
+		if err := bson.Unmarshal(raw, res.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	slice.Set(res)
+	return nil
+}
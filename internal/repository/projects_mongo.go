@@ -3,11 +3,14 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProjectsRepo struct {
@@ -20,41 +23,34 @@ func NewProjectsRepo(db *mongo.Database) *ProjectsRepo {
 	}
 }
 
-func (r *ProjectsRepo) GetProjects(ctx context.Context, userId primitive.ObjectID) ([]domain.ProjectMin, error) {
-	cursor, err := r.db.Find(ctx, bson.M{"userId": userId})
-	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, nil
-		}
-		return nil, err
-	}
-
+func (r *ProjectsRepo) GetProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.ProjectMin, *pagination.Page, error) {
 	var projects []domain.ProjectMin
-	if err := cursor.All(ctx, &projects); err != nil {
-		return nil, err
+	// в портфолио попадают и проекты, в которых пользователь соавтор
+	filter := bson.M{
+		"$or":       bson.A{bson.M{"userId": userId}, bson.M{"collaborators.userId": userId}},
+		"access":    domain.All,
+		"published": true,
+		"deletedAt": nil,
 	}
-	return projects, nil
-}
-
-func (r *ProjectsRepo) GetSelfProjects(ctx context.Context, userId primitive.ObjectID) ([]domain.SelfProjectMin, error) {
-	cursor, err := r.db.Find(ctx, bson.M{"userId": userId})
+	page, err := findPage(ctx, r.db, filter, params, projectSorts, "createdAt", pagination.Desc, &projects)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, nil
-		}
-		return nil, err
+		return nil, nil, err
 	}
+	return projects, page, nil
+}
 
+func (r *ProjectsRepo) GetSelfProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
 	var projects []domain.SelfProjectMin
-	if err := cursor.All(ctx, &projects); err != nil {
-		return nil, err
+	page, err := findPage(ctx, r.db, bson.M{"userId": userId, "deletedAt": nil}, params, projectSorts, "createdAt", pagination.Desc, &projects)
+	if err != nil {
+		return nil, nil, err
 	}
-	return projects, nil
+	return projects, page, nil
 }
 
 func (r *ProjectsRepo) GetProjectById(ctx context.Context, projectId primitive.ObjectID) (*domain.Project, error) {
 	var project *domain.Project
-	if err := r.db.FindOne(ctx, bson.M{"_id": projectId}).Decode(&project); err != nil {
+	if err := r.db.FindOne(ctx, bson.M{"_id": projectId, "deletedAt": nil}).Decode(&project); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, domain.ErrProjectNotFound
 		}
@@ -66,7 +62,7 @@ func (r *ProjectsRepo) GetProjectById(ctx context.Context, projectId primitive.O
 
 func (r *ProjectsRepo) GetSelfProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error) {
 	var project *domain.SelfProject
-	if err := r.db.FindOne(ctx, bson.M{"_id": projectId, "userId": userId}).Decode(&project); err != nil {
+	if err := r.db.FindOne(ctx, bson.M{"_id": projectId, "userId": userId, "deletedAt": nil}).Decode(&project); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, domain.ErrProjectNotFound
 		}
@@ -76,27 +72,163 @@ func (r *ProjectsRepo) GetSelfProjectById(ctx context.Context, projectId, userId
 	return project, nil
 }
 
-func (r *ProjectsRepo) GetDrafts(ctx context.Context, userId primitive.ObjectID) ([]domain.SelfProjectMin, error) {
-	cursor, err := r.db.Find(ctx, bson.M{"userId": userId, "Published": false})
+// CreateIndexes создает индекс для списка проектов пользователя по числу лайков
+func (r *ProjectsRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "likes", Value: -1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetName("projects_user_likes"),
+	})
+	return err
+}
+
+// FillDefaults заполняет поля, появившиеся после создания проектов: счетчики отметок (без них проект
+// выпадает из постраничной сортировки по лайкам) и режим комментариев. Пустое значение null тоже заменяется.
+func (r *ProjectsRepo) FillDefaults(ctx context.Context) error {
+	defaults := bson.M{"likes": 0, "bookmarks": 0, "commentsMode": domain.CommentsOpen}
+	for field, value := range defaults {
+		if _, err := r.db.UpdateMany(ctx, bson.M{field: nil}, bson.M{"$set": bson.M{field: value}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ProjectsRepo) SetCommentsMode(ctx context.Context, projectId primitive.ObjectID, mode domain.CommentsMode) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$set": bson.M{"commentsMode": mode}})
+	return err
+}
+
+// IncReactions меняет счетчик лайков или закладок проекта на delta
+func (r *ProjectsRepo) IncReactions(ctx context.Context, projectId primitive.ObjectID, kind domain.ReactionKind, delta int) error {
+	field := "likes"
+	if kind == domain.ReactionBookmark {
+		field = "bookmarks"
+	}
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$inc": bson.M{field: delta}})
+	return err
+}
+
+// GetPublicByIds возвращает опубликованные проекты из ids, которые можно открыть по ссылке
+func (r *ProjectsRepo) GetPublicByIds(ctx context.Context, ids []primitive.ObjectID) ([]domain.ProjectMin, error) {
+	filter := bson.M{"_id": bson.M{"$in": ids}, "published": true, "deletedAt": nil, "access": bson.M{"$ne": domain.Nobody}}
+	cursor, err := r.db.Find(ctx, filter)
 	if err != nil {
+		return nil, err
+	}
+	var projects []domain.ProjectMin
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// IncViews увеличивает счетчик просмотров проекта
+func (r *ProjectsRepo) IncViews(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$inc": bson.M{"views": 1}})
+	return err
+}
+
+// GetMemberProjectById возвращает проект, если пользователь - его владелец или соавтор
+func (r *ProjectsRepo) GetMemberProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error) {
+	var project *domain.SelfProject
+	filter := bson.M{
+		"_id":       projectId,
+		"$or":       bson.A{bson.M{"userId": userId}, bson.M{"collaborators.userId": userId}},
+		"deletedAt": nil,
+	}
+	if err := r.db.FindOne(ctx, filter).Decode(&project); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, nil
+			return nil, domain.ErrProjectNotFound
 		}
 		return nil, err
 	}
+	return project, nil
+}
 
+// GetShared возвращает проекты других пользователей, в которых пользователь соавтор
+func (r *ProjectsRepo) GetShared(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
 	var projects []domain.SelfProjectMin
-	if err := cursor.All(ctx, &projects); err != nil {
-		return nil, err
+	page, err := findPage(ctx, r.db, bson.M{"collaborators.userId": userId, "deletedAt": nil}, params, projectSorts, "updatedAt",
+		pagination.Desc, &projects)
+	if err != nil {
+		return nil, nil, err
 	}
-	return projects, nil
+	return projects, page, nil
+}
+
+// AddCollaborator добавляет соавтора, если его еще нет в проекте
+func (r *ProjectsRepo) AddCollaborator(ctx context.Context, projectId primitive.ObjectID, collaborator domain.Collaborator) error {
+	res, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId, "collaborators.userId": bson.M{"$ne": collaborator.UserId}},
+		bson.M{"$push": bson.M{"collaborators": collaborator}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrCollaboratorExists
+	}
+	return nil
+}
+
+func (r *ProjectsRepo) SetCollaboratorRole(ctx context.Context, projectId, userId primitive.ObjectID, role domain.CollaboratorRole) error {
+	res, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId, "collaborators.userId": userId},
+		bson.M{"$set": bson.M{"collaborators.$.role": role}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrCollaboratorNotFound
+	}
+	return nil
+}
+
+func (r *ProjectsRepo) RemoveCollaborator(ctx context.Context, projectId, userId primitive.ObjectID) error {
+	res, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId, "collaborators.userId": userId},
+		bson.M{"$pull": bson.M{"collaborators": bson.M{"userId": userId}}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrCollaboratorNotFound
+	}
+	return nil
 }
 
-func (r *ProjectsRepo) CreateProject(ctx context.Context, project domain.ProjectInput) error {
-	_, err := r.db.InsertOne(ctx, project)
+// RemoveCollaboratorEverywhere убирает пользователя из соавторов всех проектов
+func (r *ProjectsRepo) RemoveCollaboratorEverywhere(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.UpdateMany(ctx, bson.M{"collaborators.userId": userId}, bson.M{"$pull": bson.M{"collaborators": bson.M{"userId": userId}}})
 	return err
 }
 
+func (r *ProjectsRepo) GetDrafts(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
+	var projects []domain.SelfProjectMin
+	page, err := findPage(ctx, r.db, bson.M{
+		"userId":    userId,
+		"deletedAt": nil,
+		"$or":       bson.A{bson.M{"published": bson.M{"$ne": true}}, bson.M{"draft": bson.M{"$exists": true}}},
+	}, params, projectSorts, "updatedAt", pagination.Desc, &projects)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projects, page, nil
+}
+
+func (r *ProjectsRepo) GetAllProjects(ctx context.Context, params pagination.Params) ([]domain.SelfProject, *pagination.Page, error) {
+	var projects []domain.SelfProject
+	page, err := findPage(ctx, r.db, bson.M{"deletedAt": nil}, params, projectSorts, "createdAt", pagination.Asc, &projects)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projects, page, nil
+}
+
+func (r *ProjectsRepo) CreateProject(ctx context.Context, project domain.ProjectInput) (primitive.ObjectID, error) {
+	res, err := r.db.InsertOne(ctx, project)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return res.InsertedID.(primitive.ObjectID), nil
+}
+
 func (r *ProjectsRepo) UpdateProject(ctx context.Context, projectId primitive.ObjectID, project domain.SelfProject) error {
 	update := bson.M{}
 	if project.Name != "" {
@@ -105,20 +237,207 @@ func (r *ProjectsRepo) UpdateProject(ctx context.Context, projectId primitive.Ob
 	if project.Description != "" {
 		update["description"] = project.Description
 	}
+	if project.Tags != nil {
+		update["tags"] = project.Tags
+	}
 	if project.Files != nil {
 		update["files"] = project.Files
 	}
 	if project.Access != "" {
 		update["access"] = project.Access
 	}
-	update["published"] = project.Published
+	if project.Order != 0 {
+		update["order"] = project.Order
+	}
 	update["updatedAt"] = project.UpdatedAt
 
 	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$set": update})
 	return err
 }
 
-func (r *ProjectsRepo) RemoveProject(ctx context.Context, projectId primitive.ObjectID) error {
+func (r *ProjectsRepo) SaveDraft(ctx context.Context, projectId primitive.ObjectID, draft domain.ProjectDraft) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$set": bson.M{"draft": draft, "updatedAt": draft.UpdatedAt}})
+	return err
+}
+
+// PublishProject делает проект публичным. Если передан черновик, он заменяет опубликованную версию.
+func (r *ProjectsRepo) PublishProject(ctx context.Context, projectId primitive.ObjectID, draft *domain.ProjectDraft, publishedAt time.Time) error {
+	set := bson.M{"published": true, "publishedAt": publishedAt}
+	if draft != nil {
+		set["name"] = draft.Name
+		set["description"] = draft.Description
+		set["tags"] = draft.Tags
+		set["files"] = draft.Files
+		set["updatedAt"] = draft.UpdatedAt
+	}
+
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"draft": "", "publishAt": ""},
+	})
+	return err
+}
+
+func (r *ProjectsRepo) UnpublishProject(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{
+		"$set":   bson.M{"published": false},
+		"$unset": bson.M{"publishAt": ""},
+	})
+	return err
+}
+
+func (r *ProjectsRepo) SetPublishAt(ctx context.Context, projectId primitive.ObjectID, publishAt *time.Time) error {
+	update := bson.M{"$unset": bson.M{"publishAt": ""}}
+	if publishAt != nil {
+		update = bson.M{"$set": bson.M{"publishAt": publishAt}}
+	}
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, update)
+	return err
+}
+
+func (r *ProjectsRepo) GetScheduled(ctx context.Context, before time.Time) ([]domain.SelfProject, error) {
+	cursor, err := r.db.Find(ctx, bson.M{"publishAt": bson.M{"$lte": before}, "deletedAt": nil})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var projects []domain.SelfProject
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// RestoreProject заменяет содержимое проекта снимком. Для опубликованного проекта содержимое пишется в черновик.
+func (r *ProjectsRepo) RestoreProject(ctx context.Context, projectId primitive.ObjectID, snapshot domain.ProjectSnapshot, asDraft bool, updatedAt time.Time) error {
+	set := bson.M{"access": snapshot.Access, "order": snapshot.Order, "updatedAt": updatedAt}
+	if asDraft {
+		set["draft"] = domain.ProjectDraft{
+			Name:        snapshot.Name,
+			Description: snapshot.Description,
+			Tags:        snapshot.Tags,
+			Files:       snapshot.Files,
+			UpdatedAt:   updatedAt,
+		}
+	} else {
+		set["name"] = snapshot.Name
+		set["description"] = snapshot.Description
+		set["tags"] = snapshot.Tags
+		set["files"] = snapshot.Files
+	}
+
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$set": set})
+	return err
+}
+
+// RemoveProject переносит проект в корзину
+func (r *ProjectsRepo) RemoveProject(ctx context.Context, projectId primitive.ObjectID, deletedAt time.Time) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId, "deletedAt": nil}, bson.M{"$set": bson.M{"deletedAt": deletedAt}})
+	return err
+}
+
+// RemoveByUser переносит в корзину все проекты пользователя вместе с ним самим
+func (r *ProjectsRepo) RemoveByUser(ctx context.Context, userId primitive.ObjectID, deletedAt time.Time) error {
+	_, err := r.db.UpdateMany(ctx, bson.M{"userId": userId, "deletedAt": nil}, bson.M{
+		"$set": bson.M{"deletedAt": deletedAt, "deletedWithUser": true},
+	})
+	return err
+}
+
+func (r *ProjectsRepo) GetDeleted(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
+	var projects []domain.SelfProjectMin
+	filter := bson.M{"userId": userId, "deletedAt": bson.M{"$ne": nil}, "deletedWithUser": bson.M{"$ne": true}}
+	page, err := findPage(ctx, r.db, filter, params, projectSorts, "deletedAt", pagination.Desc, &projects)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projects, page, nil
+}
+
+func (r *ProjectsRepo) GetDeletedById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error) {
+	var project *domain.SelfProject
+	filter := bson.M{"_id": projectId, "userId": userId, "deletedAt": bson.M{"$ne": nil}, "deletedWithUser": bson.M{"$ne": true}}
+	if err := r.db.FindOne(ctx, filter).Decode(&project); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrProjectNotFound
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+// GetDeletedBefore возвращает проекты, удаленные раньше before отдельно от своего владельца
+func (r *ProjectsRepo) GetDeletedBefore(ctx context.Context, before time.Time) ([]domain.SelfProject, error) {
+	cursor, err := r.db.Find(ctx, bson.M{"deletedAt": bson.M{"$lt": before}, "deletedWithUser": bson.M{"$ne": true}})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var projects []domain.SelfProject
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (r *ProjectsRepo) RestoreDeleted(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": projectId}, bson.M{"$unset": bson.M{"deletedAt": "", "deletedWithUser": ""}})
+	return err
+}
+
+func (r *ProjectsRepo) RestoreByUser(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.UpdateMany(ctx, bson.M{"userId": userId, "deletedWithUser": true}, bson.M{
+		"$unset": bson.M{"deletedAt": "", "deletedWithUser": ""},
+	})
+	return err
+}
+
+func (r *ProjectsRepo) GetIdsByUser(ctx context.Context, userId primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := r.db.Find(ctx, bson.M{"userId": userId}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var projects []domain.ProjectMin
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(projects))
+	for _, p := range projects {
+		ids = append(ids, p.Id)
+	}
+	return ids, nil
+}
+
+// GetAllFiles возвращает файлы живых версий и черновиков всех проектов, включая удаленные
+func (r *ProjectsRepo) GetAllFiles(ctx context.Context) ([]domain.SelfProject, error) {
+	cursor, err := r.db.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"userId": 1, "files": 1, "draft.files": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var projects []domain.SelfProject
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// PurgeProject удаляет проект окончательно
+func (r *ProjectsRepo) PurgeProject(ctx context.Context, projectId primitive.ObjectID) error {
 	_, err := r.db.DeleteOne(ctx, bson.M{"_id": projectId})
 	return err
 }
+
+func (r *ProjectsRepo) PurgeByUser(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"userId": userId})
+	return err
+}
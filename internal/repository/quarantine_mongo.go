@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/database/mongodb"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuarantineRepo struct {
+	db *mongo.Collection
+}
+
+func NewQuarantineRepo(db *mongo.Database) *QuarantineRepo {
+	return &QuarantineRepo{
+		db: db.Collection(quarantineCollection),
+	}
+}
+
+// CreateIndexes создает уникальный индекс по пользователю и содержимому: повторная загрузка того же файла
+// не добавляет запись в карантин
+func (r *QuarantineRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "hash", Value: 1}},
+		Options: options.Index().SetName("quarantine_user_hash").SetUnique(true),
+	})
+	return err
+}
+
+// Create добавляет файл в карантин, если он там еще не лежит
+func (r *QuarantineRepo) Create(ctx context.Context, file domain.QuarantinedFile) error {
+	_, err := r.db.InsertOne(ctx, file)
+	if mongodb.IsDuplicate(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *QuarantineRepo) GetById(ctx context.Context, id primitive.ObjectID) (*domain.QuarantinedFile, error) {
+	var file domain.QuarantinedFile
+	if err := r.db.FindOne(ctx, bson.M{"_id": id}).Decode(&file); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrQuarantinedFileNotFound
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *QuarantineRepo) GetAll(ctx context.Context, params pagination.Params) ([]domain.QuarantinedFile, *pagination.Page, error) {
+	var files []domain.QuarantinedFile
+	page, err := findPage(ctx, r.db, bson.M{}, params, quarantineSorts, "createdAt", pagination.Desc, &files)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, page, nil
+}
+
+func (r *QuarantineRepo) Remove(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.db.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrQuarantinedFileNotFound
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const rateLimitPrefix = "ratelimit:"
+
+// RateLimitRepo считает действия в окне фиксированной длины
+type RateLimitRepo struct {
+	client *redis.Client
+}
+
+func NewRateLimitRepo(client *redis.Client) *RateLimitRepo {
+	return &RateLimitRepo{
+		client: client,
+	}
+}
+
+// Hit учитывает действие и возвращает, сколько их было с начала окна, включая это. Окно начинается
+// с первого действия и длится window.
+func (r *RateLimitRepo) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	key = rateLimitPrefix + key
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// счетчик создается вместе со сроком жизни, INCR срок не меняет
+		pipe.SetNX(ctx, key, 0, window)
+		incr = pipe.Incr(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/database/mongodb"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReactionsRepo хранит лайки и закладки, по одной записи на проект, пользователя и вид отметки
+type ReactionsRepo struct {
+	db *mongo.Collection
+}
+
+func NewReactionsRepo(db *mongo.Database) *ReactionsRepo {
+	return &ReactionsRepo{
+		db: db.Collection(reactionsCollection),
+	}
+}
+
+// CreateIndexes создает уникальный индекс отметок, благодаря ему повторная отметка не добавляется,
+// и индекс для списка закладок пользователя
+func (r *ReactionsRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "projectId", Value: 1}, {Key: "userId", Value: 1}, {Key: "kind", Value: 1}},
+			Options: options.Index().SetName("reactions_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "kind", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("reactions_user"),
+		},
+	})
+	return err
+}
+
+// Add сохраняет отметку и сообщает, что ее еще не было
+func (r *ReactionsRepo) Add(ctx context.Context, reaction domain.Reaction) (bool, error) {
+	_, err := r.db.InsertOne(ctx, reaction)
+	if mongodb.IsDuplicate(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Remove удаляет отметку и возвращает ее, nil - отметки не было
+func (r *ReactionsRepo) Remove(ctx context.Context, projectId, userId primitive.ObjectID, kind domain.ReactionKind) (*domain.Reaction, error) {
+	var reaction domain.Reaction
+	err := r.db.FindOneAndDelete(ctx, bson.M{"projectId": projectId, "userId": userId, "kind": kind}).Decode(&reaction)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+// GetByProjectUser возвращает отметки пользователя на проекте
+func (r *ReactionsRepo) GetByProjectUser(ctx context.Context, projectId, userId primitive.ObjectID) ([]domain.Reaction, error) {
+	return r.find(ctx, bson.M{"projectId": projectId, "userId": userId})
+}
+
+func (r *ReactionsRepo) GetByUser(ctx context.Context, userId primitive.ObjectID, kind domain.ReactionKind,
+	params pagination.Params) ([]domain.Reaction, *pagination.Page, error) {
+	var reactions []domain.Reaction
+	page, err := findPage(ctx, r.db, bson.M{"userId": userId, "kind": kind}, params, reactionSorts, "createdAt", pagination.Desc, &reactions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reactions, page, nil
+}
+
+// GetAllByUser возвращает все отметки пользователя, нужны при его окончательном удалении
+func (r *ReactionsRepo) GetAllByUser(ctx context.Context, userId primitive.ObjectID) ([]domain.Reaction, error) {
+	return r.find(ctx, bson.M{"userId": userId})
+}
+
+func (r *ReactionsRepo) RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"projectId": projectId})
+	return err
+}
+
+func (r *ReactionsRepo) RemoveById(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *ReactionsRepo) find(ctx context.Context, filter bson.M) ([]domain.Reaction, error) {
+	cursor, err := r.db.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var reactions []domain.Reaction
+	if err := cursor.All(ctx, &reactions); err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
 	"github.com/go-redis/redis/v8"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,8 +18,16 @@ type Users interface {
 	SetSession(ctx context.Context, userId primitive.ObjectID) error
 	GetById(ctx context.Context, userId primitive.ObjectID) (domain.User, error)
 	UpdateById(ctx context.Context, userId primitive.ObjectID, user domain.UserUpdate) error
-	RemoveById(ctx context.Context, userId primitive.ObjectID) error
-	GetAllUsers(ctx context.Context) ([]domain.User, error)
+	RemoveById(ctx context.Context, userId primitive.ObjectID, deletedAt time.Time) error
+	GetAllUsers(ctx context.Context, params pagination.Params) ([]domain.User, *pagination.Page, error)
+
+	GetDeleted(ctx context.Context, params pagination.Params) ([]domain.User, *pagination.Page, error)
+	GetDeletedById(ctx context.Context, userId primitive.ObjectID) (domain.User, error)
+	GetDeletedBefore(ctx context.Context, before time.Time) ([]domain.User, error)
+	GetAllAvatars(ctx context.Context) ([]domain.User, error)
+	GetAuthors(ctx context.Context, ids []primitive.ObjectID) ([]domain.ProjectAuthor, error)
+	RestoreById(ctx context.Context, userId primitive.ObjectID) error
+	PurgeById(ctx context.Context, userId primitive.ObjectID) error
 }
 
 type Auth interface {
@@ -27,27 +37,209 @@ type Auth interface {
 }
 
 type Projects interface {
-	GetProjects(ctx context.Context, userId primitive.ObjectID) ([]domain.ProjectMin, error)
-	CreateProject(ctx context.Context, project domain.ProjectInput) error
+	GetProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.ProjectMin, *pagination.Page, error)
+	CreateProject(ctx context.Context, project domain.ProjectInput) (primitive.ObjectID, error)
 	GetProjectById(ctx context.Context, projectId primitive.ObjectID) (*domain.Project, error)
 	UpdateProject(ctx context.Context, projectId primitive.ObjectID, project domain.SelfProject) error
-	RemoveProject(ctx context.Context, projectId primitive.ObjectID) error
+	RemoveProject(ctx context.Context, projectId primitive.ObjectID, deletedAt time.Time) error
+	SaveDraft(ctx context.Context, projectId primitive.ObjectID, draft domain.ProjectDraft) error
+	PublishProject(ctx context.Context, projectId primitive.ObjectID, draft *domain.ProjectDraft, publishedAt time.Time) error
+	UnpublishProject(ctx context.Context, projectId primitive.ObjectID) error
+	SetPublishAt(ctx context.Context, projectId primitive.ObjectID, publishAt *time.Time) error
+	GetScheduled(ctx context.Context, before time.Time) ([]domain.SelfProject, error)
+	RestoreProject(ctx context.Context, projectId primitive.ObjectID, snapshot domain.ProjectSnapshot, asDraft bool, updatedAt time.Time) error
+
+	RemoveByUser(ctx context.Context, userId primitive.ObjectID, deletedAt time.Time) error
+	GetDeleted(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
+	GetDeletedById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error)
+	GetDeletedBefore(ctx context.Context, before time.Time) ([]domain.SelfProject, error)
+	RestoreDeleted(ctx context.Context, projectId primitive.ObjectID) error
+	RestoreByUser(ctx context.Context, userId primitive.ObjectID) error
+	GetIdsByUser(ctx context.Context, userId primitive.ObjectID) ([]primitive.ObjectID, error)
+	GetAllFiles(ctx context.Context) ([]domain.SelfProject, error)
+	PurgeProject(ctx context.Context, projectId primitive.ObjectID) error
+	PurgeByUser(ctx context.Context, userId primitive.ObjectID) error
 
-	GetDrafts(ctx context.Context, userId primitive.ObjectID) ([]domain.SelfProjectMin, error)
+	GetDrafts(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
 	GetSelfProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error)
-	GetSelfProjects(ctx context.Context, userId primitive.ObjectID) ([]domain.SelfProjectMin, error)
+	GetMemberProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error)
+	GetSelfProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
+	GetAllProjects(ctx context.Context, params pagination.Params) ([]domain.SelfProject, *pagination.Page, error)
+
+	GetShared(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
+	AddCollaborator(ctx context.Context, projectId primitive.ObjectID, collaborator domain.Collaborator) error
+	SetCollaboratorRole(ctx context.Context, projectId, userId primitive.ObjectID, role domain.CollaboratorRole) error
+	RemoveCollaborator(ctx context.Context, projectId, userId primitive.ObjectID) error
+	RemoveCollaboratorEverywhere(ctx context.Context, userId primitive.ObjectID) error
+
+	IncViews(ctx context.Context, projectId primitive.ObjectID) error
+	IncReactions(ctx context.Context, projectId primitive.ObjectID, kind domain.ReactionKind, delta int) error
+	GetPublicByIds(ctx context.Context, ids []primitive.ObjectID) ([]domain.ProjectMin, error)
+	SetCommentsMode(ctx context.Context, projectId primitive.ObjectID, mode domain.CommentsMode) error
+}
+
+type Revisions interface {
+	Create(ctx context.Context, revision domain.Revision) (primitive.ObjectID, error)
+	GetById(ctx context.Context, projectId, revisionId primitive.ObjectID) (*domain.Revision, error)
+	GetByProject(ctx context.Context, projectId primitive.ObjectID, params pagination.Params) ([]domain.Revision, *pagination.Page, error)
+	Prune(ctx context.Context, projectId primitive.ObjectID, keep int, olderThan time.Time) error
+	RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error
+	GetAllFiles(ctx context.Context) ([]domain.Revision, error)
+}
+
+type Uploads interface {
+	Save(ctx context.Context, upload domain.Upload) error
+	RemoveByKey(ctx context.Context, key string) error
+	RemoveByPrefix(ctx context.Context, prefix string) error
+	GetUsage(ctx context.Context, userId primitive.ObjectID) (int64, error)
+}
+
+type Files interface {
+	Create(ctx context.Context, file domain.StoredFile) error
+	GetById(ctx context.Context, id string) (*domain.StoredFile, error)
+	GetAll(ctx context.Context) ([]domain.StoredFile, error)
+	AddRef(ctx context.Context, id string, ref domain.FileRef) (*domain.StoredFile, error)
+	RemoveRef(ctx context.Context, dir, name string) (*domain.StoredFile, error)
+	RemoveOtherRef(ctx context.Context, id, dir, name string) (*domain.StoredFile, error)
+	SetRefPrivate(ctx context.Context, dir, name string, private bool) (*domain.StoredFile, error)
+	GetByDirPrefix(ctx context.Context, prefix string) ([]domain.StoredFile, error)
+	RemoveUnreferenced(ctx context.Context, id string) (bool, error)
+}
+
+type Invitations interface {
+	Create(ctx context.Context, invitation domain.Invitation) (primitive.ObjectID, error)
+	GetById(ctx context.Context, id primitive.ObjectID) (*domain.Invitation, error)
+	GetPendingByUser(ctx context.Context, userId primitive.ObjectID) ([]domain.Invitation, error)
+	GetPendingByProject(ctx context.Context, projectId primitive.ObjectID) ([]domain.Invitation, error)
+	Answer(ctx context.Context, id primitive.ObjectID, status domain.InvitationStatus, answeredAt time.Time) error
+	Remove(ctx context.Context, projectId, id primitive.ObjectID) error
+	RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error
+	RemoveByUser(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type Views interface {
+	Record(ctx context.Context, view domain.DailyView, dimensions map[string]string, newVisitor bool) error
+	GetSeries(ctx context.Context, target domain.ViewTarget, targetId primitive.ObjectID, from, to time.Time) ([]domain.DailyView, error)
+	GetTop(ctx context.Context, target domain.ViewTarget, targetId primitive.ObjectID, dimension string, from, to time.Time,
+		limit int) ([]domain.ViewCount, error)
+	RemoveByTarget(ctx context.Context, target domain.ViewTarget, targetId primitive.ObjectID) error
+	RemoveByOwner(ctx context.Context, ownerId primitive.ObjectID) error
+}
+
+type Reactions interface {
+	Add(ctx context.Context, reaction domain.Reaction) (bool, error)
+	Remove(ctx context.Context, projectId, userId primitive.ObjectID, kind domain.ReactionKind) (*domain.Reaction, error)
+	GetByProjectUser(ctx context.Context, projectId, userId primitive.ObjectID) ([]domain.Reaction, error)
+	GetByUser(ctx context.Context, userId primitive.ObjectID, kind domain.ReactionKind, params pagination.Params) ([]domain.Reaction, *pagination.Page, error)
+	GetAllByUser(ctx context.Context, userId primitive.ObjectID) ([]domain.Reaction, error)
+	RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error
+	RemoveById(ctx context.Context, id primitive.ObjectID) error
+}
+
+type Comments interface {
+	Create(ctx context.Context, comment domain.Comment) (primitive.ObjectID, error)
+	GetById(ctx context.Context, projectId, id primitive.ObjectID) (*domain.Comment, error)
+	GetThreads(ctx context.Context, projectId primitive.ObjectID, params pagination.Params) ([]domain.Comment, *pagination.Page, error)
+	GetReplies(ctx context.Context, threadId primitive.ObjectID, params pagination.Params) ([]domain.Comment, *pagination.Page, error)
+	GetByProject(ctx context.Context, projectId primitive.ObjectID, status domain.CommentStatus,
+		params pagination.Params) ([]domain.Comment, *pagination.Page, error)
+	GetAllByUser(ctx context.Context, userId primitive.ObjectID) ([]domain.Comment, error)
+	SetStatus(ctx context.Context, projectId, id primitive.ObjectID, status domain.CommentStatus) (*domain.Comment, error)
+	IncReplies(ctx context.Context, threadId primitive.ObjectID, delta int) error
+	Remove(ctx context.Context, projectId, id primitive.ObjectID) (*domain.Comment, error)
+	RemoveThread(ctx context.Context, threadId primitive.ObjectID) error
+	RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error
+}
+
+type Notifications interface {
+	Create(ctx context.Context, notification domain.Notification) error
+	GetByUser(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.Notification, *pagination.Page, error)
+	CountUnread(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	MarkRead(ctx context.Context, userId, id primitive.ObjectID) error
+	MarkAllRead(ctx context.Context, userId primitive.ObjectID) error
+	RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error
+	RemoveByUser(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type Messages interface {
+	Create(ctx context.Context, message domain.ContactMessage) (primitive.ObjectID, error)
+	GetByFolder(ctx context.Context, userId primitive.ObjectID, folder domain.MessageFolder,
+		params pagination.Params) ([]domain.ContactMessage, *pagination.Page, error)
+	CountUnread(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	SetRead(ctx context.Context, userId, id primitive.ObjectID, read bool) error
+	Move(ctx context.Context, userId, id primitive.ObjectID, folder domain.MessageFolder) error
+	Remove(ctx context.Context, userId, id primitive.ObjectID) error
+	RemoveByUser(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type RateLimits interface {
+	Hit(ctx context.Context, key string, window time.Duration) (int64, error)
+}
+
+type Visitors interface {
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+type Quarantine interface {
+	Create(ctx context.Context, file domain.QuarantinedFile) error
+	GetById(ctx context.Context, id primitive.ObjectID) (*domain.QuarantinedFile, error)
+	GetAll(ctx context.Context, params pagination.Params) ([]domain.QuarantinedFile, *pagination.Page, error)
+	Remove(ctx context.Context, id primitive.ObjectID) error
+}
+
+type UploadSessions interface {
+	Create(ctx context.Context, session domain.UploadSession, ttl time.Duration) error
+	Get(ctx context.Context, id string) (*domain.UploadSession, error)
+	Update(ctx context.Context, session domain.UploadSession, ttl time.Duration) error
+	Remove(ctx context.Context, id string) error
+	Lock(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, id string) error
+}
+
+type Search interface {
+	IndexProject(ctx context.Context, project domain.SelfProject) error
+	RemoveProject(ctx context.Context, projectId primitive.ObjectID) error
+	IndexUser(ctx context.Context, user domain.User) error
+	RemoveUser(ctx context.Context, userId primitive.ObjectID) error
+	Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResults, error)
 }
 
 type Repositories struct {
 	Users
 	Auth
 	Projects
+	Revisions
+	Uploads
+	Files
+	Quarantine
+	Invitations
+	Views
+	Visitors
+	Reactions
+	Comments
+	Notifications
+	Messages
+	RateLimits
+	UploadSessions
 }
 
 func NewRepositories(db *mongo.Database, client *redis.Client) *Repositories {
 	return &Repositories{
-		Auth:     NewAuthRepo(client),
-		Users:    NewUsersRepo(db),
-		Projects: NewProjectsRepo(db),
+		Auth:           NewAuthRepo(client),
+		Users:          NewUsersRepo(db),
+		Projects:       NewProjectsRepo(db),
+		Revisions:      NewRevisionsRepo(db),
+		Uploads:        NewUploadsRepo(db),
+		Files:          NewFilesRepo(db),
+		Quarantine:     NewQuarantineRepo(db),
+		Invitations:    NewInvitationsRepo(db),
+		Views:          NewViewsRepo(db),
+		Visitors:       NewVisitorsRepo(client),
+		Reactions:      NewReactionsRepo(db),
+		Comments:       NewCommentsRepo(db),
+		Notifications:  NewNotificationsRepo(db),
+		Messages:       NewMessagesRepo(db),
+		RateLimits:     NewRateLimitRepo(client),
+		UploadSessions: NewUploadSessionsRepo(client),
 	}
 }
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var revisionSorts = map[string]string{
+	"createdAt": "createdAt",
+}
+
+// RevisionsRepo не умеет изменять ревизии: они только создаются и удаляются по правилам хранения
+type RevisionsRepo struct {
+	db *mongo.Collection
+}
+
+func NewRevisionsRepo(db *mongo.Database) *RevisionsRepo {
+	return &RevisionsRepo{
+		db: db.Collection(revisionCollection),
+	}
+}
+
+func (r *RevisionsRepo) Create(ctx context.Context, revision domain.Revision) (primitive.ObjectID, error) {
+	res, err := r.db.InsertOne(ctx, revision)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return res.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *RevisionsRepo) GetById(ctx context.Context, projectId, revisionId primitive.ObjectID) (*domain.Revision, error) {
+	var revision *domain.Revision
+	if err := r.db.FindOne(ctx, bson.M{"_id": revisionId, "projectId": projectId}).Decode(&revision); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return revision, nil
+}
+
+func (r *RevisionsRepo) GetByProject(ctx context.Context, projectId primitive.ObjectID, params pagination.Params) ([]domain.Revision, *pagination.Page, error) {
+	var revisions []domain.Revision
+	page, err := findPage(ctx, r.db, bson.M{"projectId": projectId}, params, revisionSorts, "createdAt", pagination.Desc, &revisions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return revisions, page, nil
+}
+
+// Prune оставляет не больше keep последних ревизий проекта и удаляет ревизии старше olderThan.
+// Нулевые значения отключают соответствующее ограничение.
+func (r *RevisionsRepo) Prune(ctx context.Context, projectId primitive.ObjectID, keep int, olderThan time.Time) error {
+	if !olderThan.IsZero() {
+		if _, err := r.db.DeleteMany(ctx, bson.M{"projectId": projectId, "createdAt": bson.M{"$lt": olderThan}}); err != nil {
+			return err
+		}
+	}
+	if keep <= 0 {
+		return nil
+	}
+
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(keep - 1)).
+		SetProjection(bson.M{"_id": 1, "createdAt": 1})
+	var oldest domain.Revision
+	if err := r.db.FindOne(ctx, bson.M{"projectId": projectId}, opts).Decode(&oldest); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+
+	_, err := r.db.DeleteMany(ctx, bson.M{"projectId": projectId, "$or": bson.A{
+		bson.M{"createdAt": bson.M{"$lt": oldest.CreatedAt}},
+		bson.M{"createdAt": oldest.CreatedAt, "_id": bson.M{"$lt": oldest.Id}},
+	}})
+	return err
+}
+
+func (r *RevisionsRepo) RemoveByProject(ctx context.Context, projectId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"projectId": projectId})
+	return err
+}
+
+// GetAllFiles возвращает файлы из снимков всех ревизий
+func (r *RevisionsRepo) GetAllFiles(ctx context.Context) ([]domain.Revision, error) {
+	cursor, err := r.db.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"projectId": 1, "snapshot.files": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var revisions []domain.Revision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchBleve хранит встроенный индекс на диске, документы в нем обновляются сервисами при изменении данных
+type SearchBleve struct {
+	index   bleve.Index
+	created bool
+}
+
+type bleveDoc struct {
+	Type        string   `json:"type"`
+	UserId      string   `json:"userId"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+func NewSearchBleve(path string) (*SearchBleve, error) {
+	index, err := bleve.Open(path)
+	if err == nil {
+		return &SearchBleve{index: index}, nil
+	}
+	if !errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		return nil, err
+	}
+
+	index, err = bleve.New(path, bleveMapping())
+	if err != nil {
+		return nil, err
+	}
+	return &SearchBleve{index: index, created: true}, nil
+}
+
+func bleveMapping() mapping.IndexMapping {
+	keywordField := func(name string) *mapping.FieldMapping {
+		field := bleve.NewTextFieldMapping()
+		field.Analyzer = keyword.Name
+		field.Name = name
+		return field
+	}
+	textField := func() *mapping.FieldMapping {
+		field := bleve.NewTextFieldMapping()
+		field.Analyzer = standard.Name
+		field.IncludeTermVectors = true
+		return field
+	}
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("type", keywordField(""))
+	doc.AddFieldMappingsAt("userId", keywordField(""))
+	doc.AddFieldMappingsAt("name", textField())
+	doc.AddFieldMappingsAt("description", textField())
+	// теги ищутся как текст и фильтруются по точному значению через отдельное поле tag
+	doc.AddFieldMappingsAt("tags", textField(), keywordField("tag"))
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	return m
+}
+
+// Created сообщает, что индекс был создан заново и его нужно заполнить
+func (s *SearchBleve) Created() bool {
+	return s.created
+}
+
+func (s *SearchBleve) Close() error {
+	return s.index.Close()
+}
+
+func (s *SearchBleve) IndexProject(ctx context.Context, project domain.SelfProject) error {
+	if project.Access != domain.All || !project.Published {
+		return s.RemoveProject(ctx, project.Id)
+	}
+	return s.index.Index(bleveId(domain.SearchProject, project.Id), bleveDoc{
+		Type:        string(domain.SearchProject),
+		UserId:      project.UserId.Hex(),
+		Name:        project.Name,
+		Description: project.Description,
+		Tags:        project.Tags,
+	})
+}
+
+func (s *SearchBleve) RemoveProject(ctx context.Context, projectId primitive.ObjectID) error {
+	return s.index.Delete(bleveId(domain.SearchProject, projectId))
+}
+
+func (s *SearchBleve) IndexUser(ctx context.Context, user domain.User) error {
+	return s.index.Index(bleveId(domain.SearchUser, user.Id), bleveDoc{
+		Type:   string(domain.SearchUser),
+		UserId: user.Id.Hex(),
+		Name:   user.Name,
+	})
+}
+
+func (s *SearchBleve) RemoveUser(ctx context.Context, userId primitive.ObjectID) error {
+	return s.index.Delete(bleveId(domain.SearchUser, userId))
+}
+
+func (s *SearchBleve) Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchResults, error) {
+	text := bleve.NewDisjunctionQuery(
+		boostedMatch(q.Query, "name", 10),
+		boostedMatch(q.Query, "tags", 5),
+		boostedMatch(q.Query, "description", 1),
+	)
+	must := []query.Query{text}
+
+	if len(q.Types) > 0 {
+		types := bleve.NewDisjunctionQuery()
+		for _, t := range q.Types {
+			term := bleve.NewTermQuery(string(t))
+			term.SetField("type")
+			types.AddQuery(term)
+		}
+		must = append(must, types)
+	}
+	for _, tag := range q.Tags {
+		term := bleve.NewTermQuery(tag)
+		term.SetField("tag")
+		must = append(must, term)
+	}
+	if !q.UserId.IsZero() {
+		term := bleve.NewTermQuery(q.UserId.Hex())
+		term.SetField("userId")
+		must = append(must, term)
+	}
+
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(must...), q.Limit, q.Offset, false)
+	req.Fields = []string{"type", "userId", "name", "tags"}
+	req.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	req.Highlight.AddField("name")
+	req.Highlight.AddField("description")
+	req.Highlight.AddField("tags")
+
+	found, err := s.index.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &domain.SearchResults{Total: int(found.Total), Results: []domain.SearchResult{}}
+	for _, hit := range found.Hits {
+		parts := strings.SplitN(hit.ID, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(parts[1])
+		if err != nil {
+			continue
+		}
+		userId, _ := primitive.ObjectIDFromHex(fieldString(hit.Fields["userId"]))
+		name := fieldString(hit.Fields["name"])
+
+		// фрагменты без совпадений bleve возвращает как есть, в подсветку они не попадают
+		var highlights map[string][]string
+		for field, fragments := range hit.Fragments {
+			for _, f := range fragments {
+				if strings.Contains(f, "<mark>") {
+					if highlights == nil {
+						highlights = map[string][]string{}
+					}
+					highlights[field] = append(highlights[field], f)
+				}
+			}
+		}
+		res.Results = append(res.Results, domain.SearchResult{
+			Type:       domain.SearchType(parts[0]),
+			Id:         id,
+			UserId:     userId,
+			Name:       name,
+			Tags:       fieldStrings(hit.Fields["tags"]),
+			Score:      hit.Score,
+			Highlights: highlights,
+		})
+	}
+	return res, nil
+}
+
+func bleveId(t domain.SearchType, id primitive.ObjectID) string {
+	return string(t) + ":" + id.Hex()
+}
+
+func boostedMatch(text, field string, boost float64) query.Query {
+	match := bleve.NewMatchQuery(text)
+	match.SetField(field)
+	match.SetBoost(boost)
+	return match
+}
+
+func fieldString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// bleve возвращает одиночное значение массива строкой, а несколько значений - слайсом
+func fieldStrings(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		var res []string
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
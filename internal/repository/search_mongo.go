@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const fragmentSize = 160
+
+// SearchMongo ищет по текстовым индексам коллекций, поэтому отдельная синхронизация индекса не нужна
+type SearchMongo struct {
+	users    *mongo.Collection
+	projects *mongo.Collection
+}
+
+func NewSearchMongo(ctx context.Context, db *mongo.Database) (*SearchMongo, error) {
+	s := &SearchMongo{
+		users:    db.Collection(usersCollection),
+		projects: db.Collection(projectCollection),
+	}
+
+	_, err := s.projects.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: "text"}, {Key: "tags", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().
+			SetName("projects_text").
+			SetDefaultLanguage("none").
+			SetWeights(bson.M{"name": 10, "tags": 5, "description": 1}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: "text"}},
+		Options: options.Index().SetName("users_text").SetDefaultLanguage("none"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *SearchMongo) IndexProject(ctx context.Context, project domain.SelfProject) error {
+	return nil
+}
+
+func (s *SearchMongo) RemoveProject(ctx context.Context, projectId primitive.ObjectID) error {
+	return nil
+}
+
+func (s *SearchMongo) IndexUser(ctx context.Context, user domain.User) error {
+	return nil
+}
+
+func (s *SearchMongo) RemoveUser(ctx context.Context, userId primitive.ObjectID) error {
+	return nil
+}
+
+type projectHit struct {
+	Id          primitive.ObjectID `bson:"_id"`
+	UserId      primitive.ObjectID `bson:"userId"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description"`
+	Tags        []string           `bson:"tags"`
+	Score       float64            `bson:"score"`
+}
+
+type userHit struct {
+	Id    primitive.ObjectID `bson:"_id"`
+	Name  string             `bson:"name"`
+	Score float64            `bson:"score"`
+}
+
+func (s *SearchMongo) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResults, error) {
+	terms := searchTerms(query.Query)
+	// каждая коллекция отдает первые offset+limit документов, общий порядок собирается уже после слияния
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(int64(query.Offset + query.Limit))
+
+	res := &domain.SearchResults{Results: []domain.SearchResult{}}
+
+	if query.HasType(domain.SearchProject) {
+		filter := bson.M{"$text": bson.M{"$search": query.Query}, "access": domain.All, "published": true, "deletedAt": nil}
+		if len(query.Tags) > 0 {
+			filter["tags"] = bson.M{"$all": query.Tags}
+		}
+		if !query.UserId.IsZero() {
+			filter["userId"] = query.UserId
+		}
+
+		total, err := s.projects.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		cursor, err := s.projects.Find(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		var hits []projectHit
+		if err := cursor.All(ctx, &hits); err != nil {
+			return nil, err
+		}
+
+		res.Total += int(total)
+		for _, hit := range hits {
+			highlights := map[string][]string{}
+			if f := highlight(hit.Name, terms); f != "" {
+				highlights["name"] = []string{f}
+			}
+			if f := highlight(hit.Description, terms); f != "" {
+				highlights["description"] = []string{f}
+			}
+			for _, tag := range hit.Tags {
+				if f := highlight(tag, terms); f != "" {
+					highlights["tags"] = append(highlights["tags"], f)
+				}
+			}
+			if len(highlights) == 0 {
+				highlights = nil
+			}
+			res.Results = append(res.Results, domain.SearchResult{
+				Type:       domain.SearchProject,
+				Id:         hit.Id,
+				UserId:     hit.UserId,
+				Name:       hit.Name,
+				Tags:       hit.Tags,
+				Score:      hit.Score,
+				Highlights: highlights,
+			})
+		}
+	}
+
+	// у пользователей нет тегов, поэтому фильтр по тегам исключает их из выдачи
+	if query.HasType(domain.SearchUser) && len(query.Tags) == 0 {
+		filter := bson.M{"$text": bson.M{"$search": query.Query}, "deletedAt": nil}
+		if !query.UserId.IsZero() {
+			filter["_id"] = query.UserId
+		}
+
+		total, err := s.users.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		cursor, err := s.users.Find(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		var hits []userHit
+		if err := cursor.All(ctx, &hits); err != nil {
+			return nil, err
+		}
+
+		res.Total += int(total)
+		for _, hit := range hits {
+			result := domain.SearchResult{
+				Type:   domain.SearchUser,
+				Id:     hit.Id,
+				UserId: hit.Id,
+				Name:   hit.Name,
+				Score:  hit.Score,
+			}
+			if f := highlight(hit.Name, terms); f != "" {
+				result.Highlights = map[string][]string{"name": {f}}
+			}
+			res.Results = append(res.Results, result)
+		}
+	}
+
+	sort.SliceStable(res.Results, func(i, j int) bool {
+		return res.Results[i].Score > res.Results[j].Score
+	})
+	if query.Offset >= len(res.Results) {
+		res.Results = []domain.SearchResult{}
+		return res, nil
+	}
+	res.Results = res.Results[query.Offset:]
+	if len(res.Results) > query.Limit {
+		res.Results = res.Results[:query.Limit]
+	}
+
+	return res, nil
+}
+
+// searchTerms разбирает строку запроса в синтаксисе $text на отдельные слова для подсветки
+func searchTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ReplaceAll(query, `"`, " ")) {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// highlight оборачивает найденные слова в <mark> и обрезает текст до фрагмента вокруг первого совпадения
+func highlight(text string, terms []string) string {
+	if len(terms) == 0 || text == "" {
+		return ""
+	}
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	re := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+
+	matches := re.FindAllStringIndex(text, -1)
+	if matches == nil {
+		return ""
+	}
+
+	start, end := 0, len(text)
+	if utf8.RuneCountInString(text) > fragmentSize {
+		start = backRunes(text, matches[0][0], fragmentSize/4)
+		end = forwardRunes(text, start, fragmentSize)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[0] < pos {
+			continue
+		}
+		if m[1] > end {
+			break
+		}
+		b.WriteString(html.EscapeString(text[pos:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		pos = m[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func backRunes(s string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+func forwardRunes(s string, pos, n int) int {
+	for ; n > 0 && pos < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+	}
+	return pos
+}
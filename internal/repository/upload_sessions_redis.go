@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/go-redis/redis/v8"
+)
+
+const uploadSessionPrefix = "upload:"
+
+// UploadSessionsRepo хранит сессии возобновляемых загрузок в Redis, сессия удаляется по истечении ttl
+type UploadSessionsRepo struct {
+	client *redis.Client
+}
+
+func NewUploadSessionsRepo(client *redis.Client) *UploadSessionsRepo {
+	return &UploadSessionsRepo{
+		client: client,
+	}
+}
+
+func (r *UploadSessionsRepo) Create(ctx context.Context, session domain.UploadSession, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, uploadSessionPrefix+session.Id, data, ttl).Err()
+}
+
+func (r *UploadSessionsRepo) Get(ctx context.Context, id string) (*domain.UploadSession, error) {
+	data, err := r.client.Get(ctx, uploadSessionPrefix+id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, domain.ErrUploadNotFound
+		}
+		return nil, err
+	}
+	var session domain.UploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Update перезаписывает существующую сессию, истекшая сессия не восстанавливается
+func (r *UploadSessionsRepo) Update(ctx context.Context, session domain.UploadSession, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	ok, err := r.client.SetXX(ctx, uploadSessionPrefix+session.Id, data, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrUploadNotFound
+	}
+	return nil
+}
+
+func (r *UploadSessionsRepo) Remove(ctx context.Context, id string) error {
+	return r.client.Del(ctx, uploadSessionPrefix+id).Err()
+}
+
+// Lock не дает двум запросам одновременно писать в одну загрузку. Блокировка снимается сама через ttl,
+// если процесс упал, не сняв ее.
+func (r *UploadSessionsRepo) Lock(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, uploadSessionPrefix+id+":lock", 1, ttl).Result()
+}
+
+func (r *UploadSessionsRepo) Unlock(ctx context.Context, id string) error {
+	return r.client.Del(ctx, uploadSessionPrefix+id+":lock").Err()
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UploadsRepo ведет учет объектов в хранилище. Ключ объекта служит _id, поэтому перезапись файла
+// заменяет запись, а не добавляет новую.
+type UploadsRepo struct {
+	db *mongo.Collection
+}
+
+func NewUploadsRepo(db *mongo.Database) *UploadsRepo {
+	return &UploadsRepo{
+		db: db.Collection(uploadsCollection),
+	}
+}
+
+func (r *UploadsRepo) Save(ctx context.Context, upload domain.Upload) error {
+	_, err := r.db.ReplaceOne(ctx, bson.M{"_id": upload.Key}, upload, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *UploadsRepo) RemoveByKey(ctx context.Context, key string) error {
+	_, err := r.db.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+func (r *UploadsRepo) RemoveByPrefix(ctx context.Context, prefix string) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"_id": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}})
+	return err
+}
+
+func (r *UploadsRepo) GetUsage(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	cursor, err := r.db.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": userId}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "used": bson.M{"$sum": "$size"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var res []struct {
+		Used int64 `bson:"used"`
+	}
+	if err := cursor.All(ctx, &res); err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return res[0].Used, nil
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/Alexander272/my-portfolio/internal/domain"
 	"github.com/Alexander272/my-portfolio/pkg/database/mongodb"
 	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UsersRepo struct {
@@ -36,7 +38,7 @@ func (r *UsersRepo) Create(ctx context.Context, user domain.User) error {
 
 func (r *UsersRepo) GetByEmail(ctx context.Context, email string) (domain.User, error) {
 	var user domain.User
-	if err := r.db.FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
+	if err := r.db.FindOne(ctx, bson.M{"email": email, "deletedAt": nil}).Decode(&user); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return domain.User{}, domain.ErrUserNotFound
 		}
@@ -70,7 +72,7 @@ func (r *UsersRepo) SetSession(ctx context.Context, userId primitive.ObjectID) e
 
 func (r *UsersRepo) GetById(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
 	var user domain.User
-	if err := r.db.FindOne(ctx, bson.M{"_id": userId}).Decode(&user); err != nil {
+	if err := r.db.FindOne(ctx, bson.M{"_id": userId, "deletedAt": nil}).Decode(&user); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return domain.User{}, domain.ErrUserNotFound
 		}
@@ -80,6 +82,20 @@ func (r *UsersRepo) GetById(ctx context.Context, userId primitive.ObjectID) (dom
 	return user, nil
 }
 
+// GetAuthors возвращает публичные данные пользователей с id из ids, удаленные пользователи пропускаются
+func (r *UsersRepo) GetAuthors(ctx context.Context, ids []primitive.ObjectID) ([]domain.ProjectAuthor, error) {
+	cursor, err := r.db.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deletedAt": nil},
+		options.Find().SetProjection(bson.M{"name": 1, "userUrl": 1, "avatar": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var authors []domain.ProjectAuthor
+	if err := cursor.All(ctx, &authors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
 func (r *UsersRepo) UpdateById(ctx context.Context, userId primitive.ObjectID, user domain.UserUpdate) error {
 	update := bson.M{}
 	if user.Name != "" {
@@ -106,13 +122,40 @@ func (r *UsersRepo) UpdateById(ctx context.Context, userId primitive.ObjectID, u
 	return err
 }
 
-func (r *UsersRepo) RemoveById(ctx context.Context, userId primitive.ObjectID) error {
-	_, err := r.db.DeleteOne(ctx, bson.M{"_id": userId})
-	return err
+// RemoveById переносит пользователя в корзину
+func (r *UsersRepo) RemoveById(ctx context.Context, userId primitive.ObjectID, deletedAt time.Time) error {
+	res, err := r.db.UpdateOne(ctx, bson.M{"_id": userId, "deletedAt": nil}, bson.M{"$set": bson.M{"deletedAt": deletedAt}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UsersRepo) GetDeleted(ctx context.Context, params pagination.Params) ([]domain.User, *pagination.Page, error) {
+	var users []domain.User
+	page, err := findPage(ctx, r.db, bson.M{"deletedAt": bson.M{"$ne": nil}}, params, userSorts, "deletedAt", pagination.Desc, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, page, nil
+}
+
+func (r *UsersRepo) GetDeletedById(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
+	var user domain.User
+	if err := r.db.FindOne(ctx, bson.M{"_id": userId, "deletedAt": bson.M{"$ne": nil}}).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.User{}, domain.ErrUserNotFound
+		}
+		return domain.User{}, err
+	}
+	return user, nil
 }
 
-func (r *UsersRepo) GetAllUsers(ctx context.Context) ([]domain.User, error) {
-	cursor, err := r.db.Find(ctx, bson.M{})
+func (r *UsersRepo) GetDeletedBefore(ctx context.Context, before time.Time) ([]domain.User, error) {
+	cursor, err := r.db.Find(ctx, bson.M{"deletedAt": bson.M{"$lt": before}})
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
@@ -126,3 +169,36 @@ func (r *UsersRepo) GetAllUsers(ctx context.Context) ([]domain.User, error) {
 	}
 	return users, nil
 }
+
+// GetAllAvatars возвращает аватары всех пользователей, включая удаленных
+func (r *UsersRepo) GetAllAvatars(ctx context.Context) ([]domain.User, error) {
+	cursor, err := r.db.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"avatar": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var users []domain.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *UsersRepo) RestoreById(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": userId}, bson.M{"$unset": bson.M{"deletedAt": ""}})
+	return err
+}
+
+// PurgeById удаляет пользователя окончательно
+func (r *UsersRepo) PurgeById(ctx context.Context, userId primitive.ObjectID) error {
+	_, err := r.db.DeleteOne(ctx, bson.M{"_id": userId})
+	return err
+}
+
+func (r *UsersRepo) GetAllUsers(ctx context.Context, params pagination.Params) ([]domain.User, *pagination.Page, error) {
+	var users []domain.User
+	page, err := findPage(ctx, r.db, bson.M{"deletedAt": nil}, params, userSorts, "createdAt", pagination.Asc, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, page, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ViewsRepo хранит дневные счетчики просмотров: по документу на цель, день и значение разреза
+type ViewsRepo struct {
+	db *mongo.Collection
+}
+
+func NewViewsRepo(db *mongo.Database) *ViewsRepo {
+	return &ViewsRepo{
+		db: db.Collection(viewsCollection),
+	}
+}
+
+// CreateIndexes создает уникальный индекс счетчиков, по нему же выбирается статистика за период
+func (r *ViewsRepo) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "target", Value: 1}, {Key: "targetId", Value: 1}, {Key: "dimension", Value: 1}, {Key: "day", Value: 1},
+				{Key: "value", Value: 1}},
+			Options: options.Index().SetName("views_counter").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "ownerId", Value: 1}},
+			Options: options.Index().SetName("views_owner"),
+		},
+	})
+	return err
+}
+
+// Record учитывает просмотр в итоге за день и в каждом разрезе из dimensions (разрез - значение)
+func (r *ViewsRepo) Record(ctx context.Context, view domain.DailyView, dimensions map[string]string, newVisitor bool) error {
+	visitors := 0
+	if newVisitor {
+		visitors = 1
+	}
+	update := bson.M{
+		"$inc":         bson.M{"views": 1, "visitors": visitors},
+		"$setOnInsert": bson.M{"ownerId": view.OwnerId},
+	}
+
+	counter := func(dimension, value string) mongo.WriteModel {
+		filter := bson.M{"target": view.Target, "targetId": view.TargetId, "day": view.Day, "dimension": dimension, "value": value}
+		return mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true)
+	}
+	models := []mongo.WriteModel{counter(domain.ViewTotal, "")}
+	for dimension, value := range dimensions {
+		models = append(models, counter(dimension, value))
+	}
+	_, err := r.db.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// GetSeries возвращает итоги по дням за период, дни без просмотров пропускаются
+func (r *ViewsRepo) GetSeries(ctx context.Context, target domain.ViewTarget, targetId primitive.ObjectID, from, to time.Time) ([]domain.DailyView, error) {
+	filter := periodFilter(target, targetId, domain.ViewTotal, from, to)
+	cursor, err := r.db.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "day", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var series []domain.DailyView
+	if err := cursor.All(ctx, &series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// GetTop возвращает самые частые значения разреза за период
+func (r *ViewsRepo) GetTop(ctx context.Context, target domain.ViewTarget, targetId primitive.ObjectID, dimension string, from, to time.Time,
+	limit int) ([]domain.ViewCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: periodFilter(target, targetId, dimension, from, to)}},
+		{{Key: "$group", Value: bson.M{"_id": "$value", "views": bson.M{"$sum": "$views"}, "visitors": bson.M{"$sum": "$visitors"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "views", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	top := []domain.ViewCount{}
+	if err := cursor.All(ctx, &top); err != nil {
+		return nil, err
+	}
+	return top, nil
+}
+
+func (r *ViewsRepo) RemoveByTarget(ctx context.Context, target domain.ViewTarget, targetId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"target": target, "targetId": targetId})
+	return err
+}
+
+// RemoveByOwner удаляет статистику профиля пользователя и всех его проектов
+func (r *ViewsRepo) RemoveByOwner(ctx context.Context, ownerId primitive.ObjectID) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"ownerId": ownerId})
+	return err
+}
+
+func periodFilter(target domain.ViewTarget, targetId primitive.ObjectID, dimension string, from, to time.Time) bson.M {
+	return bson.M{
+		"target":    target,
+		"targetId":  targetId,
+		"dimension": dimension,
+		"day":       bson.M{"$gte": from, "$lte": to},
+	}
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const visitorPrefix = "visitor:"
+
+// VisitorsRepo помнит, каких посетителей уже видели, чтобы не считать их уникальными повторно
+type VisitorsRepo struct {
+	client *redis.Client
+}
+
+func NewVisitorsRepo(client *redis.Client) *VisitorsRepo {
+	return &VisitorsRepo{
+		client: client,
+	}
+}
+
+// Add запоминает посетителя на ttl и сообщает, что его еще не видели
+func (r *VisitorsRepo) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, visitorPrefix+key, 1, ttl).Result()
+}
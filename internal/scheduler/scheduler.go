@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+)
+
+type Job func(ctx context.Context) error
+
+type task struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler периодически запускает фоновые задачи, каждая задача выполняется в своей горутине
+type Scheduler struct {
+	tasks  []task
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) Add(name string, interval time.Duration, job Job) {
+	s.tasks = append(s.tasks, task{name: name, interval: interval, job: job})
+}
+
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, t := range s.tasks {
+		if t.interval <= 0 {
+			logger.Infof("scheduler: job %s is disabled", t.name)
+			continue
+		}
+		s.wg.Add(1)
+		go s.run(ctx, t)
+	}
+}
+
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, t task) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.job(ctx); err != nil && ctx.Err() == nil {
+			logger.Errorf("scheduler: job %s failed: %s", t.name, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
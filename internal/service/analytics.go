@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/geoip"
+	"github.com/Alexander272/my-portfolio/pkg/useragent"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	day = 24 * time.Hour
+	// период статистики по умолчанию и самый длинный допустимый период, в днях
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+	// сколько источников переходов и стран показывать в статистике
+	topViewsLimit = 10
+	// посетитель уникален в пределах суток, запись о нем живет чуть дольше на случай расхождения часов
+	visitorTTL = day + time.Hour
+)
+
+// AnalyticsOptions настраивает учет просмотров
+type AnalyticsOptions struct {
+	// Salt - секрет, с которым хешируются IP адреса посетителей
+	Salt string
+	// SiteHosts - хосты самого сайта, переходы с них не считаются источниками
+	SiteHosts []string
+}
+
+// AnalyticsService считает просмотры публичных проектов и профилей. Хранятся только дневные счетчики:
+// посетитель различается по хешу IP и User-Agent с солью, которая меняется каждый день, поэтому
+// посещения одного человека в разные дни не связать между собой. Боты не учитываются.
+type AnalyticsService struct {
+	views    repository.Views
+	visitors repository.Visitors
+	projects *ProjectService
+	geo      geoip.Locator
+	conf     AnalyticsOptions
+}
+
+func NewAnalyticsService(views repository.Views, visitors repository.Visitors, projects *ProjectService, geo geoip.Locator,
+	conf AnalyticsOptions) *AnalyticsService {
+	return &AnalyticsService{
+		views:    views,
+		visitors: visitors,
+		projects: projects,
+		geo:      geo,
+		conf:     conf,
+	}
+}
+
+// RecordView учитывает просмотр в счетчиках за текущий день (по UTC)
+func (s *AnalyticsService) RecordView(ctx context.Context, view domain.View) error {
+	if useragent.IsBot(view.UserAgent) {
+		return nil
+	}
+	today := time.Now().UTC().Truncate(day)
+
+	key := string(view.Target) + ":" + view.TargetId.Hex() + ":" + s.visitorId(today, view)
+	newVisitor, err := s.visitors.Add(ctx, key, visitorTTL)
+	if err != nil {
+		return err
+	}
+
+	dimensions := map[string]string{}
+	if host := s.referrerHost(view.Referrer); host != "" {
+		dimensions[domain.ViewReferrer] = host
+	}
+	if s.geo != nil {
+		if country := s.geo.Country(net.ParseIP(view.IP)); country != "" {
+			dimensions[domain.ViewCountry] = country
+		}
+	}
+
+	err = s.views.Record(ctx, domain.DailyView{
+		Target:   view.Target,
+		TargetId: view.TargetId,
+		OwnerId:  view.OwnerId,
+		Day:      today,
+	}, dimensions, newVisitor)
+	if err != nil {
+		return err
+	}
+	if view.Target == domain.ViewProject {
+		return s.projects.repo.IncViews(ctx, view.TargetId)
+	}
+	return nil
+}
+
+// GetProjectStats возвращает статистику проекта его владельцу. Нулевые from и to - последние 30 дней.
+func (s *AnalyticsService) GetProjectStats(ctx context.Context, projectId, userId primitive.ObjectID, from, to time.Time) (*domain.ViewStats, error) {
+	if _, err := s.projects.projectFor(ctx, projectId, userId, accessOwner); err != nil {
+		return nil, err
+	}
+	return s.stats(ctx, domain.ViewProject, projectId, from, to)
+}
+
+// GetProfileStats возвращает статистику просмотров профиля пользователя
+func (s *AnalyticsService) GetProfileStats(ctx context.Context, userId primitive.ObjectID, from, to time.Time) (*domain.ViewStats, error) {
+	return s.stats(ctx, domain.ViewProfile, userId, from, to)
+}
+
+func (s *AnalyticsService) stats(ctx context.Context, target domain.ViewTarget, targetId primitive.ObjectID, from, to time.Time) (*domain.ViewStats, error) {
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	to = to.UTC().Truncate(day)
+	if from.IsZero() {
+		from = to.Add(-(defaultStatsDays - 1) * day)
+	}
+	from = from.UTC().Truncate(day)
+	if from.After(to) || to.Sub(from) >= maxStatsDays*day {
+		return nil, domain.ErrInvalidStatsPeriod
+	}
+
+	series, err := s.views.GetSeries(ctx, target, targetId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	stats := &domain.ViewStats{From: from, To: to, Series: []domain.DailyView{}}
+	// дни без просмотров заполняются нулями, чтобы ряд был непрерывным
+	next := 0
+	for d := from; !d.After(to); d = d.Add(day) {
+		point := domain.DailyView{Day: d}
+		if next < len(series) && series[next].Day.Equal(d) {
+			point = series[next]
+			next++
+		}
+		stats.Views += point.Views
+		stats.Visitors += point.Visitors
+		stats.Series = append(stats.Series, point)
+	}
+
+	if stats.Referrers, err = s.views.GetTop(ctx, target, targetId, domain.ViewReferrer, from, to, topViewsLimit); err != nil {
+		return nil, err
+	}
+	if stats.Countries, err = s.views.GetTop(ctx, target, targetId, domain.ViewCountry, from, to, topViewsLimit); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// visitorId - обезличенный идентификатор посетителя, действительный только в течение дня date
+func (s *AnalyticsService) visitorId(date time.Time, view domain.View) string {
+	sum := sha256.Sum256([]byte(s.conf.Salt + "|" + date.Format("2006-01-02") + "|" + view.IP + "|" + view.UserAgent))
+	return hex.EncodeToString(sum[:16])
+}
+
+// referrerHost оставляет от адреса, с которого пришел посетитель, только хост. Переходы внутри сайта
+// и адреса не из веба источниками не считаются.
+func (s *AnalyticsService) referrerHost(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, own := range s.conf.SiteHosts {
+		if host == strings.TrimPrefix(strings.ToLower(own), "www.") {
+			return ""
+		}
+	}
+	return host
+}
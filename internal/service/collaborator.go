@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CollaboratorService управляет соавторами проектов. Владелец приглашает пользователя по email,
+// соавтором тот становится, приняв приглашение. Права соавтора проверяет ProjectService.
+type CollaboratorService struct {
+	projects    *ProjectService
+	repo        repository.Projects
+	users       repository.Users
+	invitations repository.Invitations
+}
+
+func NewCollaboratorService(projects *ProjectService, repo repository.Projects, users repository.Users,
+	invitations repository.Invitations) *CollaboratorService {
+	return &CollaboratorService{
+		projects:    projects,
+		repo:        repo,
+		users:       users,
+		invitations: invitations,
+	}
+}
+
+// GetShared возвращает чужие проекты, в которых пользователь соавтор
+func (s *CollaboratorService) GetShared(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
+	projects, page, err := s.repo.GetShared(ctx, userId, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projects, page, setSelfCovers(ctx, s.projects.files, projects)
+}
+
+// GetCollaborators возвращает владельца и соавторов проекта любому из них
+func (s *CollaboratorService) GetCollaborators(ctx context.Context, projectId, userId primitive.ObjectID) ([]domain.ProjectAuthor, error) {
+	project, err := s.projects.projectFor(ctx, projectId, userId, accessView)
+	if err != nil {
+		return nil, err
+	}
+	return s.projects.authors(ctx, project.UserId, project.Collaborators)
+}
+
+// Invite приглашает в проект пользователя с указанным email
+func (s *CollaboratorService) Invite(ctx context.Context, projectId, userId primitive.ObjectID, input domain.InvitationInput) (*domain.Invitation, error) {
+	if !input.Role.Valid() {
+		return nil, domain.ErrInvalidRole
+	}
+	project, err := s.projects.projectFor(ctx, projectId, userId, accessOwner)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByEmail(ctx, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user.Id == project.UserId {
+		return nil, domain.ErrSelfInvitation
+	}
+	for _, c := range project.Collaborators {
+		if c.UserId == user.Id {
+			return nil, domain.ErrCollaboratorExists
+		}
+	}
+
+	invitation := domain.Invitation{
+		ProjectId:   projectId,
+		ProjectName: project.Name,
+		InviterId:   userId,
+		UserId:      user.Id,
+		Role:        input.Role,
+		Status:      domain.InvitationPending,
+		CreatedAt:   time.Now(),
+	}
+	if invitation.Id, err = s.invitations.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetProjectInvitations возвращает владельцу приглашения в проект, на которые еще не ответили
+func (s *CollaboratorService) GetProjectInvitations(ctx context.Context, projectId, userId primitive.ObjectID) ([]domain.Invitation, error) {
+	if _, err := s.projects.projectFor(ctx, projectId, userId, accessOwner); err != nil {
+		return nil, err
+	}
+	return s.invitations.GetPendingByProject(ctx, projectId)
+}
+
+func (s *CollaboratorService) RevokeInvitation(ctx context.Context, projectId, userId, invitationId primitive.ObjectID) error {
+	if _, err := s.projects.projectFor(ctx, projectId, userId, accessOwner); err != nil {
+		return err
+	}
+	return s.invitations.Remove(ctx, projectId, invitationId)
+}
+
+// GetInvitations возвращает приглашения пользователя, на которые он еще не ответил
+func (s *CollaboratorService) GetInvitations(ctx context.Context, userId primitive.ObjectID) ([]domain.Invitation, error) {
+	return s.invitations.GetPendingByUser(ctx, userId)
+}
+
+// AcceptInvitation делает пользователя соавтором с ролью из приглашения
+func (s *CollaboratorService) AcceptInvitation(ctx context.Context, userId, invitationId primitive.ObjectID) error {
+	invitation, err := s.answer(ctx, userId, invitationId, domain.InvitationAccepted)
+	if err != nil {
+		return err
+	}
+	err = s.repo.AddCollaborator(ctx, invitation.ProjectId, domain.Collaborator{
+		UserId:  userId,
+		Role:    invitation.Role,
+		AddedAt: time.Now(),
+	})
+	// пользователя могли пригласить повторно после того, как он уже стал соавтором
+	if err != nil && !errors.Is(err, domain.ErrCollaboratorExists) {
+		return err
+	}
+	return nil
+}
+
+func (s *CollaboratorService) DeclineInvitation(ctx context.Context, userId, invitationId primitive.ObjectID) error {
+	_, err := s.answer(ctx, userId, invitationId, domain.InvitationDeclined)
+	return err
+}
+
+// answer отвечает на приглашение. Чужое приглашение для пользователя не существует.
+func (s *CollaboratorService) answer(ctx context.Context, userId, invitationId primitive.ObjectID, status domain.InvitationStatus) (*domain.Invitation, error) {
+	invitation, err := s.invitations.GetById(ctx, invitationId)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.UserId != userId {
+		return nil, domain.ErrInvitationNotFound
+	}
+	if err := s.invitations.Answer(ctx, invitationId, status, time.Now()); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (s *CollaboratorService) SetRole(ctx context.Context, projectId, userId, collaboratorId primitive.ObjectID, role domain.CollaboratorRole) error {
+	if !role.Valid() {
+		return domain.ErrInvalidRole
+	}
+	if _, err := s.projects.projectFor(ctx, projectId, userId, accessOwner); err != nil {
+		return err
+	}
+	return s.repo.SetCollaboratorRole(ctx, projectId, collaboratorId, role)
+}
+
+// RemoveCollaborator убирает соавтора из проекта. Владелец убирает любого соавтора, соавтор - только себя.
+func (s *CollaboratorService) RemoveCollaborator(ctx context.Context, projectId, userId, collaboratorId primitive.ObjectID) error {
+	level := accessOwner
+	if userId == collaboratorId {
+		level = accessView
+	}
+	if _, err := s.projects.projectFor(ctx, projectId, userId, level); err != nil {
+		return err
+	}
+	return s.repo.RemoveCollaborator(ctx, projectId, collaboratorId)
+}
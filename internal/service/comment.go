@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/markup"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxCommentLength = 2000
+	// длина начала комментария, которое показывается в уведомлении
+	commentExcerptLength = 140
+)
+
+// CommentOptions ограничивает частоту комментариев: не больше RateLimit от пользователя за RateWindow.
+// RateLimit 0 снимает ограничение.
+type CommentOptions struct {
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+// CommentService ведет обсуждения опубликованных проектов. Комментировать может любой вошедший пользователь,
+// модерирует комментарии владелец проекта: одобряет, скрывает и удаляет их. Свой комментарий автор
+// может удалить сам.
+type CommentService struct {
+	comments      repository.Comments
+	projects      repository.Projects
+	users         repository.Users
+	limits        repository.RateLimits
+	notifications *NotificationService
+	conf          CommentOptions
+}
+
+func NewCommentService(comments repository.Comments, projects repository.Projects, users repository.Users,
+	limits repository.RateLimits, notifications *NotificationService, conf CommentOptions) *CommentService {
+	return &CommentService{
+		comments:      comments,
+		projects:      projects,
+		users:         users,
+		limits:        limits,
+		notifications: notifications,
+		conf:          conf,
+	}
+}
+
+// GetThreads возвращает видимые комментарии верхнего уровня, новые - первыми
+func (s *CommentService) GetThreads(ctx context.Context, projectId primitive.ObjectID, params pagination.Params) ([]domain.Comment, *pagination.Page, error) {
+	if _, err := s.visibleProject(ctx, projectId); err != nil {
+		return nil, nil, err
+	}
+	comments, page, err := s.comments.GetThreads(ctx, projectId, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return comments, page, s.setAuthors(ctx, comments)
+}
+
+// GetReplies возвращает видимые ответы ветки threadId в порядке написания
+func (s *CommentService) GetReplies(ctx context.Context, projectId, threadId primitive.ObjectID,
+	params pagination.Params) ([]domain.Comment, *pagination.Page, error) {
+	if _, err := s.visibleProject(ctx, projectId); err != nil {
+		return nil, nil, err
+	}
+	thread, err := s.comments.GetById(ctx, projectId, threadId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if thread.ThreadId != nil || thread.Status != domain.CommentApproved {
+		return nil, nil, domain.ErrCommentNotFound
+	}
+	comments, page, err := s.comments.GetReplies(ctx, threadId, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return comments, page, s.setAuthors(ctx, comments)
+}
+
+// Create добавляет комментарий или ответ. В проекте с премодерацией чужой комментарий ждет одобрения владельца.
+func (s *CommentService) Create(ctx context.Context, projectId, userId primitive.ObjectID, input domain.CommentInput) (*domain.Comment, error) {
+	body := markup.Normalize(input.Body)
+	if body == "" {
+		return nil, domain.ErrCommentEmpty
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return nil, domain.ErrCommentTooLong
+	}
+	project, err := s.visibleProject(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	if project.CommentsMode == domain.CommentsClosed {
+		return nil, domain.ErrCommentsClosed
+	}
+
+	comment := domain.Comment{
+		ProjectId: projectId,
+		UserId:    userId,
+		Body:      body,
+		Html:      markup.Render(body),
+		Status:    domain.CommentApproved,
+		CreatedAt: time.Now(),
+	}
+	owner := project.UserId == userId
+	if project.CommentsMode == domain.CommentsPremoderated && !owner {
+		comment.Status = domain.CommentPending
+	}
+	if input.ParentId != "" {
+		parentId, err := primitive.ObjectIDFromHex(input.ParentId)
+		if err != nil {
+			return nil, domain.ErrCommentNotFound
+		}
+		parent, err := s.comments.GetById(ctx, projectId, parentId)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Status != domain.CommentApproved {
+			return nil, domain.ErrCommentNotFound
+		}
+		threadId := parent.Id
+		if parent.ThreadId != nil {
+			threadId = *parent.ThreadId
+		}
+		comment.ThreadId, comment.ParentId = &threadId, &parent.Id
+	}
+	if err := s.allow(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	if comment.Id, err = s.comments.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	if comment.ThreadId != nil && comment.Status == domain.CommentApproved {
+		if err := s.comments.IncReplies(ctx, *comment.ThreadId, 1); err != nil {
+			return nil, err
+		}
+	}
+	if !owner {
+		kind := domain.NotifyComment
+		if comment.Status == domain.CommentPending {
+			kind = domain.NotifyCommentPending
+		}
+		s.notifications.notify(ctx, domain.Notification{
+			UserId:      project.UserId,
+			Kind:        kind,
+			ActorId:     userId,
+			ProjectId:   projectId,
+			ProjectName: project.Name,
+			CommentId:   comment.Id,
+			Excerpt:     excerpt(body, commentExcerptLength),
+		})
+	}
+
+	comments := []domain.Comment{comment}
+	if err := s.setAuthors(ctx, comments); err != nil {
+		return nil, err
+	}
+	return &comments[0], nil
+}
+
+// Remove удаляет комментарий вместе с ответами, если это комментарий верхнего уровня.
+// Удалить комментарий может его автор и владелец проекта.
+func (s *CommentService) Remove(ctx context.Context, projectId, userId, commentId primitive.ObjectID) error {
+	comment, err := s.comments.GetById(ctx, projectId, commentId)
+	if err != nil {
+		return err
+	}
+	if comment.UserId != userId {
+		if _, err := s.projects.GetSelfProjectById(ctx, projectId, userId); err != nil {
+			if errors.Is(err, domain.ErrProjectNotFound) {
+				return domain.ErrCommentForbidden
+			}
+			return err
+		}
+	}
+	return removeComment(ctx, s.comments, projectId, commentId)
+}
+
+// GetForModeration возвращает владельцу комментарии проекта со статусом status (пустой - с любым), новые - первыми
+func (s *CommentService) GetForModeration(ctx context.Context, projectId, userId primitive.ObjectID, status domain.CommentStatus,
+	params pagination.Params) ([]domain.Comment, *pagination.Page, error) {
+	if status != "" && status != domain.CommentApproved && status != domain.CommentPending && status != domain.CommentHidden {
+		return nil, nil, domain.ErrInvalidCommentStatus
+	}
+	if _, err := s.projects.GetSelfProjectById(ctx, projectId, userId); err != nil {
+		return nil, nil, err
+	}
+	comments, page, err := s.comments.GetByProject(ctx, projectId, status, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return comments, page, s.setAuthors(ctx, comments)
+}
+
+// Approve показывает ожидающий одобрения или скрытый комментарий
+func (s *CommentService) Approve(ctx context.Context, projectId, userId, commentId primitive.ObjectID) error {
+	return s.setStatus(ctx, projectId, userId, commentId, domain.CommentApproved)
+}
+
+// Hide скрывает комментарий. Вместе с комментарием верхнего уровня скрывается вся его ветка.
+func (s *CommentService) Hide(ctx context.Context, projectId, userId, commentId primitive.ObjectID) error {
+	return s.setStatus(ctx, projectId, userId, commentId, domain.CommentHidden)
+}
+
+func (s *CommentService) SetMode(ctx context.Context, projectId, userId primitive.ObjectID, mode domain.CommentsMode) error {
+	if !mode.Valid() {
+		return domain.ErrInvalidCommentsMode
+	}
+	if _, err := s.projects.GetSelfProjectById(ctx, projectId, userId); err != nil {
+		return err
+	}
+	return s.projects.SetCommentsMode(ctx, projectId, mode)
+}
+
+func (s *CommentService) setStatus(ctx context.Context, projectId, userId, commentId primitive.ObjectID, status domain.CommentStatus) error {
+	if _, err := s.projects.GetSelfProjectById(ctx, projectId, userId); err != nil {
+		return err
+	}
+	prev, err := s.comments.SetStatus(ctx, projectId, commentId, status)
+	if err != nil {
+		return err
+	}
+	// счетчик ответов меняется, только если ответ стал видимым или перестал быть видимым
+	if prev.ThreadId == nil || (prev.Status == domain.CommentApproved) == (status == domain.CommentApproved) {
+		return nil
+	}
+	delta := 1
+	if status != domain.CommentApproved {
+		delta = -1
+	}
+	return s.comments.IncReplies(ctx, *prev.ThreadId, delta)
+}
+
+// visibleProject возвращает проект, комментарии которого видны всем: опубликованный и открытый хотя бы по ссылке
+func (s *CommentService) visibleProject(ctx context.Context, projectId primitive.ObjectID) (*domain.Project, error) {
+	project, err := s.projects.GetProjectById(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+	if !project.Published || project.Access == domain.Nobody {
+		return nil, domain.ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// allow учитывает комментарий пользователя и проверяет, что он не превысил ограничение частоты
+func (s *CommentService) allow(ctx context.Context, userId primitive.ObjectID) error {
+	if s.conf.RateLimit <= 0 {
+		return nil
+	}
+	count, err := s.limits.Hit(ctx, "comments:"+userId.Hex(), s.conf.RateWindow)
+	if err != nil {
+		return err
+	}
+	if count > int64(s.conf.RateLimit) {
+		return domain.ErrCommentRateLimited
+	}
+	return nil
+}
+
+func (s *CommentService) setAuthors(ctx context.Context, comments []domain.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(comments))
+	seen := map[primitive.ObjectID]bool{}
+	for _, c := range comments {
+		if !seen[c.UserId] {
+			seen[c.UserId] = true
+			ids = append(ids, c.UserId)
+		}
+	}
+	authors, err := s.users.GetAuthors(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byId := make(map[primitive.ObjectID]domain.ProjectAuthor, len(authors))
+	for _, a := range authors {
+		byId[a.Id] = a
+	}
+	for i := range comments {
+		if author, ok := byId[comments[i].UserId]; ok {
+			comments[i].Author = &author
+		}
+	}
+	return nil
+}
+
+// removeComment удаляет комментарий: комментарий верхнего уровня - вместе с веткой,
+// видимый ответ - вместе с его учетом в счетчике ветки
+func removeComment(ctx context.Context, comments repository.Comments, projectId, commentId primitive.ObjectID) error {
+	removed, err := comments.Remove(ctx, projectId, commentId)
+	if err != nil {
+		return err
+	}
+	if removed.ThreadId == nil {
+		return comments.RemoveThread(ctx, removed.Id)
+	}
+	if removed.Status == domain.CommentApproved {
+		return comments.IncReplies(ctx, *removed.ThreadId, -1)
+	}
+	return nil
+}
+
+// excerpt возвращает начало текста в одну строку не длиннее limit символов
+func excerpt(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/email"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/markup"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"github.com/Alexander272/my-portfolio/pkg/useragent"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// сообщение, в котором ссылок больше, похоже на рассылку
+	maxContactLinks = 2
+	forwardTimeout  = 30 * time.Second
+)
+
+// ContactOptions ограничивает частоту сообщений: не больше RateLimit с одного адреса за RateWindow
+// и не больше DailyLimit одному пользователю за сутки, 0 снимает ограничение
+type ContactOptions struct {
+	RateLimit  int
+	RateWindow time.Duration
+	DailyLimit int
+	// Salt - секрет, с которым хешируются IP посетителей: в ключах ограничения частоты адресов нет
+	Salt string
+}
+
+// ContactService принимает сообщения посетителей из формы обратной связи и ведет входящие владельца портфолио.
+// Если задан mailer, сообщения из входящих пересылаются владельцу на email, ответ на письмо уходит посетителю.
+type ContactService struct {
+	messages repository.Messages
+	users    repository.Users
+	limits   repository.RateLimits
+	mailer   email.Sender
+	conf     ContactOptions
+}
+
+func NewContactService(messages repository.Messages, users repository.Users, limits repository.RateLimits, mailer email.Sender,
+	conf ContactOptions) *ContactService {
+	return &ContactService{
+		messages: messages,
+		users:    users,
+		limits:   limits,
+		mailer:   mailer,
+		conf:     conf,
+	}
+}
+
+// Send сохраняет сообщение пользователю userId. Сообщения ботов молча отбрасываются или попадают в спам,
+// чтобы по ответу нельзя было понять, что сообщение не дошло.
+func (s *ContactService) Send(ctx context.Context, userId primitive.ObjectID, input domain.ContactInput, ip, userAgent string) error {
+	if input.Website != "" {
+		logger.Infof("contact message to user %s dropped: honeypot field is filled", userId.Hex())
+		return nil
+	}
+	message := domain.ContactMessage{
+		UserId:    userId,
+		Name:      oneLine(input.Name),
+		Email:     strings.TrimSpace(input.Email),
+		Subject:   oneLine(input.Subject),
+		Body:      markup.Normalize(input.Message),
+		Folder:    domain.FolderInbox,
+		CreatedAt: time.Now(),
+	}
+	if message.Name == "" || message.Body == "" {
+		return domain.ErrMessageEmpty
+	}
+
+	user, err := s.users.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if err := s.allow(ctx, "contact:ip:"+s.hashIP(ip), s.conf.RateLimit, s.conf.RateWindow); err != nil {
+		return err
+	}
+	if err := s.allow(ctx, "contact:user:"+userId.Hex(), s.conf.DailyLimit, day); err != nil {
+		return err
+	}
+	if looksLikeSpam(message, userAgent) {
+		message.Folder = domain.FolderSpam
+	}
+
+	if message.Id, err = s.messages.Create(ctx, message); err != nil {
+		return err
+	}
+	if message.Folder == domain.FolderInbox && s.mailer != nil {
+		go s.forward(user.Email, message)
+	}
+	return nil
+}
+
+// GetMessages возвращает сообщения из папки folder, новые - первыми
+func (s *ContactService) GetMessages(ctx context.Context, userId primitive.ObjectID, folder domain.MessageFolder,
+	params pagination.Params) ([]domain.ContactMessage, *pagination.Page, error) {
+	if !folder.Valid() {
+		return nil, nil, domain.ErrInvalidFolder
+	}
+	return s.messages.GetByFolder(ctx, userId, folder, params)
+}
+
+func (s *ContactService) GetUnread(ctx context.Context, userId primitive.ObjectID) (*domain.UnreadMessages, error) {
+	count, err := s.messages.CountUnread(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UnreadMessages{Count: count}, nil
+}
+
+func (s *ContactService) MarkRead(ctx context.Context, userId, id primitive.ObjectID, read bool) error {
+	return s.messages.SetRead(ctx, userId, id, read)
+}
+
+// Move перекладывает сообщение в папку folder: в архив, обратно во входящие или в спам
+func (s *ContactService) Move(ctx context.Context, userId, id primitive.ObjectID, folder domain.MessageFolder) error {
+	if !folder.Valid() {
+		return domain.ErrInvalidFolder
+	}
+	return s.messages.Move(ctx, userId, id, folder)
+}
+
+func (s *ContactService) Remove(ctx context.Context, userId, id primitive.ObjectID) error {
+	return s.messages.Remove(ctx, userId, id)
+}
+
+// forward пересылает сообщение владельцу. Сообщение уже сохранено во входящих, поэтому ошибка только пишется в лог.
+func (s *ContactService) forward(to string, message domain.ContactMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), forwardTimeout)
+	defer cancel()
+
+	subject := "Новое сообщение из портфолио"
+	if message.Subject != "" {
+		subject += ": " + message.Subject
+	}
+	from := (&mail.Address{Name: message.Name, Address: message.Email}).String()
+	var body strings.Builder
+	body.WriteString("От: " + from + "\n")
+	if message.Subject != "" {
+		body.WriteString("Тема: " + message.Subject + "\n")
+	}
+	body.WriteString("\n" + message.Body + "\n\n")
+	body.WriteString("Ответьте на это письмо, чтобы написать отправителю. Сообщение сохранено во входящих портфолио.\n")
+
+	err := s.mailer.Send(ctx, email.Message{To: to, ReplyTo: from, Subject: subject, Body: body.String()})
+	if err != nil {
+		logger.Errorf("failed to forward message %s to user %s: %s", message.Id.Hex(), message.UserId.Hex(), err.Error())
+	}
+}
+
+// allow учитывает сообщение по ключу key и проверяет, что их не больше limit за window
+func (s *ContactService) allow(ctx context.Context, key string, limit int, window time.Duration) error {
+	if limit <= 0 {
+		return nil
+	}
+	count, err := s.limits.Hit(ctx, key, window)
+	if err != nil {
+		return err
+	}
+	if count > int64(limit) {
+		return domain.ErrContactRateLimited
+	}
+	return nil
+}
+
+func (s *ContactService) hashIP(ip string) string {
+	sum := sha256.Sum256([]byte(s.conf.Salt + "|" + ip))
+	return hex.EncodeToString(sum[:16])
+}
+
+// looksLikeSpam отбирает сообщения ботов и рассылки со ссылками
+func looksLikeSpam(message domain.ContactMessage, userAgent string) bool {
+	if useragent.IsBot(userAgent) {
+		return true
+	}
+	text := strings.ToLower(message.Name + " " + message.Subject + " " + message.Body)
+	links := strings.Count(text, "://") + strings.Count(text, "www.") - strings.Count(text, "//www.")
+	return links > maxContactLinks || strings.Contains(strings.ToLower(message.Name), "http")
+}
+
+// oneLine убирает из строки переводы строк и лишние пробелы
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(markup.Normalize(s)), " ")
+}
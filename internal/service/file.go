@@ -1,40 +1,746 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
 	"mime/multipart"
+	"path"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/images"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/scanner"
 	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	webpType = "image/webp"
+	// сколько раз загрузка пробует сохранить файл, если такой же файл одновременно сохраняется и удаляется
+	maxStoreAttempts = 3
+	// storedFilesRoot - каталог общих объектов в хранилище
+	storedFilesRoot = "files"
+	// quarantineRoot - каталог зараженных файлов
+	quarantineRoot = "quarantine"
+)
+
+// расширения и типы файлов, которые хранятся как есть
+var (
+	mediaExts      = map[string]string{"video/mp4": ".mp4", "video/webm": ".webm", "application/zip": ".zip"}
+	mediaFileTypes = map[string]string{"video/mp4": domain.FileVideo, "video/webm": domain.FileVideo, "application/zip": domain.FileArchive}
+)
+
+// UploadLimits ограничивает загрузки. Нулевые значения отключают соответствующее ограничение.
+type UploadLimits struct {
+	// MaxFileSize - максимальный размер загружаемого файла в байтах
+	MaxFileSize int64
+	// MaxPixels - максимальная площадь изображения, защищает от картинок, распаковывающихся в гигабайты
+	MaxPixels int
+	// UserQuota - сколько байт в хранилище может занимать один пользователь, включая варианты изображений
+	UserQuota int64
+	// Concurrency - сколько растровых изображений обрабатывается одновременно. Распакованное изображение
+	// занимает до MaxPixels*4 байт, поэтому вместе с MaxPixels это ограничивает память на загрузки.
+	// Нулевое значение - по числу процессоров.
+	Concurrency int
+}
+
+// ImageOptions настраивает обработку загружаемых изображений
+type ImageOptions struct {
+	// Variants - пресеты уменьшенных копий
+	Variants []images.Preset
+	// KeepCopyright сохраняет в оригинале поля EXIF об авторе и правах, остальные метаданные удаляются всегда
+	KeepCopyright bool
+}
+
 type FileService struct {
-	storage storage.Provider
+	storage    storage.Provider
+	uploads    repository.Uploads
+	files      repository.Files
+	quarantine repository.Quarantine
+	// scanner проверяет новые файлы антивирусом, nil - проверка отключена
+	scanner      scanner.Scanner
+	signedUrlTTL time.Duration
+	images       ImageOptions
+	limits       UploadLimits
+	// decoding ограничивает число изображений, распакованных в память одновременно
+	decoding chan struct{}
 }
 
-func NewFileService(storage storage.Provider) *FileService {
+func NewFileService(storage storage.Provider, uploads repository.Uploads, files repository.Files, quarantine repository.Quarantine,
+	scanner scanner.Scanner, signedUrlTTL time.Duration, images ImageOptions, limits UploadLimits) *FileService {
+	concurrency := limits.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
 	return &FileService{
-		storage: storage,
+		storage:      storage,
+		uploads:      uploads,
+		files:        files,
+		quarantine:   quarantine,
+		scanner:      scanner,
+		signedUrlTTL: signedUrlTTL,
+		images:       images,
+		limits:       limits,
+		decoding:     make(chan struct{}, concurrency),
 	}
 }
 
-func (s *FileService) Upload(ctx context.Context, file multipart.File, header *multipart.FileHeader, path, filename string) (*domain.File, error) {
-	if !strings.Contains(header.Header.Get("Content-Type"), "image") {
-		return nil, nil
+// какие файлы, кроме изображений, принимает загрузка
+type fileKinds int
+
+const (
+	acceptImages fileKinds = iota
+	// acceptAttachments - изображения и PDF
+	acceptAttachments
+	// acceptMedia - также видео и архивы, они загружаются только частями (см. UploadService)
+	acceptMedia
+)
+
+// accepted проверяет, можно ли загрузить файл такого типа
+func accepted(mediaType string, accept fileKinds) bool {
+	switch mediaType {
+	case "application/pdf":
+		return accept >= acceptAttachments
+	case "video/mp4", "video/webm", "application/zip":
+		return accept >= acceptMedia
+	}
+	return true
+}
+
+// Upload сохраняет изображение. Растровые изображения поворачиваются по тегу EXIF Orientation, хранятся в WebP
+// без метаданных, рядом с ними сохраняются уменьшенные варианты по пресетам с именами <объект>_<пресет>.webp.
+// SVG очищается от скриптов и внешних ссылок, из анимированных GIF и AVIF удаляются только метаданные.
+// Перед сохранением файл проверяется антивирусом, зараженный файл отправляется в карантин (см. scan).
+// Тип файла определяется по содержимому, место учитывается за userId. Файл получает имя filename в каталоге dir,
+// а объекты в хранилище - общие для одинаковых файлов пользователя (см. upload).
+func (s *FileService) Upload(ctx context.Context, userId primitive.ObjectID, file multipart.File, header *multipart.FileHeader,
+	dir, filename string, private bool) (*domain.File, error) {
+	return s.uploadMultipart(ctx, userId, file, header, dir, filename, private, acceptImages)
+}
+
+// UploadAttachment сохраняет изображение или документ (PDF)
+func (s *FileService) UploadAttachment(ctx context.Context, userId primitive.ObjectID, file multipart.File, header *multipart.FileHeader,
+	dir, filename string, private bool) (*domain.File, error) {
+	return s.uploadMultipart(ctx, userId, file, header, dir, filename, private, acceptAttachments)
+}
+
+// UploadMedia сохраняет собранный из частей файл: изображение, PDF, видео (MP4, WebM) или архив (ZIP).
+// Ограничение MaxFileSize к нему не применяется, размер проверяется при создании загрузки.
+func (s *FileService) UploadMedia(ctx context.Context, userId primitive.ObjectID, file io.ReaderAt, size int64, origName,
+	dir, filename string, private bool) (*domain.File, error) {
+	return s.upload(ctx, userId, file, size, origName, dir, filename, private, acceptMedia)
+}
+
+func (s *FileService) uploadMultipart(ctx context.Context, userId primitive.ObjectID, file multipart.File, header *multipart.FileHeader,
+	dir, filename string, private bool, accept fileKinds) (*domain.File, error) {
+	// размеру из заголовка multipart верить нельзя, настоящий размер дает сам файл
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if s.limits.MaxFileSize > 0 && size > s.limits.MaxFileSize {
+		return nil, domain.ErrFileTooLarge
+	}
+	return s.upload(ctx, userId, file, size, header.Filename, dir, filename, private, accept)
+}
+
+// preparedFile - файл в том виде, в котором он попадет в хранилище. Содержимое не хранится в памяти целиком:
+// body читается из загруженного файла при отправке в хранилище.
+type preparedFile struct {
+	body          *images.Rewrite
+	contentType   string
+	ext           string
+	fileType      string
+	width, height int
+	// img - декодированное изображение для вариантов, nil если варианты не создаются
+	img image.Image
+	// placeholder - заглушка изображения, nil для SVG и AVIF
+	placeholder *images.Placeholder
+}
+
+// upload сохраняет файл как ссылку dir/<имя> на общий объект пользователя. Если пользователь уже загружал
+// такой же файл, новая ссылка указывает на сохраненный объект, и место второй раз не занимается.
+func (s *FileService) upload(ctx context.Context, userId primitive.ObjectID, file io.ReaderAt, size int64, origName,
+	dir, filename string, private bool, accept fileKinds) (*domain.File, error) {
+	mediaType, err := detectType(file, size)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted(mediaType, accept) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnsupportedFileType, mediaType)
+	}
+	hash, err := fileHash(file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	id := userId.Hex() + "/" + hash
+	ref := domain.FileRef{Dir: dir, Private: private, CreatedAt: time.Now()}
+	base := fileBase(origName, filename)
+	// одновременная загрузка такого же файла может сохранить его первой, тогда ссылка добавляется к ее объекту
+	for i := 0; i < maxStoreAttempts; i++ {
+		uploaded, err := s.addRef(ctx, id, ref, base, origName)
+		if errors.Is(err, domain.ErrFileNotFound) {
+			stored := domain.StoredFile{Id: id, UserId: userId, Hash: hash, CreatedAt: time.Now()}
+			uploaded, err = s.storeFile(ctx, stored, file, size, mediaType, ref, base, origName)
+		}
+		if errors.Is(err, domain.ErrStoredFileExists) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.releaseReplaced(ctx, id, dir, uploaded.Name)
+		return uploaded, nil
+	}
+	return nil, domain.ErrStoredFileExists
+}
+
+// releaseReplaced убирает ссылку с тем же именем с прежнего объекта: загрузка под существующим именем
+// (например, аватара) заменяет файл. Ошибки только логируются, новый файл к этому моменту уже сохранен.
+func (s *FileService) releaseReplaced(ctx context.Context, id, dir, name string) {
+	for {
+		replaced, err := s.files.RemoveOtherRef(ctx, id, dir, name)
+		if err != nil {
+			if !errors.Is(err, domain.ErrFileNotFound) {
+				logger.Errorf("failed to release replaced file %s/%s: %s", dir, name, err.Error())
+			}
+			return
+		}
+		if err := s.removeUnreferenced(ctx, replaced); err != nil {
+			logger.Errorf("failed to remove replaced file %s/%s: %s", dir, name, err.Error())
+		}
+	}
+}
+
+// addRef добавляет ссылку на уже сохраненный объект. domain.ErrFileNotFound - такого объекта нет.
+func (s *FileService) addRef(ctx context.Context, id string, ref domain.FileRef, base, origName string) (*domain.File, error) {
+	existing, err := s.files.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ref.Name = base + path.Ext(existing.File.Key)
+	stored, err := s.files.AddRef(ctx, id, ref)
+	if err != nil {
+		return nil, err
+	}
+	// публичная ссылка открывает объект, который до нее был приватным
+	if !ref.Private && filePrivate(existing.Refs) {
+		if err := s.setObjectPrivate(ctx, stored.File, false); err != nil {
+			if err := s.Remove(context.Background(), ref.Dir, ref.Name); err != nil {
+				logger.Errorf("failed to remove reference %s/%s: %s", ref.Dir, ref.Name, err.Error())
+			}
+			return nil, err
+		}
+	}
+
+	uploaded := stored.File
+	uploaded.Name = ref.Name
+	uploaded.OrigName = origName
+	return &uploaded, nil
+}
+
+// storeFile сохраняет новый объект и ссылку на него. В памяти целиком оказываются только распакованное
+// растровое изображение, его оригинал в WebP и один вариант за раз, остальное передается в хранилище потоком.
+func (s *FileService) storeFile(ctx context.Context, stored domain.StoredFile, file io.ReaderAt, size int64, mediaType string,
+	ref domain.FileRef, base, origName string) (*domain.File, error) {
+	// проверяется только новое содержимое: файл, на который добавляется ссылка, проверен при первой загрузке
+	if err := s.scan(ctx, stored, file, size, mediaType, origName); err != nil {
+		return nil, err
 	}
-	res, err := s.storage.Upload(ctx, file, header, path, filename)
+	if images.Decodable(mediaType) {
+		select {
+		case s.decoding <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-s.decoding }()
+	}
+
+	prepared, err := s.prepareFile(file, size, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := s.quota(ctx, stored.UserId)
 	if err != nil {
 		return nil, err
 	}
-	return &domain.File{
-		FileType: "Image",
-		Name:     res.Name,
-		OrigName: header.Filename,
-		Url:      res.Url,
+	if err := quota.reserve(prepared.body.Size); err != nil {
+		return nil, err
+	}
+
+	// имя объекта уникально, поэтому объекты одновременных загрузок одного файла не перезаписывают друг друга
+	dir, name := storedFilesDir(stored.UserId), primitive.NewObjectID().Hex()
+	var keys []string
+	// при ошибке уже сохраненные части удаляются, чтобы не занимать место без ссылок на них
+	fail := func(err error) (*domain.File, error) {
+		s.removeObjects(context.Background(), keys)
+		return nil, err
+	}
+
+	res, err := s.store(ctx, stored.UserId, prepared.body.Reader(), prepared.body.Size, prepared.contentType, dir, name+prepared.ext, ref.Private)
+	if err != nil {
+		return fail(err)
+	}
+	keys = append(keys, res.Key)
+	ref.Name = base + prepared.ext
+	stored.File = domain.File{
+		FileType:    prepared.fileType,
+		ContentType: prepared.contentType,
+		Name:        ref.Name,
+		OrigName:    origName,
+		Url:         res.Url,
+		Width:       prepared.width,
+		Height:      prepared.height,
+		Size:        prepared.body.Size,
+		Key:         res.Key,
+	}
+	if prepared.placeholder != nil {
+		stored.File.Blurhash = prepared.placeholder.Blurhash
+		stored.File.DominantColor = prepared.placeholder.Color
+	}
+
+	if prepared.img != nil {
+		for _, preset := range s.images.Variants {
+			v, err := images.EncodeVariant(prepared.img, preset)
+			if err != nil {
+				return fail(err)
+			}
+			if err := quota.reserve(int64(len(v.Data))); err != nil {
+				return fail(err)
+			}
+			res, err := s.store(ctx, stored.UserId, bytes.NewReader(v.Data), int64(len(v.Data)), webpType, dir, name+"_"+v.Name+".webp", ref.Private)
+			if err != nil {
+				return fail(err)
+			}
+			keys = append(keys, res.Key)
+			stored.File.Variants = append(stored.File.Variants, domain.FileVariant{
+				Name:   v.Name,
+				Url:    res.Url,
+				Width:  v.Width,
+				Height: v.Height,
+				Key:    res.Key,
+			})
+		}
+	}
+
+	stored.Refs = []domain.FileRef{ref}
+	if err := s.files.Create(ctx, stored); err != nil {
+		return fail(err)
+	}
+	uploaded := stored.File
+	return &uploaded, nil
+}
+
+// scan проверяет файл антивирусом до разбора и сохранения. Зараженный файл сохраняется приватным объектом
+// в карантин вне каталогов пользователей, где его не раздает хранилище и не удаляет сборка мусора,
+// а загрузка завершается ошибкой domain.ErrFileInfected. Файл, который не удалось проверить, не сохраняется.
+func (s *FileService) scan(ctx context.Context, stored domain.StoredFile, file io.ReaderAt, size int64, mediaType, origName string) error {
+	if s.scanner == nil {
+		return nil
+	}
+	res, err := s.scanner.Scan(ctx, io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrScanFailed, err)
+	}
+	if !res.Infected {
+		return nil
+	}
+
+	logger.Infof("file %s of user %s contains %s, moving it to quarantine", origName, stored.UserId.Hex(), res.Signature)
+	quarantined := domain.QuarantinedFile{
+		UserId:      stored.UserId,
+		Hash:        stored.Hash,
+		OrigName:    origName,
+		ContentType: mediaType,
+		Size:        size,
+		Signature:   res.Signature,
+		CreatedAt:   time.Now(),
+	}
+	// файл без объекта все равно попадает в карантин, чтобы администратор узнал о попытке загрузки
+	obj, err := s.storage.Upload(ctx, io.NewSectionReader(file, 0, size), size, mediaType, quarantineDir(stored.UserId), stored.Hash, true)
+	if err != nil {
+		logger.Errorf("failed to store quarantined file %s: %s", stored.Id, err.Error())
+	} else {
+		quarantined.Key = obj.Key
+	}
+	if err := s.quarantine.Create(ctx, quarantined); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", domain.ErrFileInfected, res.Signature)
+}
+
+// quarantineDir - каталог карантина пользователя
+func quarantineDir(userId primitive.ObjectID) string {
+	return path.Join(quarantineRoot, userId.Hex())
+}
+
+// fileHash возвращает SHA-256 содержимого файла
+func fileHash(file io.ReaderAt, size int64) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, size)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// storedFilesDir - каталог общих объектов пользователя. Он лежит вне каталога пользователя, чтобы удаление
+// по префиксу каталога не задевало объекты, на которые ссылаются из других каталогов.
+func storedFilesDir(userId primitive.ObjectID) string {
+	return path.Join(storedFilesRoot, userId.Hex())
+}
+
+// filePrivate сообщает, должен ли объект с такими ссылками быть приватным
+func filePrivate(refs []domain.FileRef) bool {
+	for _, ref := range refs {
+		if !ref.Private {
+			return false
+		}
+	}
+	return true
+}
+
+// detectType определяет тип файла по первым байтам
+func detectType(file io.ReaderAt, size int64) (string, error) {
+	head := make([]byte, images.SniffLen)
+	if size < int64(len(head)) {
+		head = head[:size]
+	}
+	if _, err := file.ReadAt(head, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	return images.DetectType(head), nil
+}
+
+// uploadQuota - сколько байт пользователь еще может загрузить, отрицательное значение - без ограничений
+type uploadQuota int64
+
+// quota считает остаток квоты пользователя. Проверка и запись не атомарны,
+// поэтому одновременные загрузки могут ненамного превысить квоту.
+func (s *FileService) quota(ctx context.Context, userId primitive.ObjectID) (*uploadQuota, error) {
+	left := uploadQuota(-1)
+	if s.limits.UserQuota <= 0 {
+		return &left, nil
+	}
+	used, err := s.uploads.GetUsage(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	left = uploadQuota(s.limits.UserQuota - used)
+	if left < 0 {
+		left = 0
+	}
+	return &left, nil
+}
+
+func (q *uploadQuota) reserve(size int64) error {
+	if *q < 0 {
+		return nil
+	}
+	if size > int64(*q) {
+		return domain.ErrQuotaExceeded
+	}
+	*q -= uploadQuota(size)
+	return nil
+}
+
+// store сохраняет объект в хранилище и записывает его в учет занятого места
+func (s *FileService) store(ctx context.Context, userId primitive.ObjectID, body io.Reader, size int64, contentType, dir, filename string,
+	private bool) (*storage.File, error) {
+	res, err := s.storage.Upload(ctx, body, size, contentType, dir, filename, private)
+	if err != nil {
+		return nil, err
+	}
+	err = s.uploads.Save(ctx, domain.Upload{
+		Key:       res.Key,
+		UserId:    userId,
+		Size:      size,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Usage возвращает место, занятое файлами пользователя, и его квоту (0 - без ограничений)
+func (s *FileService) Usage(ctx context.Context, userId primitive.ObjectID) (*domain.StorageUsage, error) {
+	used, err := s.uploads.GetUsage(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.StorageUsage{Used: used, Quota: s.limits.UserQuota}, nil
+}
+
+// prepareFile проверяет файл по его содержимому и приводит к виду для хранения
+func (s *FileService) prepareFile(file io.ReaderAt, size int64, mediaType string) (*preparedFile, error) {
+	maxPixels := s.limits.MaxPixels
+	switch mediaType {
+	case "image/svg+xml":
+		clean, width, height, err := images.SanitizeSVG(io.NewSectionReader(file, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidFile, err)
+		}
+		return &preparedFile{
+			body:        images.Unchanged(bytes.NewReader(clean), int64(len(clean))),
+			contentType: mediaType,
+			ext:         ".svg",
+			fileType:    domain.FileImage,
+			width:       width,
+			height:      height,
+		}, nil
+
+	case "image/avif":
+		info, err := images.ReadAVIF(file, size, maxPixels)
+		if err != nil {
+			return nil, imageError(err, mediaType)
+		}
+		return &preparedFile{body: info.Stripped, contentType: mediaType, ext: ".avif", fileType: domain.FileImage, width: info.Width, height: info.Height}, nil
+
+	case "image/gif":
+		info, err := images.ReadGIF(file, size, maxPixels)
+		if err != nil {
+			return nil, imageError(err, mediaType)
+		}
+		if info.Animated {
+			prepared := &preparedFile{body: info.Stripped, contentType: mediaType, ext: ".gif", fileType: domain.FileImage, width: info.Width, height: info.Height}
+			// заглушка строится по первому кадру, gif.Decode дальше него не читает
+			if first, err := images.Decode(io.NewSectionReader(file, 0, size), mediaType); err == nil {
+				placeholder := images.NewPlaceholder(first)
+				prepared.placeholder = &placeholder
+			}
+			return prepared, nil
+		}
+
+	case "application/pdf":
+		return &preparedFile{body: images.Unchanged(file, size), contentType: mediaType, ext: ".pdf", fileType: domain.FileDocument}, nil
+
+	case "video/mp4", "video/webm", "application/zip":
+		return &preparedFile{body: images.Unchanged(file, size), contentType: mediaType, ext: mediaExts[mediaType], fileType: mediaFileTypes[mediaType]}, nil
+	}
+
+	if _, _, err := images.CheckSize(io.NewSectionReader(file, 0, size), mediaType, maxPixels); err != nil {
+		return nil, imageError(err, mediaType)
+	}
+	img, err := images.Decode(io.NewSectionReader(file, 0, size), mediaType)
+	if err != nil {
+		return nil, imageError(err, mediaType)
+	}
+	meta := images.ReadMetadata(file, size, mediaType)
+	img = images.Orient(img, meta.Orientation)
+	// webp уже сжат, и если его не нужно поворачивать, перекодирование только увеличило бы его
+	if mediaType != webpType || meta.Orientation > 1 {
+		data, err := images.EncodeOriginal(img)
+		if err != nil {
+			return nil, err
+		}
+		file, size = bytes.NewReader(data), int64(len(data))
+	}
+	keep := images.Metadata{}
+	if s.images.KeepCopyright {
+		keep = meta.OnlyCopyright()
+	}
+	body, err := images.StripWebP(file, size, keep)
+	if err != nil {
+		return nil, imageError(err, mediaType)
+	}
+	bounds := img.Bounds()
+	placeholder := images.NewPlaceholder(img)
+	return &preparedFile{
+		body:        body,
+		contentType: webpType,
+		ext:         ".webp",
+		fileType:    domain.FileImage,
+		width:       bounds.Dx(),
+		height:      bounds.Dy(),
+		img:         img,
+		placeholder: &placeholder,
 	}, nil
 }
 
-func (s *FileService) Remove(ctx context.Context, path, filename string) error {
-	return s.storage.Remove(ctx, path, filename)
+// Remove удаляет файл dir/filename, при пустом filename - все файлы с префиксом dir. Общий объект удаляется
+// из хранилища и освобождает занятое место, когда на него не остается ссылок.
+func (s *FileService) Remove(ctx context.Context, dir, filename string) error {
+	if filename != "" {
+		stored, err := s.files.RemoveRef(ctx, dir, filename)
+		if err == nil {
+			return s.removeUnreferenced(ctx, stored)
+		}
+		if !errors.Is(err, domain.ErrFileNotFound) {
+			return err
+		}
+		// файлы, загруженные до появления общих объектов, лежат в каталоге dir
+		if err := s.storage.Remove(ctx, dir, filename); err != nil {
+			return err
+		}
+		return s.uploads.RemoveByKey(ctx, strings.TrimPrefix(path.Join(dir, filename), "/"))
+	}
+
+	// как и в хранилище, dir - префикс, поэтому каталоги сравниваются с завершающим "/"
+	stored, err := s.files.GetByDirPrefix(ctx, strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		return err
+	}
+	for _, file := range stored {
+		for _, ref := range file.Refs {
+			if !strings.HasPrefix(ref.Dir+"/", dir) {
+				continue
+			}
+			released, err := s.files.RemoveRef(ctx, ref.Dir, ref.Name)
+			if err != nil {
+				if errors.Is(err, domain.ErrFileNotFound) {
+					continue
+				}
+				return err
+			}
+			if err := s.removeUnreferenced(ctx, released); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.storage.Remove(ctx, dir, ""); err != nil {
+		return err
+	}
+	return s.uploads.RemoveByPrefix(ctx, strings.TrimPrefix(dir, "/"))
+}
+
+// removeUnreferenced удаляет объект, на который не осталось ссылок
+func (s *FileService) removeUnreferenced(ctx context.Context, stored *domain.StoredFile) error {
+	if len(stored.Refs) > 0 {
+		return nil
+	}
+	removed, err := s.files.RemoveUnreferenced(ctx, stored.Id)
+	if err != nil || !removed {
+		return err
+	}
+	// запись уже удалена, и новые ссылки на эти объекты не появятся: такой же файл сохранится заново
+	s.removeObjects(ctx, fileKeys(stored.File))
+	return nil
+}
+
+// removeObjects удаляет объекты из хранилища и учета места. Ошибки только логируются: объект без записи
+// о нем ни на что не влияет, кроме занятого места.
+func (s *FileService) removeObjects(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		dir, name := path.Split(key)
+		if err := s.storage.Remove(ctx, strings.TrimSuffix(dir, "/"), name); err != nil {
+			logger.Errorf("failed to remove %s: %s", key, err.Error())
+			continue
+		}
+		if err := s.uploads.RemoveByKey(ctx, key); err != nil {
+			logger.Errorf("failed to remove upload record %s: %s", key, err.Error())
+		}
+	}
+}
+
+// SetPrivate меняет доступ к файлам каталога dir. Общий объект остается публичным, пока на него есть
+// публичные ссылки. Файлы без ключа (загруженные до его появления) пропускаются.
+func (s *FileService) SetPrivate(ctx context.Context, dir string, files []domain.File, private bool) error {
+	for _, file := range files {
+		stored, err := s.files.SetRefPrivate(ctx, dir, file.Name, private)
+		if err != nil {
+			if !errors.Is(err, domain.ErrFileNotFound) {
+				return err
+			}
+			if err := s.setObjectPrivate(ctx, file, private); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.setObjectPrivate(ctx, stored.File, filePrivate(stored.Refs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setObjectPrivate меняет доступ к объектам файла и его вариантов в хранилище
+func (s *FileService) setObjectPrivate(ctx context.Context, file domain.File, private bool) error {
+	for _, key := range fileKeys(file) {
+		if err := s.storage.SetPrivate(ctx, key, private); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				logger.Errorf("file %s is missing in storage", key)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Sign возвращает копию files, в которой ссылки заменены подписанными с ограниченным сроком действия
+func (s *FileService) Sign(ctx context.Context, files []domain.File) ([]domain.File, error) {
+	if files == nil {
+		return nil, nil
+	}
+	signed := make([]domain.File, len(files))
+	for i, file := range files {
+		signed[i] = file
+		if file.Key != "" {
+			url, err := s.storage.SignedUrl(ctx, file.Key, s.signedUrlTTL)
+			if err != nil {
+				return nil, err
+			}
+			signed[i].Url = url
+		}
+
+		if file.Variants == nil {
+			continue
+		}
+		signed[i].Variants = make([]domain.FileVariant, len(file.Variants))
+		for j, v := range file.Variants {
+			signed[i].Variants[j] = v
+			if v.Key == "" {
+				continue
+			}
+			url, err := s.storage.SignedUrl(ctx, v.Key, s.signedUrlTTL)
+			if err != nil {
+				return nil, err
+			}
+			signed[i].Variants[j].Url = url
+		}
+	}
+	return signed, nil
+}
+
+func imageError(err error, mediaType string) error {
+	switch {
+	case errors.Is(err, images.ErrUnsupportedFormat):
+		return fmt.Errorf("%w: %s", domain.ErrUnsupportedFileType, mediaType)
+	case errors.Is(err, images.ErrTooLarge):
+		return domain.ErrImageTooLarge
+	}
+	return fmt.Errorf("%w: %s", domain.ErrInvalidFile, err)
+}
+
+// fileBase возвращает имя файла в хранилище без расширения
+func fileBase(origName, name string) string {
+	if name != "" {
+		return name
+	}
+	return strings.Split(origName, ".")[0] + fmt.Sprintf("_%d", time.Now().Unix())
+}
+
+func fileKeys(file domain.File) []string {
+	var keys []string
+	if file.Key != "" {
+		keys = append(keys, file.Key)
+	}
+	for _, v := range file.Variants {
+		if v.Key != "" {
+			keys = append(keys, v.Key)
+		}
+	}
+	return keys
 }
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/pkg/images"
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Бенчмарки сравнивают потоковую загрузку с прежней, при которой файл читался в память целиком и еще раз
+// копировался при отправке в хранилище. Кроме B/op они показывают пик занятой кучи (peak-MB) при
+// одновременных загрузках: go test -run=^$ -bench=Upload -benchmem ./internal/service
+
+// discardStorage читает загружаемые файлы и никуда их не сохраняет, как хранилище, отправляющее их по сети
+type discardStorage struct{}
+
+func (discardStorage) Upload(ctx context.Context, body io.Reader, size int64, contentType, path, filename string, private bool) (*storage.File, error) {
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, err
+	}
+	key := path + "/" + filename
+	return &storage.File{Name: filename, Url: "/" + key, Key: key}, nil
+}
+
+func (discardStorage) Remove(ctx context.Context, path, filename string) error { return nil }
+
+func (discardStorage) SetPrivate(ctx context.Context, key string, private bool) error { return nil }
+
+func (discardStorage) SignedUrl(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return key, nil
+}
+
+func (discardStorage) List(ctx context.Context, prefix string, fn func(obj storage.Object) error) error {
+	return nil
+}
+
+type nopUploads struct{}
+
+func (nopUploads) Save(ctx context.Context, upload domain.Upload) error { return nil }
+
+func (nopUploads) RemoveByKey(ctx context.Context, key string) error { return nil }
+
+func (nopUploads) RemoveByPrefix(ctx context.Context, prefix string) error { return nil }
+
+func (nopUploads) GetUsage(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	return 0, nil
+}
+
+// nopFiles не находит сохраненных объектов, поэтому каждая загрузка сохраняет файл заново
+type nopFiles struct{}
+
+func (nopFiles) Create(ctx context.Context, file domain.StoredFile) error { return nil }
+
+func (nopFiles) GetById(ctx context.Context, id string) (*domain.StoredFile, error) {
+	return nil, domain.ErrFileNotFound
+}
+
+func (nopFiles) GetAll(ctx context.Context) ([]domain.StoredFile, error) { return nil, nil }
+
+func (nopFiles) AddRef(ctx context.Context, id string, ref domain.FileRef) (*domain.StoredFile, error) {
+	return nil, domain.ErrFileNotFound
+}
+
+func (nopFiles) RemoveRef(ctx context.Context, dir, name string) (*domain.StoredFile, error) {
+	return nil, domain.ErrFileNotFound
+}
+
+func (nopFiles) RemoveOtherRef(ctx context.Context, id, dir, name string) (*domain.StoredFile, error) {
+	return nil, domain.ErrFileNotFound
+}
+
+func (nopFiles) SetRefPrivate(ctx context.Context, dir, name string, private bool) (*domain.StoredFile, error) {
+	return nil, domain.ErrFileNotFound
+}
+
+func (nopFiles) GetByDirPrefix(ctx context.Context, prefix string) ([]domain.StoredFile, error) {
+	return nil, nil
+}
+
+func (nopFiles) RemoveUnreferenced(ctx context.Context, id string) (bool, error) { return false, nil }
+
+// bufferedUpload повторяет прежнюю загрузку документа: чтение файла целиком и копия при отправке
+func bufferedUpload(ctx context.Context, p storage.Provider, file io.Reader) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	copied := append([]byte(nil), data...)
+	_, err = p.Upload(ctx, bytes.NewReader(copied), int64(len(copied)), "application/pdf", "bench", "doc.pdf", false)
+	return err
+}
+
+func BenchmarkUploadDocument(b *testing.B) {
+	// multipart сохраняет большие файлы во временные, поэтому загрузка читается с диска
+	doc := make([]byte, 8<<20)
+	rand.New(rand.NewSource(1)).Read(doc)
+	copy(doc, "%PDF-1.7\n")
+	path := writeFixture(b, "doc.pdf", doc)
+	header := &multipart.FileHeader{Filename: "doc.pdf", Size: int64(len(doc))}
+	s := NewFileService(discardStorage{}, nopUploads{}, nopFiles{}, nil, nil, time.Minute, ImageOptions{}, UploadLimits{})
+
+	b.Run("buffered", func(b *testing.B) {
+		runParallel(b, path, func(ctx context.Context, file multipart.File) error {
+			return bufferedUpload(ctx, s.storage, file)
+		})
+	})
+	b.Run("streamed", func(b *testing.B) {
+		runParallel(b, path, func(ctx context.Context, file multipart.File) error {
+			_, err := s.UploadAttachment(ctx, primitive.NewObjectID(), file, header, "bench", "doc", false)
+			return err
+		})
+	})
+}
+
+func BenchmarkUploadImage(b *testing.B) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, noise(800, 600)); err != nil {
+		b.Fatal(err)
+	}
+	path := writeFixture(b, "photo.png", buf.Bytes())
+	header := &multipart.FileHeader{Filename: "photo.png", Size: int64(buf.Len())}
+	opts := ImageOptions{Variants: []images.Preset{
+		{Name: "thumbnail", MaxWidth: 320, MaxHeight: 320, Quality: 75},
+		{Name: "medium", MaxWidth: 800, MaxHeight: 800, Quality: 80},
+	}}
+
+	// без ограничения каждая одновременная загрузка держит в памяти свое распакованное изображение
+	for _, concurrency := range []int{1, 1 << 10} {
+		s := NewFileService(discardStorage{}, nopUploads{}, nopFiles{}, nil, nil, time.Minute, opts, UploadLimits{Concurrency: concurrency})
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			runParallel(b, path, func(ctx context.Context, file multipart.File) error {
+				_, err := s.Upload(ctx, primitive.NewObjectID(), file, header, "bench", "photo", false)
+				return err
+			})
+		})
+	}
+}
+
+// runParallel загружает файл из path одновременно в 4*GOMAXPROCS горутинах и сообщает пик занятой кучи
+func runParallel(b *testing.B, path string, upload func(ctx context.Context, file multipart.File) error) {
+	b.ReportAllocs()
+	b.SetParallelism(4)
+	peak := watchHeap()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		defer file.Close()
+		for pb.Next() {
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				b.Error(err)
+				return
+			}
+			if err := upload(context.Background(), file); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+	b.ReportMetric(float64(peak())/(1<<20), "peak-MB")
+}
+
+// watchHeap следит за размером кучи до вызова возвращенной функции, которая отдает прирост пика над начальным размером
+func watchHeap() func() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	base, peak := stats.HeapAlloc, stats.HeapAlloc
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > peak {
+					peak = stats.HeapAlloc
+				}
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		wg.Wait()
+		return peak - base
+	}
+}
+
+func writeFixture(b *testing.B, name string, data []byte) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// noise - изображение, которое плохо сжимается, как фотография
+func noise(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(r.Intn(64)), A: 255})
+		}
+	}
+	return img
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FilesGCOptions настраивает сборку мусора в хранилище
+type FilesGCOptions struct {
+	// GracePeriod - сколько файл без ссылок на него не считается мусором. Файл загружается раньше, чем
+	// сохраняется проект или пользователь с ним, и свежий файл может быть еще не сохранен.
+	GracePeriod time.Duration
+	// DryRun - только записывать найденный мусор в лог, ничего не удаляя
+	DryRun bool
+}
+
+// FilesGCService удаляет файлы, которые остались в хранилище без ссылок, например, когда файл загружен,
+// а сохранить проект или пользователя не удалось. Ссылками считаются файлы пользователей, проектов
+// (включая удаленные в корзину) и ревизий проектов.
+type FilesGCService struct {
+	users     repository.Users
+	projects  repository.Projects
+	revisions repository.Revisions
+	stored    repository.Files
+	storage   storage.Provider
+	files     *FileService
+	conf      FilesGCOptions
+}
+
+func NewFilesGCService(users repository.Users, projects repository.Projects, revisions repository.Revisions, stored repository.Files,
+	storage storage.Provider, files *FileService, conf FilesGCOptions) *FilesGCService {
+	return &FilesGCService{
+		users:     users,
+		projects:  projects,
+		revisions: revisions,
+		stored:    stored,
+		storage:   storage,
+		files:     files,
+		conf:      conf,
+	}
+}
+
+// Report находит мусор в хранилище, ничего не удаляя
+func (s *FilesGCService) Report(ctx context.Context) (*domain.OrphanReport, error) {
+	return s.collect(ctx, true)
+}
+
+// Collect удаляет мусор из хранилища (в режиме DryRun только сообщает о нем)
+func (s *FilesGCService) Collect(ctx context.Context) error {
+	report, err := s.collect(ctx, s.conf.DryRun)
+	if err != nil {
+		return err
+	}
+	if len(report.Refs) == 0 && len(report.Objects) == 0 {
+		return nil
+	}
+	if report.DryRun {
+		for _, ref := range report.Refs {
+			logger.Infof("files gc: orphaned reference %s", ref)
+		}
+		for _, obj := range report.Objects {
+			logger.Infof("files gc: orphaned object %s (%d bytes)", obj.Key, obj.Size)
+		}
+		logger.Infof("files gc: dry run found %d references and %d objects (%d bytes)", len(report.Refs), len(report.Objects), report.Size)
+		return nil
+	}
+	logger.Infof("files gc: removed %d references and %d objects (%d bytes)", len(report.Refs), len(report.Objects), report.Size)
+	return nil
+}
+
+func (s *FilesGCService) collect(ctx context.Context, dryRun bool) (*domain.OrphanReport, error) {
+	before := time.Now().Add(-s.conf.GracePeriod)
+	refs, keys, err := s.references(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.stored.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.OrphanReport{DryRun: dryRun, Refs: []string{}, Objects: []domain.OrphanObject{}}
+	var orphanRefs []domain.FileRef
+	// unreferenced - общие объекты, на которые не останется ссылок, freed - их ключи
+	var unreferenced []domain.StoredFile
+	freed := map[string]bool{}
+	for _, file := range stored {
+		live := 0
+		for _, ref := range file.Refs {
+			if refs[path.Join(ref.Dir, ref.Name)] || ref.CreatedAt.After(before) {
+				live++
+				continue
+			}
+			orphanRefs = append(orphanRefs, ref)
+			report.Refs = append(report.Refs, path.Join(ref.Dir, ref.Name))
+		}
+		for _, key := range fileKeys(file.File) {
+			if live > 0 {
+				keys[key] = true
+			} else {
+				freed[key] = true
+			}
+		}
+		if live == 0 {
+			unreferenced = append(unreferenced, file)
+		}
+	}
+
+	var orphanKeys []string
+	err = s.storage.List(ctx, "", func(obj storage.Object) error {
+		if !freed[obj.Key] {
+			if !ownKey(obj.Key) || keys[obj.Key] || obj.UpdatedAt.After(before) {
+				return nil
+			}
+			orphanKeys = append(orphanKeys, obj.Key)
+		}
+		report.Objects = append(report.Objects, domain.OrphanObject{Key: obj.Key, Size: obj.Size, UpdatedAt: obj.UpdatedAt})
+		report.Size += obj.Size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	for _, ref := range orphanRefs {
+		if _, err := s.stored.RemoveRef(ctx, ref.Dir, ref.Name); err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+			return nil, err
+		}
+	}
+	// объект удаляется, только если на него так и не сослались за время сборки
+	for _, file := range unreferenced {
+		file.Refs = nil
+		if err := s.files.removeUnreferenced(ctx, &file); err != nil {
+			return nil, err
+		}
+	}
+	s.files.removeObjects(ctx, orphanKeys)
+	return report, nil
+}
+
+// references собирает ссылки на общие объекты (каталог/имя) и ключи файлов, сохраненных до их появления
+func (s *FilesGCService) references(ctx context.Context) (map[string]bool, map[string]bool, error) {
+	refs, keys := map[string]bool{}, map[string]bool{}
+	add := func(dir string, files ...domain.File) {
+		for _, file := range files {
+			if file.Name == "" {
+				continue
+			}
+			refs[path.Join(dir, file.Name)] = true
+			// у старых файлов нет ключа, они лежат под своим именем в каталоге владельца
+			keys[objectKey(dir, file.Name)] = true
+			for _, key := range fileKeys(file) {
+				keys[key] = true
+			}
+		}
+	}
+
+	users, err := s.users.GetAllAvatars(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, user := range users {
+		add(avatarDir(user.Id), user.Avatar)
+	}
+
+	projects, err := s.projects.GetAllFiles(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	owners := make(map[primitive.ObjectID]primitive.ObjectID, len(projects))
+	for _, project := range projects {
+		owners[project.Id] = project.UserId
+		dir := projectFilesPath(project.UserId, project.Id)
+		add(dir, project.Files...)
+		if project.Draft != nil {
+			add(dir, project.Draft.Files...)
+		}
+	}
+
+	revisions, err := s.revisions.GetAllFiles(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, revision := range revisions {
+		owner, ok := owners[revision.ProjectId]
+		if !ok {
+			// проект мог быть создан уже после чтения проектов, файлы его ревизий все равно не трогаем
+			for _, file := range revision.Snapshot.Files {
+				for _, key := range fileKeys(file) {
+					keys[key] = true
+				}
+			}
+			continue
+		}
+		add(projectFilesPath(owner, revision.ProjectId), revision.Snapshot.Files...)
+	}
+	return refs, keys, nil
+}
+
+// ownKey сообщает, создан ли объект приложением: в хранилище могут лежать и чужие файлы.
+// Файлы пользователей лежат в каталоге с его id, общие объекты - в storedFilesDir.
+func ownKey(key string) bool {
+	first := strings.SplitN(key, "/", 2)[0]
+	return first == storedFilesRoot || primitive.IsValidObjectID(first)
+}
+
+// avatarDir - каталог аватара пользователя, как его задает обработчик обновления пользователя
+func avatarDir(userId primitive.ObjectID) string {
+	return userId.Hex() + "/avatar"
+}
+
+func objectKey(dir, name string) string {
+	return strings.TrimPrefix(path.Join(dir, name), "/")
+}
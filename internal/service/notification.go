@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationService хранит уведомления пользователей о событиях в их проектах
+type NotificationService struct {
+	repo repository.Notifications
+}
+
+func NewNotificationService(repo repository.Notifications) *NotificationService {
+	return &NotificationService{
+		repo: repo,
+	}
+}
+
+// GetNotifications возвращает уведомления пользователя, новые - первыми
+func (s *NotificationService) GetNotifications(ctx context.Context, userId primitive.ObjectID,
+	params pagination.Params) ([]domain.Notification, *pagination.Page, error) {
+	return s.repo.GetByUser(ctx, userId, params)
+}
+
+func (s *NotificationService) GetUnread(ctx context.Context, userId primitive.ObjectID) (*domain.UnreadNotifications, error) {
+	count, err := s.repo.CountUnread(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UnreadNotifications{Count: count}, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userId, id primitive.ObjectID) error {
+	return s.repo.MarkRead(ctx, userId, id)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userId primitive.ObjectID) error {
+	return s.repo.MarkAllRead(ctx, userId)
+}
+
+// notify сохраняет уведомление. Событие, о котором оно сообщает, уже произошло, поэтому ошибка только пишется в лог.
+func (s *NotificationService) notify(ctx context.Context, notification domain.Notification) {
+	notification.CreatedAt = time.Now()
+	if err := s.repo.Create(ctx, notification); err != nil {
+		logger.Errorf("failed to notify user %s: %s", notification.UserId.Hex(), err.Error())
+	}
+}
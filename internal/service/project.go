@@ -2,25 +2,88 @@ package service
 
 import (
 	"context"
-	"errors"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Alexander272/my-portfolio/internal/domain"
 	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ограничения длины подписи и альтернативного текста файла галереи в символах
+const (
+	maxCaptionLength = 1000
+	maxAltLength     = 300
+)
+
+// уровни доступа к проекту: соавтор-наблюдатель только просматривает проект, редактор меняет его содержимое,
+// а публикация, настройки, удаление и соавторы остаются за владельцем
+type projectAccess int
+
+const (
+	accessView projectAccess = iota
+	accessEdit
+	accessOwner
+)
+
 type ProjectService struct {
-	repo repository.Projects
+	repo      repository.Projects
+	users     repository.Users
+	revisions repository.Revisions
+	search    repository.Search
+	files     File
+	retention RevisionRetention
+}
+
+// RevisionRetention задает, сколько ревизий проекта хранить и как долго. Нулевые значения снимают ограничение.
+type RevisionRetention struct {
+	MaxCount int
+	MaxAge   time.Duration
 }
 
-func NewProjectService(repo repository.Projects) *ProjectService {
+func NewProjectService(repo repository.Projects, users repository.Users, revisions repository.Revisions, search repository.Search,
+	files File, retention RevisionRetention) *ProjectService {
 	return &ProjectService{
-		repo: repo,
+		repo:      repo,
+		users:     users,
+		revisions: revisions,
+		search:    search,
+		files:     files,
+		retention: retention,
 	}
 }
 
-func (s *ProjectService) GetProjects(ctx context.Context, userId primitive.ObjectID) ([]domain.ProjectMin, error) {
-	return s.repo.GetProjects(ctx, userId)
+func (s *ProjectService) GetProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.ProjectMin, *pagination.Page, error) {
+	projects, page, err := s.repo.GetProjects(ctx, userId, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	// в список попадают только проекты, доступные всем, их файлы не нужно подписывать
+	for i := range projects {
+		projects[i].Cover = coverFile(projects[i].Files)
+	}
+	return projects, page, nil
+}
+
+func (s *ProjectService) GetSelfProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
+	projects, page, err := s.repo.GetSelfProjects(ctx, userId, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projects, page, setSelfCovers(ctx, s.files, projects)
+}
+
+func (s *ProjectService) GetDrafts(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
+	projects, page, err := s.repo.GetDrafts(ctx, userId, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projects, page, setSelfCovers(ctx, s.files, projects)
 }
 
 func (s *ProjectService) GetProjectById(ctx context.Context, projectId primitive.ObjectID) (*domain.Project, error) {
@@ -28,9 +91,629 @@ func (s *ProjectService) GetProjectById(ctx context.Context, projectId primitive
 	if err != nil {
 		return nil, err
 	}
+	// скрытый проект для посторонних выглядит так же, как неопубликованный или удаленный
+	if !project.Published || project.Access == domain.Nobody {
+		return nil, domain.ErrProjectNotFound
+	}
+	if filesPrivate(project.Access) {
+		if project.Files, err = s.files.Sign(ctx, project.Files); err != nil {
+			return nil, err
+		}
+	}
+	if project.Authors, err = s.authors(ctx, project.UserId, project.Collaborators); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
 
-	if project.Access == domain.Nobody {
-		return nil, errors.New("access forbidden")
+// authors возвращает владельца проекта и его соавторов, владелец всегда первый
+func (s *ProjectService) authors(ctx context.Context, ownerId primitive.ObjectID, collaborators []domain.Collaborator) ([]domain.ProjectAuthor, error) {
+	ids := []primitive.ObjectID{ownerId}
+	roles := map[primitive.ObjectID]domain.CollaboratorRole{}
+	for _, c := range collaborators {
+		ids = append(ids, c.UserId)
+		roles[c.UserId] = c.Role
+	}
+	users, err := s.users.GetAuthors(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[primitive.ObjectID]domain.ProjectAuthor, len(users))
+	for _, u := range users {
+		byId[u.Id] = u
+	}
+
+	authors := []domain.ProjectAuthor{}
+	for _, id := range ids {
+		// удаленные пользователи не показываются
+		author, ok := byId[id]
+		if !ok {
+			continue
+		}
+		author.Role = roles[id]
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
+
+// GetSelfProjectById возвращает проект владельцу или соавтору с любой ролью
+func (s *ProjectService) GetSelfProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error) {
+	project, err := s.projectFor(ctx, projectId, userId, accessView)
+	if err != nil {
+		return nil, err
+	}
+	if filesPrivate(project.Access) {
+		if project.Files, err = s.files.Sign(ctx, project.Files); err != nil {
+			return nil, err
+		}
+		if project.Draft != nil {
+			if project.Draft.Files, err = s.files.Sign(ctx, project.Draft.Files); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return project, nil
 }
+
+func (s *ProjectService) CreateProject(ctx context.Context, project domain.ProjectInput) (primitive.ObjectID, error) {
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+	project.Published = false
+	if project.Access == "" {
+		project.Access = domain.All
+	}
+	project.CommentsMode = domain.CommentsOpen
+
+	id, err := s.repo.CreateProject(ctx, project)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	s.syncSearch(ctx, id, project.UserId)
+	s.recordRevision(ctx, domain.SelfProject{Id: id, UserId: project.UserId}, project.UserId, domain.RevisionCreate)
+	return id, nil
+}
+
+// UpdateProject меняет неопубликованный проект напрямую. У опубликованного проекта содержимое сохраняется
+// в черновик, а живая версия остается прежней до повторной публикации. Черновик, оставшийся после снятия
+// с публикации, тоже продолжает редактироваться, иначе публикация заменила бы им более новые правки. Каждое изменение сохраняется ревизией.
+// Соавтор-редактор меняет только содержимое, доступ и порядок проекта меняет владелец.
+func (s *ProjectService) UpdateProject(ctx context.Context, projectId, userId primitive.ObjectID, project domain.SelfProject) error {
+	current, err := s.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+	if current.UserId != userId && (project.Access != "" || project.Order != 0) {
+		return domain.ErrProjectForbidden
+	}
+
+	if err := s.updateProject(ctx, current, project); err != nil {
+		return err
+	}
+	s.recordRevision(ctx, *current, userId, domain.RevisionUpdate)
+
+	if project.Access != "" && filesPrivate(project.Access) != filesPrivate(current.Access) {
+		return s.syncFilesAccess(ctx, current)
+	}
+	return nil
+}
+
+func (s *ProjectService) updateProject(ctx context.Context, current *domain.SelfProject, project domain.SelfProject) error {
+	projectId, userId := current.Id, current.UserId
+
+	project.UpdatedAt = time.Now()
+	if !current.Published && current.Draft == nil {
+		if err := s.repo.UpdateProject(ctx, projectId, project); err != nil {
+			return err
+		}
+		s.syncSearch(ctx, projectId, userId)
+		return nil
+	}
+
+	if project.Name != "" || project.Description != "" || project.Tags != nil || project.Files != nil {
+		draft := current.Draft
+		if draft == nil {
+			draft = &domain.ProjectDraft{
+				Name:        current.Name,
+				Description: current.Description,
+				Tags:        current.Tags,
+				Files:       current.Files,
+			}
+		}
+		if project.Name != "" {
+			draft.Name = project.Name
+		}
+		if project.Description != "" {
+			draft.Description = project.Description
+		}
+		if project.Tags != nil {
+			draft.Tags = project.Tags
+		}
+		if project.Files != nil {
+			draft.Files = project.Files
+		}
+		draft.UpdatedAt = project.UpdatedAt
+		if err := s.repo.SaveDraft(ctx, projectId, *draft); err != nil {
+			return err
+		}
+	}
+
+	// доступ и порядок - настройки проекта, а не его содержимое, поэтому применяются сразу
+	if project.Access != "" || project.Order != 0 {
+		if err := s.repo.UpdateProject(ctx, projectId, domain.SelfProject{
+			Access:    project.Access,
+			Order:     project.Order,
+			UpdatedAt: project.UpdatedAt,
+		}); err != nil {
+			return err
+		}
+		s.syncSearch(ctx, projectId, userId)
+	}
+	return nil
+}
+
+// PublishProject публикует проект или его черновик. Опубликованный проект без черновика не публикуется повторно,
+// чтобы не сдвигать дату публикации.
+func (s *ProjectService) PublishProject(ctx context.Context, projectId, userId primitive.ObjectID) error {
+	project, err := s.projectFor(ctx, projectId, userId, accessOwner)
+	if err != nil {
+		return err
+	}
+	if project.Published && project.Draft == nil {
+		return domain.ErrNothingToPublish
+	}
+	return s.publish(ctx, *project)
+}
+
+func (s *ProjectService) UnpublishProject(ctx context.Context, projectId, userId primitive.ObjectID) error {
+	if _, err := s.projectFor(ctx, projectId, userId, accessOwner); err != nil {
+		return err
+	}
+
+	if err := s.repo.UnpublishProject(ctx, projectId); err != nil {
+		return err
+	}
+	s.syncSearch(ctx, projectId, userId)
+	return nil
+}
+
+// SchedulePublish откладывает публикацию проекта (или его черновика) до publishAt, nil отменяет запланированную публикацию
+func (s *ProjectService) SchedulePublish(ctx context.Context, projectId, userId primitive.ObjectID, publishAt *time.Time) error {
+	project, err := s.projectFor(ctx, projectId, userId, accessOwner)
+	if err != nil {
+		return err
+	}
+	if publishAt != nil {
+		if !publishAt.After(time.Now()) {
+			return domain.ErrInvalidPublishAt
+		}
+		if project.Published && project.Draft == nil {
+			return domain.ErrNothingToPublish
+		}
+	}
+	return s.repo.SetPublishAt(ctx, projectId, publishAt)
+}
+
+// PublishScheduled публикует проекты, время публикации которых уже наступило. Вызывается планировщиком.
+func (s *ProjectService) PublishScheduled(ctx context.Context) error {
+	projects, err := s.repo.GetScheduled(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		if err := s.publish(ctx, project); err != nil {
+			logger.Errorf("failed to publish scheduled project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		logger.Infof("scheduled project %s published", project.Id.Hex())
+	}
+	return nil
+}
+
+func (s *ProjectService) publish(ctx context.Context, project domain.SelfProject) error {
+	if err := s.repo.PublishProject(ctx, project.Id, project.Draft, time.Now()); err != nil {
+		return err
+	}
+	s.syncSearch(ctx, project.Id, project.UserId)
+	return nil
+}
+
+// RemoveProject переносит проект в корзину, окончательно он удаляется по истечении срока хранения
+func (s *ProjectService) RemoveProject(ctx context.Context, projectId, userId primitive.ObjectID) error {
+	if _, err := s.projectFor(ctx, projectId, userId, accessOwner); err != nil {
+		return err
+	}
+
+	if err := s.repo.RemoveProject(ctx, projectId, time.Now()); err != nil {
+		return err
+	}
+	if err := s.search.RemoveProject(ctx, projectId); err != nil {
+		logger.Errorf("failed to remove project %s from search index: %s", projectId.Hex(), err.Error())
+	}
+	return nil
+}
+
+// syncSearch обновляет проект в поисковом индексе. Ошибка индексации не отменяет уже сохраненные изменения.
+func (s *ProjectService) syncSearch(ctx context.Context, projectId, userId primitive.ObjectID) {
+	project, err := s.repo.GetSelfProjectById(ctx, projectId, userId)
+	if err == nil {
+		err = s.search.IndexProject(ctx, *project)
+	}
+	if err != nil {
+		logger.Errorf("failed to index project %s: %s", projectId.Hex(), err.Error())
+	}
+}
+
+func (s *ProjectService) GetRevisions(ctx context.Context, projectId, userId primitive.ObjectID, params pagination.Params) ([]domain.Revision, *pagination.Page, error) {
+	if _, err := s.projectFor(ctx, projectId, userId, accessView); err != nil {
+		return nil, nil, err
+	}
+	return s.revisions.GetByProject(ctx, projectId, params)
+}
+
+func (s *ProjectService) GetRevision(ctx context.Context, projectId, userId, revisionId primitive.ObjectID) (*domain.Revision, error) {
+	if _, err := s.projectFor(ctx, projectId, userId, accessView); err != nil {
+		return nil, err
+	}
+	return s.revisions.GetById(ctx, projectId, revisionId)
+}
+
+func (s *ProjectService) DiffRevisions(ctx context.Context, projectId, userId, fromId, toId primitive.ObjectID) (*domain.RevisionDiff, error) {
+	if _, err := s.projectFor(ctx, projectId, userId, accessView); err != nil {
+		return nil, err
+	}
+	from, err := s.revisions.GetById(ctx, projectId, fromId)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.revisions.GetById(ctx, projectId, toId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RevisionDiff{
+		From:   from.Id,
+		To:     to.Id,
+		Fields: from.Snapshot.Diff(to.Snapshot),
+	}, nil
+}
+
+// RestoreRevision делает содержимое старой ревизии текущим и записывает это новой ревизией.
+// Как и при обычном редактировании, проект с опубликованной версией или черновиком получает восстановленное
+// содержимое в черновик.
+func (s *ProjectService) RestoreRevision(ctx context.Context, projectId, userId, revisionId primitive.ObjectID) error {
+	current, err := s.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+	revision, err := s.revisions.GetById(ctx, projectId, revisionId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.RestoreProject(ctx, projectId, revision.Snapshot, current.Published || current.Draft != nil, time.Now()); err != nil {
+		return err
+	}
+	s.syncSearch(ctx, projectId, current.UserId)
+	s.recordRevision(ctx, *current, userId, domain.RevisionRestore)
+	// восстановленные файлы могли быть загружены при другом уровне доступа
+	return s.syncFilesAccess(ctx, current)
+}
+
+// AddFile загружает изображение или документ и добавляет его в конец галереи проекта (у опубликованного проекта -
+// черновика) с подписью и альтернативным текстом из input. Файлы проектов, доступных не всем, загружаются приватными.
+func (s *ProjectService) AddFile(ctx context.Context, projectId, userId primitive.ObjectID, file multipart.File,
+	header *multipart.FileHeader, input domain.GalleryFileInput) (*domain.File, error) {
+	if err := checkGalleryText(input); err != nil {
+		return nil, err
+	}
+	return s.attachFile(ctx, projectId, userId, input, func(ownerId primitive.ObjectID, dir, filename string, private bool) (*domain.File, error) {
+		return s.files.UploadAttachment(ctx, ownerId, file, header, dir, filename, private)
+	})
+}
+
+// attachFile сохраняет файл функцией upload в каталог файлов проекта с доступом, как у проекта,
+// и добавляет его в содержимое проекта. Файл соавтора сохраняется от имени владельца и занимает его квоту.
+func (s *ProjectService) attachFile(ctx context.Context, projectId, userId primitive.ObjectID, input domain.GalleryFileInput,
+	upload func(ownerId primitive.ObjectID, dir, filename string, private bool) (*domain.File, error)) (*domain.File, error) {
+	current, err := s.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	private := filesPrivate(current.Access)
+	uploaded, err := upload(current.UserId, projectFilesPath(current.UserId, projectId), primitive.NewObjectID().Hex(), private)
+	if err != nil {
+		return nil, err
+	}
+	setGalleryText(uploaded, input)
+
+	files := append(append([]domain.File{}, contentFiles(current)...), *uploaded)
+	if err := s.updateFiles(ctx, current, userId, files); err != nil {
+		return nil, err
+	}
+
+	if private {
+		signed, err := s.files.Sign(ctx, []domain.File{*uploaded})
+		if err != nil {
+			return nil, err
+		}
+		return &signed[0], nil
+	}
+	return uploaded, nil
+}
+
+// RemoveFile убирает файл из содержимого проекта. Из хранилища файл не удаляется:
+// на него могут ссылаться живая версия проекта и его ревизии.
+func (s *ProjectService) RemoveFile(ctx context.Context, projectId, userId primitive.ObjectID, name string) error {
+	current, err := s.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+
+	files := []domain.File{}
+	for _, f := range contentFiles(current) {
+		if f.Name != name {
+			files = append(files, f)
+		}
+	}
+	if len(files) == len(contentFiles(current)) {
+		return domain.ErrFileNotFound
+	}
+
+	return s.updateFiles(ctx, current, userId, files)
+}
+
+// ReorderFiles расставляет файлы галереи в порядке names, в котором должны быть перечислены все файлы проекта
+func (s *ProjectService) ReorderFiles(ctx context.Context, projectId, userId primitive.ObjectID, names []string) error {
+	current, err := s.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]domain.File, len(contentFiles(current)))
+	for _, f := range contentFiles(current) {
+		byName[f.Name] = f
+	}
+	if len(names) != len(byName) {
+		return domain.ErrInvalidFileOrder
+	}
+	files := make([]domain.File, 0, len(names))
+	for _, name := range names {
+		f, ok := byName[name]
+		if !ok {
+			return domain.ErrInvalidFileOrder
+		}
+		// повторное имя не найдется во второй раз
+		delete(byName, name)
+		files = append(files, f)
+	}
+
+	return s.updateFiles(ctx, current, userId, files)
+}
+
+// UpdateFile меняет подпись и альтернативный текст файла галереи
+func (s *ProjectService) UpdateFile(ctx context.Context, projectId, userId primitive.ObjectID, name string,
+	input domain.GalleryFileInput) (*domain.File, error) {
+	if err := checkGalleryText(input); err != nil {
+		return nil, err
+	}
+	current, err := s.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	files := append([]domain.File{}, contentFiles(current)...)
+	i := fileIndex(files, name)
+	if i < 0 {
+		return nil, domain.ErrFileNotFound
+	}
+	setGalleryText(&files[i], input)
+	if err := s.updateFiles(ctx, current, userId, files); err != nil {
+		return nil, err
+	}
+
+	if filesPrivate(current.Access) {
+		signed, err := s.files.Sign(ctx, files[i:i+1])
+		if err != nil {
+			return nil, err
+		}
+		return &signed[0], nil
+	}
+	return &files[i], nil
+}
+
+// SetCover делает изображение name обложкой проекта, пустое name возвращает обложку по умолчанию - первое изображение
+func (s *ProjectService) SetCover(ctx context.Context, projectId, userId primitive.ObjectID, name string) error {
+	current, err := s.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return err
+	}
+
+	files := append([]domain.File{}, contentFiles(current)...)
+	if name != "" {
+		i := fileIndex(files, name)
+		if i < 0 {
+			return domain.ErrFileNotFound
+		}
+		if files[i].FileType != domain.FileImage {
+			return domain.ErrCoverNotImage
+		}
+	}
+	for i := range files {
+		files[i].Cover = files[i].Name == name
+	}
+	return s.updateFiles(ctx, current, userId, files)
+}
+
+// updateFiles сохраняет новый список файлов содержимого проекта и записывает изменение ревизией от имени authorId
+func (s *ProjectService) updateFiles(ctx context.Context, current *domain.SelfProject, authorId primitive.ObjectID, files []domain.File) error {
+	if err := s.updateProject(ctx, current, domain.SelfProject{Files: files}); err != nil {
+		return err
+	}
+	s.recordRevision(ctx, *current, authorId, domain.RevisionUpdate)
+	return nil
+}
+
+// syncFilesAccess приводит доступ к файлам живой версии и черновика в соответствие с доступом к проекту
+func (s *ProjectService) syncFilesAccess(ctx context.Context, current *domain.SelfProject) error {
+	project, err := s.repo.GetSelfProjectById(ctx, current.Id, current.UserId)
+	if err != nil {
+		return err
+	}
+
+	private, dir := filesPrivate(project.Access), projectFilesPath(project.UserId, project.Id)
+	if err := s.files.SetPrivate(ctx, dir, project.Files, private); err != nil {
+		return err
+	}
+	if project.Draft != nil {
+		return s.files.SetPrivate(ctx, dir, project.Draft.Files, private)
+	}
+	return nil
+}
+
+// recordRevision сохраняет текущее содержимое проекта ревизией, если оно отличается от предыдущего состояния.
+// Ошибки только логируются: изменение проекта к этому моменту уже сохранено.
+func (s *ProjectService) recordRevision(ctx context.Context, previous domain.SelfProject, authorId primitive.ObjectID, action domain.RevisionAction) {
+	projectId := previous.Id
+	project, err := s.repo.GetSelfProjectById(ctx, projectId, previous.UserId)
+	if err != nil {
+		logger.Errorf("failed to load project %s for revision: %s", projectId.Hex(), err.Error())
+		return
+	}
+
+	snapshot := project.Snapshot()
+	diff := previous.Snapshot().Diff(snapshot)
+	if len(diff) == 0 && action == domain.RevisionUpdate {
+		return
+	}
+	changes := make([]string, 0, len(diff))
+	for _, d := range diff {
+		changes = append(changes, d.Field)
+	}
+
+	now := time.Now()
+	if _, err := s.revisions.Create(ctx, domain.Revision{
+		ProjectId: projectId,
+		UserId:    authorId,
+		Action:    action,
+		Changes:   changes,
+		Snapshot:  snapshot,
+		CreatedAt: now,
+	}); err != nil {
+		logger.Errorf("failed to save revision of project %s: %s", projectId.Hex(), err.Error())
+		return
+	}
+
+	var olderThan time.Time
+	if s.retention.MaxAge > 0 {
+		olderThan = now.Add(-s.retention.MaxAge)
+	}
+	if err := s.revisions.Prune(ctx, projectId, s.retention.MaxCount, olderThan); err != nil {
+		logger.Errorf("failed to prune revisions of project %s: %s", projectId.Hex(), err.Error())
+	}
+}
+
+// projectFor возвращает проект, если у пользователя есть доступ уровня level. Тому, кто не владелец и
+// не соавтор, проект не виден вовсе, а соавтору с недостаточной ролью возвращается ErrProjectForbidden.
+func (s *ProjectService) projectFor(ctx context.Context, projectId, userId primitive.ObjectID, level projectAccess) (*domain.SelfProject, error) {
+	project, err := s.repo.GetMemberProjectById(ctx, projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if project.UserId == userId {
+		return project, nil
+	}
+	switch level {
+	case accessView:
+		return project, nil
+	case accessEdit:
+		for _, c := range project.Collaborators {
+			if c.UserId == userId && c.Role == domain.RoleEditor {
+				return project, nil
+			}
+		}
+	}
+	return nil, domain.ErrProjectForbidden
+}
+
+// filesPrivate сообщает, что файлы проекта нельзя раздавать по постоянным публичным ссылкам
+func filesPrivate(access domain.AccessType) bool {
+	return access != domain.All
+}
+
+// contentFiles возвращает файлы редактируемого содержимого: черновика, если он есть, иначе живой версии
+func contentFiles(project *domain.SelfProject) []domain.File {
+	if project.Draft != nil {
+		return project.Draft.Files
+	}
+	return project.Files
+}
+
+func checkGalleryText(input domain.GalleryFileInput) error {
+	if (input.Caption != nil && utf8.RuneCountInString(*input.Caption) > maxCaptionLength) ||
+		(input.Alt != nil && utf8.RuneCountInString(*input.Alt) > maxAltLength) {
+		return domain.ErrGalleryTextLong
+	}
+	return nil
+}
+
+func setGalleryText(file *domain.File, input domain.GalleryFileInput) {
+	if input.Caption != nil {
+		file.Caption = strings.TrimSpace(*input.Caption)
+	}
+	if input.Alt != nil {
+		file.Alt = strings.TrimSpace(*input.Alt)
+	}
+}
+
+func fileIndex(files []domain.File, name string) int {
+	for i, f := range files {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// coverFile возвращает обложку проекта: выбранное изображение, а если его нет - первое изображение галереи
+func coverFile(files []domain.File) *domain.File {
+	var first *domain.File
+	for i := range files {
+		if files[i].FileType != domain.FileImage {
+			continue
+		}
+		if files[i].Cover {
+			return &files[i]
+		}
+		if first == nil {
+			first = &files[i]
+		}
+	}
+	return first
+}
+
+// setSelfCovers выбирает обложки для списков проектов владельца: по рабочему содержимому, с подписанными
+// ссылками для проектов, доступных не всем
+func setSelfCovers(ctx context.Context, files File, projects []domain.SelfProjectMin) error {
+	for i := range projects {
+		content := projects[i].Files
+		if projects[i].Draft != nil {
+			content = projects[i].Draft.Files
+		}
+		cover := coverFile(content)
+		if cover != nil && filesPrivate(projects[i].Access) {
+			signed, err := files.Sign(ctx, []domain.File{*cover})
+			if err != nil {
+				return err
+			}
+			cover = &signed[0]
+		}
+		projects[i].Cover = cover
+	}
+	return nil
+}
+
+func projectFilesPath(userId, projectId primitive.ObjectID) string {
+	return path.Join(userId.Hex(), "projects", projectId.Hex())
+}
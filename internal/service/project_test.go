@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryProjects хранит проекты в памяти и повторяет то, как их меняет ProjectsRepo.
+// Методы, которые тестам не нужны, не реализованы: их вызов паникует на встроенном nil интерфейсе.
+type memoryProjects struct {
+	repository.Projects
+	projects map[primitive.ObjectID]*domain.SelfProject
+}
+
+func newMemoryProjects(projects ...domain.SelfProject) *memoryProjects {
+	r := &memoryProjects{projects: map[primitive.ObjectID]*domain.SelfProject{}}
+	for i := range projects {
+		r.projects[projects[i].Id] = &projects[i]
+	}
+	return r
+}
+
+func (r *memoryProjects) get(projectId primitive.ObjectID) (*domain.SelfProject, error) {
+	project, ok := r.projects[projectId]
+	if !ok {
+		return nil, domain.ErrProjectNotFound
+	}
+	res := *project
+	if project.Draft != nil {
+		draft := *project.Draft
+		res.Draft = &draft
+	}
+	return &res, nil
+}
+
+func (r *memoryProjects) GetSelfProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error) {
+	return r.get(projectId)
+}
+
+func (r *memoryProjects) GetMemberProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error) {
+	return r.get(projectId)
+}
+
+func (r *memoryProjects) UpdateProject(ctx context.Context, projectId primitive.ObjectID, update domain.SelfProject) error {
+	project := r.projects[projectId]
+	if update.Name != "" {
+		project.Name = update.Name
+	}
+	if update.Description != "" {
+		project.Description = update.Description
+	}
+	if update.Tags != nil {
+		project.Tags = update.Tags
+	}
+	if update.Files != nil {
+		project.Files = update.Files
+	}
+	if update.Access != "" {
+		project.Access = update.Access
+	}
+	if update.Order != 0 {
+		project.Order = update.Order
+	}
+	project.UpdatedAt = update.UpdatedAt
+	return nil
+}
+
+func (r *memoryProjects) SaveDraft(ctx context.Context, projectId primitive.ObjectID, draft domain.ProjectDraft) error {
+	r.projects[projectId].Draft = &draft
+	return nil
+}
+
+func (r *memoryProjects) PublishProject(ctx context.Context, projectId primitive.ObjectID, draft *domain.ProjectDraft, publishedAt time.Time) error {
+	project := r.projects[projectId]
+	if draft != nil {
+		project.Name, project.Description, project.Tags, project.Files = draft.Name, draft.Description, draft.Tags, draft.Files
+	}
+	project.Published, project.PublishedAt, project.Draft, project.PublishAt = true, publishedAt, nil, nil
+	return nil
+}
+
+func (r *memoryProjects) UnpublishProject(ctx context.Context, projectId primitive.ObjectID) error {
+	project := r.projects[projectId]
+	project.Published, project.PublishAt = false, nil
+	return nil
+}
+
+type nopRevisions struct{ repository.Revisions }
+
+func (nopRevisions) Create(ctx context.Context, revision domain.Revision) (primitive.ObjectID, error) {
+	return primitive.NewObjectID(), nil
+}
+
+func (nopRevisions) Prune(ctx context.Context, projectId primitive.ObjectID, keep int, olderThan time.Time) error {
+	return nil
+}
+
+type nopSearch struct{}
+
+func (nopSearch) IndexProject(ctx context.Context, project domain.SelfProject) error { return nil }
+
+func (nopSearch) RemoveProject(ctx context.Context, projectId primitive.ObjectID) error { return nil }
+
+func (nopSearch) IndexUser(ctx context.Context, user domain.User) error { return nil }
+
+func (nopSearch) RemoveUser(ctx context.Context, userId primitive.ObjectID) error { return nil }
+
+func (nopSearch) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResults, error) {
+	return &domain.SearchResults{}, nil
+}
+
+func newTestProjectService(project domain.SelfProject) (*ProjectService, *memoryProjects) {
+	repo := newMemoryProjects(project)
+	return NewProjectService(repo, nil, nopRevisions{}, nopSearch{}, nil, RevisionRetention{}), repo
+}
+
+func TestUpdateAfterUnpublishKeepsDraft(t *testing.T) {
+	ctx := context.Background()
+	owner := primitive.NewObjectID()
+	project := domain.SelfProject{Id: primitive.NewObjectID(), UserId: owner, Name: "v1", Access: domain.All}
+	s, repo := newTestProjectService(project)
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"publish", func() error { return s.PublishProject(ctx, project.Id, owner) }},
+		{"edit published", func() error { return s.UpdateProject(ctx, project.Id, owner, domain.SelfProject{Name: "v2"}) }},
+		{"unpublish", func() error { return s.UnpublishProject(ctx, project.Id, owner) }},
+		{"edit unpublished", func() error { return s.UpdateProject(ctx, project.Id, owner, domain.SelfProject{Name: "v3"}) }},
+		{"publish again", func() error { return s.PublishProject(ctx, project.Id, owner) }},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+	}
+
+	got := repo.projects[project.Id]
+	if got.Name != "v3" {
+		t.Errorf("name after publish = %q, want v3: the newest edit was reverted", got.Name)
+	}
+	if got.Draft != nil {
+		t.Errorf("draft after publish = %+v, want nil", got.Draft)
+	}
+}
+
+func TestPublishWithoutChanges(t *testing.T) {
+	ctx := context.Background()
+	owner := primitive.NewObjectID()
+	publishedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	project := domain.SelfProject{Id: primitive.NewObjectID(), UserId: owner, Name: "v1", Published: true, PublishedAt: publishedAt}
+	s, repo := newTestProjectService(project)
+
+	if err := s.PublishProject(ctx, project.Id, owner); !errors.Is(err, domain.ErrNothingToPublish) {
+		t.Errorf("publish without draft: err = %v, want %v", err, domain.ErrNothingToPublish)
+	}
+	if got := repo.projects[project.Id].PublishedAt; !got.Equal(publishedAt) {
+		t.Errorf("publishedAt = %s, want %s", got, publishedAt)
+	}
+}
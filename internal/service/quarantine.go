@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"path"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuarantineService показывает администратору файлы, в которых антивирус нашел угрозу, и удаляет их
+type QuarantineService struct {
+	repo    repository.Quarantine
+	storage storage.Provider
+}
+
+func NewQuarantineService(repo repository.Quarantine, storage storage.Provider) *QuarantineService {
+	return &QuarantineService{
+		repo:    repo,
+		storage: storage,
+	}
+}
+
+func (s *QuarantineService) GetAll(ctx context.Context, params pagination.Params) ([]domain.QuarantinedFile, *pagination.Page, error) {
+	return s.repo.GetAll(ctx, params)
+}
+
+// Remove удаляет файл из хранилища и запись о нем. После этого пользователь снова может загрузить такой же файл,
+// и он будет проверен заново.
+func (s *QuarantineService) Remove(ctx context.Context, id primitive.ObjectID) error {
+	file, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if file.Key != "" {
+		dir, name := path.Split(file.Key)
+		if err := s.storage.Remove(ctx, path.Clean(dir), name); err != nil {
+			return err
+		}
+	}
+	return s.repo.Remove(ctx, id)
+}
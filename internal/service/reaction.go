@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReactionService ставит и снимает лайки и закладки на чужих публичных проектах. Повторная отметка
+// и снятие несуществующей ничего не меняют, счетчик проекта меняется, только если отметка действительно
+// добавилась или удалилась. Если счетчик обновить не удалось, отметка откатывается, чтобы повтор запроса
+// снова изменил и ее, и счетчик.
+type ReactionService struct {
+	reactions repository.Reactions
+	projects  repository.Projects
+	files     File
+}
+
+func NewReactionService(reactions repository.Reactions, projects repository.Projects, files File) *ReactionService {
+	return &ReactionService{
+		reactions: reactions,
+		projects:  projects,
+		files:     files,
+	}
+}
+
+// React ставит отметку kind на проект, который можно открыть по ссылке
+func (s *ReactionService) React(ctx context.Context, projectId, userId primitive.ObjectID, kind domain.ReactionKind) error {
+	project, err := s.projects.GetProjectById(ctx, projectId)
+	if err != nil {
+		return err
+	}
+	if !project.Published || project.Access == domain.Nobody {
+		return domain.ErrProjectNotFound
+	}
+	if project.UserId == userId {
+		return domain.ErrOwnProjectReaction
+	}
+
+	added, err := s.reactions.Add(ctx, domain.Reaction{
+		ProjectId: projectId,
+		UserId:    userId,
+		Kind:      kind,
+		CreatedAt: time.Now(),
+	})
+	if err != nil || !added {
+		return err
+	}
+	if err := s.projects.IncReactions(ctx, projectId, kind, 1); err != nil {
+		if _, rbErr := s.reactions.Remove(ctx, projectId, userId, kind); rbErr != nil {
+			logger.Errorf("failed to roll back %s of project %s: %s", kind, projectId.Hex(), rbErr.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// Unreact снимает отметку kind. Снять отметку можно и с проекта, который уже скрыт.
+func (s *ReactionService) Unreact(ctx context.Context, projectId, userId primitive.ObjectID, kind domain.ReactionKind) error {
+	removed, err := s.reactions.Remove(ctx, projectId, userId, kind)
+	if err != nil || removed == nil {
+		return err
+	}
+	if err := s.projects.IncReactions(ctx, projectId, kind, -1); err != nil {
+		// возвращается та же отметка, с прежним id и временем, чтобы закладка осталась на своем месте в списке
+		if _, rbErr := s.reactions.Add(ctx, *removed); rbErr != nil {
+			logger.Errorf("failed to roll back removed %s of project %s: %s", kind, projectId.Hex(), rbErr.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *ReactionService) GetReactions(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.ProjectReactions, error) {
+	reactions, err := s.reactions.GetByProjectUser(ctx, projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	var res domain.ProjectReactions
+	for _, r := range reactions {
+		switch r.Kind {
+		case domain.ReactionLike:
+			res.Liked = true
+		case domain.ReactionBookmark:
+			res.Bookmarked = true
+		}
+	}
+	return &res, nil
+}
+
+// GetBookmarks возвращает проекты из закладок пользователя, последние добавленные - первыми.
+// Проекты, которые с тех пор скрыли или удалили, пропускаются, поэтому страница может быть короче лимита.
+func (s *ReactionService) GetBookmarks(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.ProjectMin, *pagination.Page, error) {
+	bookmarks, page, err := s.reactions.GetByUser(ctx, userId, domain.ReactionBookmark, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		ids = append(ids, b.ProjectId)
+	}
+	found, err := s.projects.GetPublicByIds(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	byId := make(map[primitive.ObjectID]domain.ProjectMin, len(found))
+	for _, p := range found {
+		byId[p.Id] = p
+	}
+
+	projects := []domain.ProjectMin{}
+	for _, id := range ids {
+		project, ok := byId[id]
+		if !ok {
+			continue
+		}
+		cover := coverFile(project.Files)
+		if cover != nil && filesPrivate(project.Access) {
+			signed, err := s.files.Sign(ctx, []domain.File{*cover})
+			if err != nil {
+				return nil, nil, err
+			}
+			cover = &signed[0]
+		}
+		project.Cover = cover
+		projects = append(projects, project)
+	}
+	return projects, page, nil
+}
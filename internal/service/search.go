@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+)
+
+// maxSearchOffset ограничивает глубину листания результатов: каждая коллекция выбирает и сортирует
+// offset+limit документов, поэтому большой offset заставил бы Mongo перебрать весь индекс
+const maxSearchOffset = 1000
+
+type SearchService struct {
+	search   repository.Search
+	users    repository.Users
+	projects repository.Projects
+}
+
+func NewSearchService(search repository.Search, users repository.Users, projects repository.Projects) *SearchService {
+	return &SearchService{
+		search:   search,
+		users:    users,
+		projects: projects,
+	}
+}
+
+func (s *SearchService) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResults, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, domain.ErrEmptySearchQuery
+	}
+	if query.Limit <= 0 {
+		query.Limit = pagination.DefaultLimit
+	}
+	if query.Limit > pagination.MaxLimit {
+		query.Limit = pagination.MaxLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+	if query.Offset > maxSearchOffset {
+		return nil, domain.ErrSearchOffsetTooLarge
+	}
+	return s.search.Search(ctx, query)
+}
+
+// Reindex заново заполняет поисковый индекс всеми пользователями и проектами
+func (s *SearchService) Reindex(ctx context.Context) error {
+	params := pagination.Params{Limit: pagination.MaxLimit}
+	for {
+		users, page, err := s.users.GetAllUsers(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := s.search.IndexUser(ctx, user); err != nil {
+				return err
+			}
+		}
+		if page.Next == "" {
+			break
+		}
+		params.Cursor = page.Next
+	}
+
+	params = pagination.Params{Limit: pagination.MaxLimit}
+	for {
+		projects, page, err := s.projects.GetAllProjects(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, project := range projects {
+			if err := s.search.IndexProject(ctx, project); err != nil {
+				return err
+			}
+		}
+		if page.Next == "" {
+			break
+		}
+		params.Cursor = page.Next
+	}
+	return nil
+}
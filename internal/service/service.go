@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"time"
@@ -9,7 +10,11 @@ import (
 	"github.com/Alexander272/my-portfolio/internal/domain"
 	"github.com/Alexander272/my-portfolio/internal/repository"
 	"github.com/Alexander272/my-portfolio/pkg/auth"
+	"github.com/Alexander272/my-portfolio/pkg/email"
+	"github.com/Alexander272/my-portfolio/pkg/geoip"
 	"github.com/Alexander272/my-portfolio/pkg/hash"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"github.com/Alexander272/my-portfolio/pkg/scanner"
 	"github.com/Alexander272/my-portfolio/pkg/storage"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -39,26 +44,174 @@ type Auth interface {
 
 type User interface {
 	SignUp(ctx context.Context, input SignUpInput) error
-	GetById(ctx context.Context, userId primitive.ObjectID) (domain.User, error)
+	GetById(ctx context.Context, userId primitive.ObjectID) (domain.PublicUser, error)
 	UpdateById(ctx context.Context, userId primitive.ObjectID, user domain.UserUpdate) error
 	RemoveById(ctx context.Context, userId primitive.ObjectID) error
-	GetAllUsers(ctx context.Context) ([]domain.User, error)
+	GetAllUsers(ctx context.Context, params pagination.Params) ([]domain.PublicUser, *pagination.Page, error)
+}
+
+type Project interface {
+	GetProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.ProjectMin, *pagination.Page, error)
+	GetProjectById(ctx context.Context, projectId primitive.ObjectID) (*domain.Project, error)
+	GetSelfProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
+	GetDrafts(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
+	GetSelfProjectById(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.SelfProject, error)
+	CreateProject(ctx context.Context, project domain.ProjectInput) (primitive.ObjectID, error)
+	UpdateProject(ctx context.Context, projectId, userId primitive.ObjectID, project domain.SelfProject) error
+	RemoveProject(ctx context.Context, projectId, userId primitive.ObjectID) error
+	PublishProject(ctx context.Context, projectId, userId primitive.ObjectID) error
+	UnpublishProject(ctx context.Context, projectId, userId primitive.ObjectID) error
+	SchedulePublish(ctx context.Context, projectId, userId primitive.ObjectID, publishAt *time.Time) error
+	PublishScheduled(ctx context.Context) error
+
+	GetRevisions(ctx context.Context, projectId, userId primitive.ObjectID, params pagination.Params) ([]domain.Revision, *pagination.Page, error)
+	GetRevision(ctx context.Context, projectId, userId, revisionId primitive.ObjectID) (*domain.Revision, error)
+	DiffRevisions(ctx context.Context, projectId, userId, fromId, toId primitive.ObjectID) (*domain.RevisionDiff, error)
+	RestoreRevision(ctx context.Context, projectId, userId, revisionId primitive.ObjectID) error
+
+	AddFile(ctx context.Context, projectId, userId primitive.ObjectID, file multipart.File, header *multipart.FileHeader,
+		input domain.GalleryFileInput) (*domain.File, error)
+	RemoveFile(ctx context.Context, projectId, userId primitive.ObjectID, name string) error
+	ReorderFiles(ctx context.Context, projectId, userId primitive.ObjectID, names []string) error
+	UpdateFile(ctx context.Context, projectId, userId primitive.ObjectID, name string, input domain.GalleryFileInput) (*domain.File, error)
+	SetCover(ctx context.Context, projectId, userId primitive.ObjectID, name string) error
+}
+
+type Collaborator interface {
+	GetShared(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
+	GetCollaborators(ctx context.Context, projectId, userId primitive.ObjectID) ([]domain.ProjectAuthor, error)
+	Invite(ctx context.Context, projectId, userId primitive.ObjectID, input domain.InvitationInput) (*domain.Invitation, error)
+	GetProjectInvitations(ctx context.Context, projectId, userId primitive.ObjectID) ([]domain.Invitation, error)
+	RevokeInvitation(ctx context.Context, projectId, userId, invitationId primitive.ObjectID) error
+	GetInvitations(ctx context.Context, userId primitive.ObjectID) ([]domain.Invitation, error)
+	AcceptInvitation(ctx context.Context, userId, invitationId primitive.ObjectID) error
+	DeclineInvitation(ctx context.Context, userId, invitationId primitive.ObjectID) error
+	SetRole(ctx context.Context, projectId, userId, collaboratorId primitive.ObjectID, role domain.CollaboratorRole) error
+	RemoveCollaborator(ctx context.Context, projectId, userId, collaboratorId primitive.ObjectID) error
+}
+
+type Analytics interface {
+	RecordView(ctx context.Context, view domain.View) error
+	GetProjectStats(ctx context.Context, projectId, userId primitive.ObjectID, from, to time.Time) (*domain.ViewStats, error)
+	GetProfileStats(ctx context.Context, userId primitive.ObjectID, from, to time.Time) (*domain.ViewStats, error)
+}
+
+type Reaction interface {
+	React(ctx context.Context, projectId, userId primitive.ObjectID, kind domain.ReactionKind) error
+	Unreact(ctx context.Context, projectId, userId primitive.ObjectID, kind domain.ReactionKind) error
+	GetReactions(ctx context.Context, projectId, userId primitive.ObjectID) (*domain.ProjectReactions, error)
+	GetBookmarks(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.ProjectMin, *pagination.Page, error)
+}
+
+type Comment interface {
+	GetThreads(ctx context.Context, projectId primitive.ObjectID, params pagination.Params) ([]domain.Comment, *pagination.Page, error)
+	GetReplies(ctx context.Context, projectId, threadId primitive.ObjectID, params pagination.Params) ([]domain.Comment, *pagination.Page, error)
+	Create(ctx context.Context, projectId, userId primitive.ObjectID, input domain.CommentInput) (*domain.Comment, error)
+	Remove(ctx context.Context, projectId, userId, commentId primitive.ObjectID) error
+	GetForModeration(ctx context.Context, projectId, userId primitive.ObjectID, status domain.CommentStatus,
+		params pagination.Params) ([]domain.Comment, *pagination.Page, error)
+	Approve(ctx context.Context, projectId, userId, commentId primitive.ObjectID) error
+	Hide(ctx context.Context, projectId, userId, commentId primitive.ObjectID) error
+	SetMode(ctx context.Context, projectId, userId primitive.ObjectID, mode domain.CommentsMode) error
+}
+
+type Notification interface {
+	GetNotifications(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.Notification, *pagination.Page, error)
+	GetUnread(ctx context.Context, userId primitive.ObjectID) (*domain.UnreadNotifications, error)
+	MarkRead(ctx context.Context, userId, id primitive.ObjectID) error
+	MarkAllRead(ctx context.Context, userId primitive.ObjectID) error
+}
+
+type Contact interface {
+	Send(ctx context.Context, userId primitive.ObjectID, input domain.ContactInput, ip, userAgent string) error
+	GetMessages(ctx context.Context, userId primitive.ObjectID, folder domain.MessageFolder,
+		params pagination.Params) ([]domain.ContactMessage, *pagination.Page, error)
+	GetUnread(ctx context.Context, userId primitive.ObjectID) (*domain.UnreadMessages, error)
+	MarkRead(ctx context.Context, userId, id primitive.ObjectID, read bool) error
+	Move(ctx context.Context, userId, id primitive.ObjectID, folder domain.MessageFolder) error
+	Remove(ctx context.Context, userId, id primitive.ObjectID) error
+}
+
+type Uploads interface {
+	Create(ctx context.Context, projectId, userId primitive.ObjectID, input domain.UploadSessionInput) (*domain.UploadSession, error)
+	Get(ctx context.Context, projectId, userId primitive.ObjectID, id string) (*domain.UploadSession, error)
+	WriteChunk(ctx context.Context, projectId, userId primitive.ObjectID, id string, offset int64, body io.Reader) (*domain.UploadSession, error)
+	Complete(ctx context.Context, projectId, userId primitive.ObjectID, id string) (*domain.File, error)
+	Cancel(ctx context.Context, projectId, userId primitive.ObjectID, id string) error
+	PurgeExpired(ctx context.Context) error
+}
+
+type Trash interface {
+	GetProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error)
+	RestoreProject(ctx context.Context, projectId, userId primitive.ObjectID) error
+	GetUsers(ctx context.Context, params pagination.Params) ([]domain.User, *pagination.Page, error)
+	RestoreUser(ctx context.Context, userId primitive.ObjectID) error
+	Purge(ctx context.Context) error
+}
+
+type Search interface {
+	Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResults, error)
+	Reindex(ctx context.Context) error
 }
 
 type File interface {
-	Upload(ctx context.Context, file multipart.File, header *multipart.FileHeader, path, filename string) (*domain.File, error)
-	Remove(ctx context.Context, path, filename string) error
+	Upload(ctx context.Context, userId primitive.ObjectID, file multipart.File, header *multipart.FileHeader, dir, filename string,
+		private bool) (*domain.File, error)
+	UploadAttachment(ctx context.Context, userId primitive.ObjectID, file multipart.File, header *multipart.FileHeader, dir, filename string,
+		private bool) (*domain.File, error)
+	UploadMedia(ctx context.Context, userId primitive.ObjectID, file io.ReaderAt, size int64, origName, dir, filename string,
+		private bool) (*domain.File, error)
+	Remove(ctx context.Context, dir, filename string) error
+	Usage(ctx context.Context, userId primitive.ObjectID) (*domain.StorageUsage, error)
+	SetPrivate(ctx context.Context, dir string, files []domain.File, private bool) error
+	Sign(ctx context.Context, files []domain.File) ([]domain.File, error)
+}
+
+type FilesGC interface {
+	Report(ctx context.Context) (*domain.OrphanReport, error)
+	Collect(ctx context.Context) error
+}
+
+type Quarantine interface {
+	GetAll(ctx context.Context, params pagination.Params) ([]domain.QuarantinedFile, *pagination.Page, error)
+	Remove(ctx context.Context, id primitive.ObjectID) error
 }
 
 type Services struct {
 	Auth
 	User
 	File
+	Project
+	Collaborator
+	Analytics
+	Reaction
+	Comment
+	Notification
+	Contact
+	Uploads
+	Search
+	Trash
+	FilesGC
+	Quarantine
 }
 
 type Deps struct {
 	Repos                  *repository.Repositories
+	SearchEngine           repository.Search
+	RevisionRetention      RevisionRetention
+	TrashRetention         time.Duration
 	StorageProvider        storage.Provider
+	Scanner                scanner.Scanner
+	GeoIP                  geoip.Locator
+	Analytics              AnalyticsOptions
+	Comments               CommentOptions
+	Contact                ContactOptions
+	Mailer                 email.Sender
+	SignedUrlTTL           time.Duration
+	Images                 ImageOptions
+	UploadLimits           UploadLimits
+	ResumableUploads       ResumableUploads
+	FilesGC                FilesGCOptions
 	Hasher                 hash.PasswordHasher
 	TokenManager           auth.TokenManager
 	AccessTokenTTL         time.Duration
@@ -68,9 +221,29 @@ type Deps struct {
 }
 
 func NewServices(deps Deps) *Services {
+	files := NewFileService(deps.StorageProvider, deps.Repos.Uploads, deps.Repos.Files, deps.Repos.Quarantine, deps.Scanner,
+		deps.SignedUrlTTL, deps.Images, deps.UploadLimits)
+	projects := NewProjectService(deps.Repos.Projects, deps.Repos.Users, deps.Repos.Revisions, deps.SearchEngine, files, deps.RevisionRetention)
+	notifications := NewNotificationService(deps.Repos.Notifications)
 	return &Services{
-		Auth: NewAuthService(deps.Repos.Users, deps.Repos.Auth, deps.TokenManager, deps.Hasher, deps.AccessTokenTTL, deps.RefreshTokenTTL, deps.Domain),
-		User: NewUserService(deps.Repos.Users, deps.TokenManager, deps.Hasher),
-		File: NewFileService(deps.StorageProvider),
+		Auth:         NewAuthService(deps.Repos.Users, deps.Repos.Auth, deps.TokenManager, deps.Hasher, deps.AccessTokenTTL, deps.RefreshTokenTTL, deps.Domain),
+		User:         NewUserService(deps.Repos.Users, deps.Repos.Projects, deps.SearchEngine, deps.TokenManager, deps.Hasher),
+		File:         files,
+		Project:      projects,
+		Collaborator: NewCollaboratorService(projects, deps.Repos.Projects, deps.Repos.Users, deps.Repos.Invitations),
+		Analytics:    NewAnalyticsService(deps.Repos.Views, deps.Repos.Visitors, projects, deps.GeoIP, deps.Analytics),
+		Reaction:     NewReactionService(deps.Repos.Reactions, deps.Repos.Projects, files),
+		Comment: NewCommentService(deps.Repos.Comments, deps.Repos.Projects, deps.Repos.Users, deps.Repos.RateLimits,
+			notifications, deps.Comments),
+		Notification: notifications,
+		Contact:      NewContactService(deps.Repos.Messages, deps.Repos.Users, deps.Repos.RateLimits, deps.Mailer, deps.Contact),
+		Uploads:      NewUploadService(deps.Repos.UploadSessions, projects, files, deps.ResumableUploads),
+		Search:       NewSearchService(deps.SearchEngine, deps.Repos.Users, deps.Repos.Projects),
+		Trash: NewTrashService(deps.Repos.Users, deps.Repos.Projects, deps.Repos.Revisions, deps.Repos.Invitations,
+			deps.Repos.Views, deps.Repos.Reactions, deps.Repos.Comments, deps.Repos.Notifications, deps.Repos.Messages, deps.SearchEngine, files,
+			deps.TrashRetention),
+		FilesGC: NewFilesGCService(deps.Repos.Users, deps.Repos.Projects, deps.Repos.Revisions, deps.Repos.Files,
+			deps.StorageProvider, files, deps.FilesGC),
+		Quarantine: NewQuarantineService(deps.Repos.Quarantine, deps.StorageProvider),
 	}
 }
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TrashService работает с удаленными проектами и пользователями: пока не истек срок хранения,
+// их можно восстановить, после этого записи и файлы удаляются окончательно.
+type TrashService struct {
+	users         repository.Users
+	projects      repository.Projects
+	revisions     repository.Revisions
+	invitations   repository.Invitations
+	views         repository.Views
+	reactions     repository.Reactions
+	comments      repository.Comments
+	notifications repository.Notifications
+	messages      repository.Messages
+	search        repository.Search
+	files         File
+	retention     time.Duration
+}
+
+func NewTrashService(users repository.Users, projects repository.Projects, revisions repository.Revisions, invitations repository.Invitations,
+	views repository.Views, reactions repository.Reactions, comments repository.Comments, notifications repository.Notifications,
+	messages repository.Messages, search repository.Search, files File, retention time.Duration) *TrashService {
+	return &TrashService{
+		users:         users,
+		projects:      projects,
+		revisions:     revisions,
+		invitations:   invitations,
+		views:         views,
+		reactions:     reactions,
+		comments:      comments,
+		notifications: notifications,
+		messages:      messages,
+		search:        search,
+		files:         files,
+		retention:     retention,
+	}
+}
+
+func (s *TrashService) GetProjects(ctx context.Context, userId primitive.ObjectID, params pagination.Params) ([]domain.SelfProjectMin, *pagination.Page, error) {
+	projects, page, err := s.projects.GetDeleted(ctx, userId, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projects, page, setSelfCovers(ctx, s.files, projects)
+}
+
+func (s *TrashService) RestoreProject(ctx context.Context, projectId, userId primitive.ObjectID) error {
+	project, err := s.projects.GetDeletedById(ctx, projectId, userId)
+	if err != nil {
+		return err
+	}
+	if s.expired(project.DeletedAt) {
+		return domain.ErrTrashExpired
+	}
+
+	if err := s.projects.RestoreDeleted(ctx, projectId); err != nil {
+		return err
+	}
+	s.indexProject(ctx, projectId, userId)
+	return nil
+}
+
+func (s *TrashService) GetUsers(ctx context.Context, params pagination.Params) ([]domain.User, *pagination.Page, error) {
+	return s.users.GetDeleted(ctx, params)
+}
+
+func (s *TrashService) RestoreUser(ctx context.Context, userId primitive.ObjectID) error {
+	user, err := s.users.GetDeletedById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if s.expired(user.DeletedAt) {
+		return domain.ErrTrashExpired
+	}
+
+	if err := s.users.RestoreById(ctx, userId); err != nil {
+		return err
+	}
+	if err := s.projects.RestoreByUser(ctx, userId); err != nil {
+		return err
+	}
+
+	user.DeletedAt = nil
+	if err := s.search.IndexUser(ctx, user); err != nil {
+		logger.Errorf("failed to index user %s: %s", userId.Hex(), err.Error())
+	}
+	ids, err := s.projects.GetIdsByUser(ctx, userId)
+	if err != nil {
+		logger.Errorf("failed to get projects of user %s: %s", userId.Hex(), err.Error())
+	}
+	for _, id := range ids {
+		s.indexProject(ctx, id, userId)
+	}
+	return nil
+}
+
+// Purge окончательно удаляет записи, срок хранения которых в корзине истек, а затем их файлы.
+// Если файлы удалить не удалось, они остаются в хранилище без ссылок на них.
+func (s *TrashService) Purge(ctx context.Context) error {
+	before := time.Now().Add(-s.retention)
+
+	projects, err := s.projects.GetDeletedBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, project := range projects {
+		if err := s.revisions.RemoveByProject(ctx, project.Id); err != nil {
+			logger.Errorf("failed to remove revisions of project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.invitations.RemoveByProject(ctx, project.Id); err != nil {
+			logger.Errorf("failed to remove invitations of project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.views.RemoveByTarget(ctx, domain.ViewProject, project.Id); err != nil {
+			logger.Errorf("failed to remove views of project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.reactions.RemoveByProject(ctx, project.Id); err != nil {
+			logger.Errorf("failed to remove reactions of project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.comments.RemoveByProject(ctx, project.Id); err != nil {
+			logger.Errorf("failed to remove comments of project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.notifications.RemoveByProject(ctx, project.Id); err != nil {
+			logger.Errorf("failed to remove notifications of project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.projects.PurgeProject(ctx, project.Id); err != nil {
+			logger.Errorf("failed to purge project %s: %s", project.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.files.Remove(ctx, projectFilesPath(project.UserId, project.Id)+"/", ""); err != nil {
+			logger.Errorf("failed to remove files of project %s: %s", project.Id.Hex(), err.Error())
+		}
+		logger.Infof("project %s purged", project.Id.Hex())
+	}
+
+	users, err := s.users.GetDeletedBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		ids, err := s.projects.GetIdsByUser(ctx, user.Id)
+		if err != nil {
+			logger.Errorf("failed to get projects of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		for _, id := range ids {
+			if err := s.revisions.RemoveByProject(ctx, id); err != nil {
+				logger.Errorf("failed to remove revisions of project %s: %s", id.Hex(), err.Error())
+			}
+			if err := s.invitations.RemoveByProject(ctx, id); err != nil {
+				logger.Errorf("failed to remove invitations of project %s: %s", id.Hex(), err.Error())
+			}
+			if err := s.reactions.RemoveByProject(ctx, id); err != nil {
+				logger.Errorf("failed to remove reactions of project %s: %s", id.Hex(), err.Error())
+			}
+			if err := s.comments.RemoveByProject(ctx, id); err != nil {
+				logger.Errorf("failed to remove comments of project %s: %s", id.Hex(), err.Error())
+			}
+		}
+		if err := s.removeReactions(ctx, user.Id); err != nil {
+			logger.Errorf("failed to remove reactions of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.removeComments(ctx, user.Id); err != nil {
+			logger.Errorf("failed to remove comments of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.notifications.RemoveByUser(ctx, user.Id); err != nil {
+			logger.Errorf("failed to remove notifications of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.messages.RemoveByUser(ctx, user.Id); err != nil {
+			logger.Errorf("failed to remove messages of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		// из чужих проектов пользователь уходит вместе со своими приглашениями
+		if err := s.invitations.RemoveByUser(ctx, user.Id); err != nil {
+			logger.Errorf("failed to remove invitations of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.projects.RemoveCollaboratorEverywhere(ctx, user.Id); err != nil {
+			logger.Errorf("failed to remove user %s from collaborators: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		// статистика профиля и проектов пользователя
+		if err := s.views.RemoveByOwner(ctx, user.Id); err != nil {
+			logger.Errorf("failed to remove views of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.projects.PurgeByUser(ctx, user.Id); err != nil {
+			logger.Errorf("failed to purge projects of user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.users.PurgeById(ctx, user.Id); err != nil {
+			logger.Errorf("failed to purge user %s: %s", user.Id.Hex(), err.Error())
+			continue
+		}
+		if err := s.files.Remove(ctx, user.Id.Hex()+"/", ""); err != nil {
+			logger.Errorf("failed to remove files of user %s: %s", user.Id.Hex(), err.Error())
+		}
+		logger.Infof("user %s purged", user.Id.Hex())
+	}
+	return nil
+}
+
+// removeReactions снимает отметки пользователя с чужих проектов вместе с их счетчиками
+func (s *TrashService) removeReactions(ctx context.Context, userId primitive.ObjectID) error {
+	reactions, err := s.reactions.GetAllByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, r := range reactions {
+		if err := s.reactions.RemoveById(ctx, r.Id); err != nil {
+			return err
+		}
+		if err := s.projects.IncReactions(ctx, r.ProjectId, r.Kind, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeComments удаляет комментарии пользователя в чужих проектах. Комментарий верхнего уровня
+// удаляется вместе с веткой, как если бы его удалил сам автор.
+func (s *TrashService) removeComments(ctx context.Context, userId primitive.ObjectID) error {
+	comments, err := s.comments.GetAllByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		// ответ мог быть удален раньше вместе со своей веткой
+		if err := removeComment(ctx, s.comments, c.ProjectId, c.Id); err != nil && !errors.Is(err, domain.ErrCommentNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TrashService) expired(deletedAt *time.Time) bool {
+	return deletedAt == nil || time.Since(*deletedAt) > s.retention
+}
+
+func (s *TrashService) indexProject(ctx context.Context, projectId, userId primitive.ObjectID) {
+	project, err := s.projects.GetSelfProjectById(ctx, projectId, userId)
+	if errors.Is(err, domain.ErrProjectNotFound) {
+		// проект остался в корзине, в индекс он не возвращается
+		return
+	}
+	if err == nil {
+		err = s.search.IndexProject(ctx, *project)
+	}
+	if err != nil {
+		logger.Errorf("failed to index project %s: %s", projectId.Hex(), err.Error())
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/internal/domain"
+	"github.com/Alexander272/my-portfolio/internal/repository"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// запрос с частью ограничен ReadTimeout сервера, блокировка живет дольше на случай медленного диска
+	chunkLockTTL = time.Minute
+	// сборка большого файла отправляет его в хранилище целиком
+	completeLockTTL = 30 * time.Minute
+	// файл без сессии моложе этого возраста может принадлежать сессии, которая еще создается
+	orphanChunkAge = time.Minute
+)
+
+// ResumableUploads настраивает загрузки частями
+type ResumableUploads struct {
+	// Dir - каталог для собираемых файлов. Части пишутся на локальный диск, поэтому при нескольких экземплярах
+	// приложения каталог должен быть общим.
+	Dir string
+	// MaxSize - максимальный размер файла, MaxChunkSize - одной части. Нулевые значения снимают ограничение.
+	// При включенном антивирусе MaxSize не больше его лимита, иначе большой файл отклоняется только после сборки.
+	MaxSize      int64
+	MaxChunkSize int64
+	// TTL - сколько живет загрузка без новых частей
+	TTL time.Duration
+}
+
+// UploadService принимает большие файлы проектов частями. Сессии хранятся в Redis, части дописываются
+// во временный файл, а после получения последней части файл целиком отправляется в хранилище.
+type UploadService struct {
+	sessions repository.UploadSessions
+	projects *ProjectService
+	files    File
+	conf     ResumableUploads
+}
+
+func NewUploadService(sessions repository.UploadSessions, projects *ProjectService, files File, conf ResumableUploads) *UploadService {
+	return &UploadService{
+		sessions: sessions,
+		projects: projects,
+		files:    files,
+		conf:     conf,
+	}
+}
+
+// Create начинает загрузку файла в проект. Размер и квота проверяются сразу, чтобы не принимать
+// гигабайты, которые все равно не удастся сохранить.
+func (s *UploadService) Create(ctx context.Context, projectId, userId primitive.ObjectID, input domain.UploadSessionInput) (*domain.UploadSession, error) {
+	project, err := s.projects.projectFor(ctx, projectId, userId, accessEdit)
+	if err != nil {
+		return nil, err
+	}
+	if s.conf.MaxSize > 0 && input.Size > s.conf.MaxSize {
+		return nil, domain.ErrFileTooLarge
+	}
+	// файлы проекта занимают квоту владельца, даже если их загружает соавтор
+	usage, err := s.files.Usage(ctx, project.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if usage.Quota > 0 && usage.Used+input.Size > usage.Quota {
+		return nil, domain.ErrQuotaExceeded
+	}
+
+	now := time.Now()
+	session := domain.UploadSession{
+		Id:        uuid.New().String(),
+		UserId:    userId,
+		ProjectId: projectId,
+		Name:      filepath.Base(input.Name),
+		Size:      input.Size,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.conf.TTL),
+	}
+
+	if err := os.MkdirAll(s.conf.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.path(session.Id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Create(ctx, session, s.conf.TTL); err != nil {
+		os.Remove(s.path(session.Id))
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Get возвращает состояние загрузки, по Offset клиент узнает, с какой позиции продолжать
+func (s *UploadService) Get(ctx context.Context, projectId, userId primitive.ObjectID, id string) (*domain.UploadSession, error) {
+	session, err := s.sessions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserId != userId || session.ProjectId != projectId {
+		return nil, domain.ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// WriteChunk дописывает часть файла с позиции offset, которая должна совпадать с текущей позицией загрузки.
+// Если запрос оборвался, полученные байты сохраняются, и позиция сдвигается на них. Состояние загрузки
+// возвращается и вместе с ошибкой, если часть была записана хотя бы частично.
+func (s *UploadService) WriteChunk(ctx context.Context, projectId, userId primitive.ObjectID, id string, offset int64,
+	body io.Reader) (*domain.UploadSession, error) {
+	if err := s.lock(ctx, id, chunkLockTTL); err != nil {
+		return nil, err
+	}
+	defer s.unlock(id)
+
+	session, err := s.Get(ctx, projectId, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != session.Offset {
+		return session, domain.ErrUploadOffsetMismatch
+	}
+
+	f, err := os.OpenFile(s.path(session.Id), os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, domain.ErrUploadNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	limit := session.Size - offset
+	if s.conf.MaxChunkSize > 0 && limit > s.conf.MaxChunkSize {
+		limit = s.conf.MaxChunkSize
+	}
+	written, copyErr := io.Copy(f, io.LimitReader(body, limit))
+	if copyErr == nil {
+		// данные сверх лимита не записываются, о них сообщается клиенту
+		if n, _ := body.Read(make([]byte, 1)); n > 0 {
+			copyErr = domain.ErrUploadChunkTooLarge
+		}
+	}
+
+	session.Offset += written
+	session.ExpiresAt = time.Now().Add(s.conf.TTL)
+	// запрос мог оборваться вместе с контекстом, а записанные байты нужно учесть
+	if err := s.sessions.Update(context.Background(), *session, s.conf.TTL); err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return session, copyErr
+	}
+	return session, nil
+}
+
+// Complete сохраняет полностью полученный файл в хранилище и добавляет его в проект. После успешного
+// сохранения загрузка удаляется, при ошибке ее можно завершить повторно или отменить.
+func (s *UploadService) Complete(ctx context.Context, projectId, userId primitive.ObjectID, id string) (*domain.File, error) {
+	if err := s.lock(ctx, id, completeLockTTL); err != nil {
+		return nil, err
+	}
+	defer s.unlock(id)
+
+	session, err := s.Get(ctx, projectId, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Offset < session.Size {
+		return nil, domain.ErrUploadIncomplete
+	}
+
+	f, err := os.Open(s.path(session.Id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, domain.ErrUploadNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	uploaded, err := s.projects.attachFile(ctx, projectId, userId, domain.GalleryFileInput{}, func(ownerId primitive.ObjectID, dir, filename string, private bool) (*domain.File, error) {
+		return s.files.UploadMedia(ctx, ownerId, f, session.Size, session.Name, dir, filename, private)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.remove(ctx, session.Id)
+	return uploaded, nil
+}
+
+// Cancel прерывает загрузку и удаляет полученные части
+func (s *UploadService) Cancel(ctx context.Context, projectId, userId primitive.ObjectID, id string) error {
+	if err := s.lock(ctx, id, chunkLockTTL); err != nil {
+		return err
+	}
+	defer s.unlock(id)
+
+	session, err := s.Get(ctx, projectId, userId, id)
+	if err != nil {
+		return err
+	}
+	s.remove(ctx, session.Id)
+	return nil
+}
+
+// PurgeExpired удаляет временные файлы загрузок, сессии которых истекли
+func (s *UploadService) PurgeExpired(ctx context.Context) error {
+	entries, err := os.ReadDir(s.conf.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < orphanChunkAge {
+			continue
+		}
+		if _, err := s.sessions.Get(ctx, entry.Name()); !errors.Is(err, domain.ErrUploadNotFound) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.conf.Dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *UploadService) lock(ctx context.Context, id string, ttl time.Duration) error {
+	ok, err := s.sessions.Lock(ctx, id, ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrUploadBusy
+	}
+	return nil
+}
+
+func (s *UploadService) unlock(id string) {
+	if err := s.sessions.Unlock(context.Background(), id); err != nil {
+		logger.Errorf("failed to unlock upload %s: %s", id, err.Error())
+	}
+}
+
+func (s *UploadService) remove(ctx context.Context, id string) {
+	if err := s.sessions.Remove(ctx, id); err != nil {
+		logger.Errorf("failed to remove upload session %s: %s", id, err.Error())
+	}
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		logger.Errorf("failed to remove upload file %s: %s", id, err.Error())
+	}
+}
+
+// path возвращает путь временного файла загрузки. id берется только из сессии, созданной сервисом.
+func (s *UploadService) path(id string) string {
+	return filepath.Join(s.conf.Dir, id)
+}
@@ -9,18 +9,25 @@ import (
 	"github.com/Alexander272/my-portfolio/internal/repository"
 	"github.com/Alexander272/my-portfolio/pkg/auth"
 	"github.com/Alexander272/my-portfolio/pkg/hash"
+	"github.com/Alexander272/my-portfolio/pkg/logger"
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserService struct {
 	repo         repository.Users
+	projects     repository.Projects
+	search       repository.Search
 	tokenManager auth.TokenManager
 	hasher       hash.PasswordHasher
 }
 
-func NewUserService(repo repository.Users, tokenManager auth.TokenManager, hasher hash.PasswordHasher) *UserService {
+func NewUserService(repo repository.Users, projects repository.Projects, search repository.Search, tokenManager auth.TokenManager,
+	hasher hash.PasswordHasher) *UserService {
 	return &UserService{
 		repo:         repo,
+		projects:     projects,
+		search:       search,
 		tokenManager: tokenManager,
 		hasher:       hasher,
 	}
@@ -59,21 +66,73 @@ func (s *UserService) SignUp(ctx context.Context, input SignUpInput) error {
 		return err
 	}
 
+	created, err := s.repo.GetByEmail(ctx, input.Email)
+	if err == nil {
+		err = s.search.IndexUser(ctx, created)
+	}
+	if err != nil {
+		logger.Errorf("failed to index user %s: %s", input.Email, err.Error())
+	}
+
 	return nil
 }
 
-func (s *UserService) GetById(ctx context.Context, userId primitive.ObjectID) (domain.User, error) {
-	return s.repo.GetById(ctx, userId)
+func (s *UserService) GetById(ctx context.Context, userId primitive.ObjectID) (domain.PublicUser, error) {
+	user, err := s.repo.GetById(ctx, userId)
+	if err != nil {
+		return domain.PublicUser{}, err
+	}
+	return user.Public(), nil
 }
 
 func (s *UserService) UpdateById(ctx context.Context, userId primitive.ObjectID, input domain.UserUpdate) error {
-	return s.repo.UpdateById(ctx, userId, input)
+	if err := s.repo.UpdateById(ctx, userId, input); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetById(ctx, userId)
+	if err == nil {
+		err = s.search.IndexUser(ctx, user)
+	}
+	if err != nil {
+		logger.Errorf("failed to index user %s: %s", userId.Hex(), err.Error())
+	}
+	return nil
 }
 
+// RemoveById переносит пользователя и все его проекты в корзину. Файлы удаляются только при окончательной очистке.
 func (s *UserService) RemoveById(ctx context.Context, userId primitive.ObjectID) error {
-	return s.repo.RemoveById(ctx, userId)
+	now := time.Now()
+	if err := s.repo.RemoveById(ctx, userId, now); err != nil {
+		return err
+	}
+	if err := s.projects.RemoveByUser(ctx, userId, now); err != nil {
+		return err
+	}
+
+	if err := s.search.RemoveUser(ctx, userId); err != nil {
+		logger.Errorf("failed to remove user %s from search index: %s", userId.Hex(), err.Error())
+	}
+	ids, err := s.projects.GetIdsByUser(ctx, userId)
+	if err != nil {
+		logger.Errorf("failed to get projects of user %s: %s", userId.Hex(), err.Error())
+	}
+	for _, id := range ids {
+		if err := s.search.RemoveProject(ctx, id); err != nil {
+			logger.Errorf("failed to remove project %s from search index: %s", id.Hex(), err.Error())
+		}
+	}
+	return nil
 }
 
-func (s *UserService) GetAllUsers(ctx context.Context) ([]domain.User, error) {
-	return s.repo.GetAllUsers(ctx)
+func (s *UserService) GetAllUsers(ctx context.Context, params pagination.Params) ([]domain.PublicUser, *pagination.Page, error) {
+	users, page, err := s.repo.GetAllUsers(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := make([]domain.PublicUser, 0, len(users))
+	for _, u := range users {
+		res = append(res, u.Public())
+	}
+	return res, page, nil
 }
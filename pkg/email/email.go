@@ -0,0 +1,66 @@
+// Package email отправляет письма
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message - текстовое письмо. ReplyTo - адрес, на который уйдет ответ получателя, если он отличается от From.
+type Message struct {
+	To      string
+	ReplyTo string
+	Subject string
+	Body    string
+}
+
+// Sender отправляет письмо. Отправитель письма задает сам Sender.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// build собирает письмо в формате RFC 5322 с телом в quoted-printable
+func build(from string, msg Message, date time.Time) ([]byte, error) {
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return nil, fmt.Errorf("email: invalid recipient: %w", err)
+	}
+	if msg.ReplyTo != "" {
+		if _, err := mail.ParseAddress(msg.ReplyTo); err != nil {
+			return nil, fmt.Errorf("email: invalid reply-to: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	// перевод строки в значении заголовка позволил бы дописать свои заголовки
+	oneLine := strings.NewReplacer("\r", " ", "\n", " ")
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + oneLine.Replace(value) + "\r\n")
+	}
+	header("From", from)
+	header("To", msg.To)
+	if msg.ReplyTo != "" {
+		header("Reply-To", msg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", oneLine.Replace(msg.Subject)))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	body := strings.ReplaceAll(strings.TrimSuffix(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n")+"\n", "\n", "\r\n")
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig - параметры почтового сервера. ImplicitTLS - соединение сразу по TLS (обычно порт 465),
+// иначе TLS включается командой STARTTLS, если сервер ее поддерживает.
+type SMTPConfig struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	From        string
+	ImplicitTLS bool
+	Timeout     time.Duration
+}
+
+// SMTP отправляет письма через почтовый сервер, на каждое письмо - отдельное соединение
+type SMTP struct {
+	conf SMTPConfig
+	from string
+}
+
+func NewSMTP(conf SMTPConfig) (*SMTP, error) {
+	from, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return nil, fmt.Errorf("email: invalid sender: %w", err)
+	}
+	return &SMTP{conf: conf, from: from.Address}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := build(s.conf.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	to, _ := mail.ParseAddress(msg.To)
+
+	if s.conf.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.conf.Timeout)
+		defer cancel()
+	}
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("email: %w", err)
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.conf.Host)
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok && !s.conf.ImplicitTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: s.conf.Host}); err != nil {
+			return fmt.Errorf("email: %w", err)
+		}
+	}
+	if s.conf.Username != "" {
+		// PlainAuth сам откажется передавать пароль без TLS на чужой сервер
+		if err := client.Auth(smtp.PlainAuth("", s.conf.Username, s.conf.Password, s.conf.Host)); err != nil {
+			return fmt.Errorf("email: %w", err)
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return client.Quit()
+}
+
+func (s *SMTP) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.conf.Host, strconv.Itoa(s.conf.Port))
+	if s.conf.ImplicitTLS {
+		dialer := tls.Dialer{Config: &tls.Config{ServerName: s.conf.Host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
@@ -0,0 +1,138 @@
+package email_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/pkg/email"
+)
+
+// fakeSMTP принимает одно письмо по SMTP без TLS и авторизации и запоминает конверт и данные
+type fakeSMTP struct {
+	listener net.Listener
+	from, to string
+	data     chan string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	f := &fakeSMTP{listener: listener, data: make(chan string, 1)}
+	t.Cleanup(func() { listener.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeSMTP) sender(t *testing.T) *email.SMTP {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(f.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	s, err := email.NewSMTP(email.SMTPConfig{Host: host, Port: p, From: "Portfolio <noreply@example.com>", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewSMTP: %s", err)
+	}
+	return s
+}
+
+func (f *fakeSMTP) serve() {
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); {
+		case cmd == "EHLO" || cmd == "HELO":
+			reply("250 fake")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			f.from = line[len("MAIL FROM:"):]
+			reply("250 ok")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			f.to = line[len("RCPT TO:"):]
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			f.data <- data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	server := newFakeSMTP(t)
+	err := server.sender(t).Send(context.Background(), email.Message{
+		To:      "owner@example.com",
+		ReplyTo: "Visitor <visitor@example.com>",
+		Subject: "Привет\r\nBcc: victim@example.com",
+		Body:    "Первая строка\nвторая строка",
+	})
+	if err != nil {
+		t.Fatalf("Send: %s", err)
+	}
+
+	data := <-server.data
+	if server.from != "<noreply@example.com>" || server.to != "<owner@example.com>" {
+		t.Errorf("envelope = %s -> %s", server.from, server.to)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("read message: %s", err)
+	}
+	if got := msg.Header.Get("Reply-To"); got != "Visitor <visitor@example.com>" {
+		t.Errorf("Reply-To = %q", got)
+	}
+	if got := msg.Header.Get("Bcc"); got != "" {
+		t.Errorf("subject injected Bcc header %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Привет  Bcc: victim@example.com" {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil || string(body) != "Первая строка\r\nвторая строка\r\n" {
+		t.Errorf("body = %q, %v", body, err)
+	}
+}
+
+func TestSMTPInvalidRecipient(t *testing.T) {
+	server := newFakeSMTP(t)
+	if err := server.sender(t).Send(context.Background(), email.Message{To: "not an address", Body: "x"}); err == nil {
+		t.Error("Send to invalid address succeeded")
+	}
+}
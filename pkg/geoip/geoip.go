@@ -0,0 +1,45 @@
+package geoip
+
+import (
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Locator определяет страну по IP адресу
+type Locator interface {
+	// Country возвращает код страны ISO 3166-1 alpha-2 или пустую строку, если страна неизвестна
+	Country(ip net.IP) string
+}
+
+// MaxMind ищет страну в локальном файле базы в формате MaxMind DB (GeoLite2-Country, GeoIP2-Country, DB-IP Lite)
+type MaxMind struct {
+	db *geoip2.Reader
+}
+
+func Open(path string) (*MaxMind, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &MaxMind{db: db}, nil
+}
+
+func (m *MaxMind) Country(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	record, err := m.db.Country(ip)
+	if err != nil {
+		return ""
+	}
+	if record.Country.IsoCode != "" {
+		return record.Country.IsoCode
+	}
+	// у адресов некоторых сетей известна только страна регистрации
+	return record.RegisteredCountry.IsoCode
+}
+
+func (m *MaxMind) Close() error {
+	return m.db.Close()
+}
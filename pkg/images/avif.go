@@ -0,0 +1,303 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+var ErrInvalidAVIF = errors.New("invalid avif")
+
+// maxMetaSize ограничивает бокс meta, который читается в память. В нем только описания элементов,
+// а данные изображения лежат в mdat.
+const maxMetaSize = 1 << 20
+
+// AVIF - сведения об AVIF из бокса meta. Декодера AVIF без cgo нет, поэтому такие изображения хранятся
+// как есть и варианты для них не создаются.
+type AVIF struct {
+	Width, Height int
+	// Stripped - тот же файл с затертыми нулями данными элементов Exif и XMP. Перестроить контейнер без них
+	// сложно: на смещения данных ссылается таблица iloc, поэтому размер файла сохраняется.
+	// Поворот в AVIF задается свойствами irot и imir, а не EXIF, и не меняется.
+	Stripped *Rewrite
+}
+
+// ReadAVIF читает размеры AVIF из свойства ispe контейнера ISOBMFF и находит метаданные.
+// Нулевой maxPixels отключает проверку размеров.
+func ReadAVIF(src io.ReaderAt, size int64, maxPixels int) (*AVIF, error) {
+	ftyp, err := readTopBox(src, size, "ftyp", 64)
+	if err != nil || len(ftyp) < 4 || (string(ftyp[:4]) != "avif" && string(ftyp[:4]) != "avis") {
+		return nil, ErrInvalidAVIF
+	}
+	meta, err := readTopBox(src, size, "meta", maxMetaSize)
+	// meta - полный бокс, перед дочерними боксами идут версия и флаги
+	if err != nil || len(meta) < 4 {
+		return nil, ErrInvalidAVIF
+	}
+	meta = meta[4:]
+
+	iprp, ok := findBox(meta, "iprp")
+	if !ok {
+		return nil, ErrInvalidAVIF
+	}
+	ipco, ok := findBox(iprp, "ipco")
+	if !ok {
+		return nil, ErrInvalidAVIF
+	}
+	// первым ispe обычно описано основное изображение, миниатюры и альфа-канал идут после него
+	ispe, ok := findBox(ipco, "ispe")
+	if !ok || len(ispe) < 12 {
+		return nil, ErrInvalidAVIF
+	}
+	info := &AVIF{
+		Width:  int(binary.BigEndian.Uint32(ispe[4:8])),
+		Height: int(binary.BigEndian.Uint32(ispe[8:12])),
+	}
+	if err := checkPixels(info.Width, info.Height, maxPixels); err != nil {
+		return nil, err
+	}
+
+	extents, err := metadataExtents(meta)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(extents, func(i, j int) bool { return extents[i].offset < extents[j].offset })
+	info.Stripped = newRewrite(src)
+	pos := int64(0)
+	for _, e := range extents {
+		if e.offset > uint64(size) || e.length > uint64(size)-e.offset {
+			return nil, ErrInvalidAVIF
+		}
+		start, end := int64(e.offset), int64(e.offset+e.length)
+		if start < pos {
+			start = pos
+		}
+		if end <= start {
+			continue
+		}
+		info.Stripped.copy(pos, start-pos)
+		info.Stripped.zero(end - start)
+		pos = end
+	}
+	info.Stripped.copy(pos, size-pos)
+	return info, nil
+}
+
+// metadataExtents возвращает расположение данных элементов Exif и XMP
+func metadataExtents(meta []byte) ([]extent, error) {
+	iinf, ok := findBox(meta, "iinf")
+	if !ok {
+		// без таблицы элементов метаданных тоже нет
+		return nil, nil
+	}
+	items, err := metadataItems(iinf)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	iloc, ok := findBox(meta, "iloc")
+	if !ok {
+		return nil, ErrInvalidAVIF
+	}
+	return itemExtents(iloc, items)
+}
+
+// readTopBox читает содержимое бокса верхнего уровня, если оно не больше limit байт
+func readTopBox(src io.ReaderAt, size int64, name string, limit int64) ([]byte, error) {
+	for pos := int64(0); pos+8 <= size; {
+		header, err := readAt(src, pos, 8)
+		if err != nil {
+			return nil, err
+		}
+		boxSize, headerSize := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = size - pos
+		case 1:
+			large, err := readAt(src, pos+8, 8)
+			if err != nil {
+				return nil, err
+			}
+			if v := binary.BigEndian.Uint64(large); v > uint64(size) {
+				return nil, ErrInvalidAVIF
+			}
+			boxSize, headerSize = int64(binary.BigEndian.Uint64(large)), 16
+		}
+		if boxSize < headerSize || boxSize > size-pos {
+			return nil, ErrInvalidAVIF
+		}
+		if string(header[4:8]) == name {
+			if boxSize-headerSize > limit {
+				return nil, ErrInvalidAVIF
+			}
+			return readAt(src, pos+headerSize, int(boxSize-headerSize))
+		}
+		pos += boxSize
+	}
+	return nil, ErrInvalidAVIF
+}
+
+// findBox ищет среди боксов верхнего уровня data бокс с типом name и возвращает его содержимое
+func findBox(data []byte, name string) ([]byte, bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, false
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, false
+		}
+		if string(data[4:8]) == name {
+			return data[header:size], true
+		}
+		data = data[size:]
+	}
+	return nil, false
+}
+
+// metadataItems возвращает идентификаторы элементов Exif и XMP (mime application/rdf+xml) из бокса iinf
+func metadataItems(iinf []byte) (map[uint32]bool, error) {
+	if len(iinf) < 6 {
+		return nil, ErrInvalidAVIF
+	}
+	body := iinf[6:]
+	if iinf[0] > 0 {
+		if len(iinf) < 8 {
+			return nil, ErrInvalidAVIF
+		}
+		body = iinf[8:]
+	}
+
+	items := map[uint32]bool{}
+	for len(body) >= 8 {
+		size := int(binary.BigEndian.Uint32(body))
+		if size < 8 || size > len(body) {
+			return nil, ErrInvalidAVIF
+		}
+		infe := body[8:size]
+		body = body[size:]
+		// до версии 2 в infe нет типа элемента, такие записи в AVIF не используются
+		if len(infe) < 4 || infe[0] < 2 {
+			continue
+		}
+		pos := 4
+		var id uint32
+		if infe[0] == 2 {
+			if len(infe) < pos+2 {
+				return nil, ErrInvalidAVIF
+			}
+			id = uint32(binary.BigEndian.Uint16(infe[pos:]))
+			pos += 2
+		} else {
+			if len(infe) < pos+4 {
+				return nil, ErrInvalidAVIF
+			}
+			id = binary.BigEndian.Uint32(infe[pos:])
+			pos += 4
+		}
+		// item_protection_index и item_type
+		if len(infe) < pos+6 {
+			return nil, ErrInvalidAVIF
+		}
+		itemType := string(infe[pos+2 : pos+6])
+		rest := infe[pos+6:]
+		switch itemType {
+		case "Exif":
+			items[id] = true
+		case "mime":
+			// item_name, затем content_type, обе строки заканчиваются нулем
+			parts := bytes.SplitN(rest, []byte{0}, 3)
+			if len(parts) >= 2 && string(parts[1]) == "application/rdf+xml" {
+				items[id] = true
+			}
+		}
+	}
+	return items, nil
+}
+
+type extent struct {
+	offset, length uint64
+}
+
+// itemExtents возвращает расположение данных элементов из бокса iloc. Данные, которые лежат
+// не в самом файле (construction_method != 0), пропускаются.
+func itemExtents(iloc []byte, items map[uint32]bool) ([]extent, error) {
+	r := &boxReader{data: iloc}
+	version := r.uint(1)
+	r.skip(3)
+	sizes := r.uint(2)
+	offsetSize, lengthSize := int(sizes>>12&0xf), int(sizes>>8&0xf)
+	baseOffsetSize, indexSize := int(sizes>>4&0xf), 0
+	if version == 1 || version == 2 {
+		indexSize = int(sizes & 0xf)
+	}
+	var count uint64
+	if version < 2 {
+		count = r.uint(2)
+	} else {
+		count = r.uint(4)
+	}
+
+	var extents []extent
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		var id uint64
+		if version < 2 {
+			id = r.uint(2)
+		} else {
+			id = r.uint(4)
+		}
+		method := uint64(0)
+		if version == 1 || version == 2 {
+			method = r.uint(2) & 0xf
+		}
+		r.skip(2) // data_reference_index
+		base := r.uint(baseOffsetSize)
+		extentCount := r.uint(2)
+		for j := uint64(0); j < extentCount && r.err == nil; j++ {
+			r.skip(indexSize)
+			offset, length := r.uint(offsetSize), r.uint(lengthSize)
+			if items[uint32(id)] && method == 0 {
+				extents = append(extents, extent{offset: base + offset, length: length})
+			}
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return extents, nil
+}
+
+// boxReader читает целые числа big-endian переменной длины и запоминает первую ошибку
+type boxReader struct {
+	data []byte
+	err  error
+}
+
+func (r *boxReader) uint(size int) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if size > len(r.data) || size > 8 {
+		r.err = ErrInvalidAVIF
+		return 0
+	}
+	var v uint64
+	for _, b := range r.data[:size] {
+		v = v<<8 | uint64(b)
+	}
+	r.data = r.data[size:]
+	return v
+}
+
+func (r *boxReader) skip(size int) {
+	r.uint(size)
+}
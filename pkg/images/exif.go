@@ -0,0 +1,271 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+var errInvalidExif = errors.New("invalid exif")
+
+const (
+	tagOrientation = 0x0112
+	tagArtist      = 0x013b
+	tagCopyright   = 0x8298
+
+	exifShort = 3
+	exifASCII = 2
+)
+
+// Metadata - поля EXIF, которые учитываются при загрузке. Остальные метаданные, включая координаты GPS,
+// в хранилище не попадают.
+type Metadata struct {
+	// Orientation - как повернуть изображение для показа, значения 1-8 из спецификации EXIF
+	Orientation int
+	Artist      string
+	Copyright   string
+}
+
+// maxExifSize ограничивает блок EXIF, который читается в память. В JPEG больше и не поместится,
+// а в PNG и WebP блок большего размера - почти наверняка не метаданные фотоаппарата.
+const maxExifSize = 64 << 10
+
+// ReadMetadata читает EXIF из JPEG, PNG или WebP. Файл не читается целиком: просматриваются заголовки
+// блоков до EXIF. Битые метаданные не мешают показать изображение, поэтому ошибки разбора не возвращаются.
+func ReadMetadata(src io.ReaderAt, size int64, contentType string) Metadata {
+	var tiff []byte
+	switch contentType {
+	case "image/jpeg", "image/jpg":
+		tiff = jpegExif(src, size)
+	case "image/png":
+		tiff = pngExif(src, size)
+	case "image/webp":
+		tiff = webpExif(src, size)
+	}
+	meta, _ := parseExif(tiff)
+	return meta
+}
+
+// OnlyCopyright возвращает только поля об авторе и правах
+func (m Metadata) OnlyCopyright() Metadata {
+	return Metadata{Artist: m.Artist, Copyright: m.Copyright}
+}
+
+func jpegExif(src io.ReaderAt, size int64) []byte {
+	if soi, err := readAt(src, 0, 2); err != nil || soi[0] != 0xff || soi[1] != 0xd8 {
+		return nil
+	}
+	for pos := int64(2); pos+4 <= size; {
+		header, err := readAt(src, pos, 4)
+		if err != nil || header[0] != 0xff {
+			return nil
+		}
+		marker := header[1]
+		// после начала скана сегментов с метаданными уже не бывает
+		if marker == 0xda || marker == 0xd9 {
+			return nil
+		}
+		length := int64(binary.BigEndian.Uint16(header[2:]))
+		end := pos + 2 + length
+		if length < 2 || end > size {
+			return nil
+		}
+		if marker == 0xe1 {
+			segment, err := readAt(src, pos+4, int(length-2))
+			if err != nil {
+				return nil
+			}
+			if bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+				return segment[6:]
+			}
+		}
+		pos = end
+	}
+	return nil
+}
+
+func pngExif(src io.ReaderAt, size int64) []byte {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if head, err := readAt(src, 0, len(signature)); err != nil || string(head) != signature {
+		return nil
+	}
+	for pos := int64(len(signature)); pos+8 <= size; {
+		header, err := readAt(src, pos, 8)
+		if err != nil {
+			return nil
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		end := pos + 12 + length
+		if end > size {
+			return nil
+		}
+		switch string(header[4:8]) {
+		case "eXIf":
+			if length > maxExifSize {
+				return nil
+			}
+			data, _ := readAt(src, pos+8, int(length))
+			return data
+		case "IDAT", "IEND":
+			return nil
+		}
+		pos = end
+	}
+	return nil
+}
+
+func webpExif(src io.ReaderAt, size int64) []byte {
+	chunks, _ := riffChunks(src, size)
+	for _, c := range chunks {
+		if c.id == "EXIF" {
+			if c.size > maxExifSize {
+				return nil
+			}
+			data, _ := readAt(src, c.offset, int(c.size))
+			return bytes.TrimPrefix(data, []byte("Exif\x00\x00"))
+		}
+	}
+	return nil
+}
+
+// parseExif читает нужные теги из IFD0 блока TIFF
+func parseExif(tiff []byte) (Metadata, error) {
+	var meta Metadata
+	if len(tiff) < 8 {
+		return meta, errInvalidExif
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return meta, errInvalidExif
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return meta, errInvalidExif
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return meta, errInvalidExif
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return meta, errInvalidExif
+		}
+		tag, typ, n := order.Uint16(tiff[entry:]), order.Uint16(tiff[entry+2:]), int(order.Uint32(tiff[entry+4:]))
+		value := tiff[entry+8 : entry+12]
+
+		switch {
+		case tag == tagOrientation && typ == exifShort:
+			if o := int(order.Uint16(value)); o >= 1 && o <= 8 {
+				meta.Orientation = o
+			}
+		case (tag == tagArtist || tag == tagCopyright) && typ == exifASCII:
+			if n > 4 {
+				offset := int(order.Uint32(value))
+				if offset < 0 || n < 0 || offset+n > len(tiff) {
+					continue
+				}
+				value = tiff[offset : offset+n]
+			} else {
+				value = value[:n]
+			}
+			text := string(bytes.TrimRight(value, "\x00 "))
+			if tag == tagArtist {
+				meta.Artist = text
+			} else {
+				meta.Copyright = text
+			}
+		}
+	}
+	return meta, nil
+}
+
+// buildExif собирает блок TIFF с автором и правами. Пустой результат означает, что сохранять нечего.
+func buildExif(meta Metadata) []byte {
+	type entry struct {
+		tag   uint16
+		value string
+	}
+	var entries []entry
+	if meta.Artist != "" {
+		entries = append(entries, entry{tagArtist, meta.Artist})
+	}
+	if meta.Copyright != "" {
+		entries = append(entries, entry{tagCopyright, meta.Copyright})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	order := binary.LittleEndian
+	ifd := make([]byte, 2+len(entries)*12+4)
+	order.PutUint16(ifd, uint16(len(entries)))
+	var values []byte
+	valuesAt := 8 + len(ifd)
+	for i, e := range entries {
+		text := append([]byte(e.value), 0)
+		field := ifd[2+i*12:]
+		order.PutUint16(field, e.tag)
+		order.PutUint16(field[2:], exifASCII)
+		order.PutUint32(field[4:], uint32(len(text)))
+		if len(text) <= 4 {
+			copy(field[8:12], text)
+			continue
+		}
+		order.PutUint32(field[8:], uint32(valuesAt+len(values)))
+		values = append(values, text...)
+	}
+
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	tiff = append(tiff, ifd...)
+	return append(tiff, values...)
+}
+
+// Orient поворачивает и отражает изображение так, как требует тег Orientation
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,231 @@
+package images_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/images"
+	"github.com/chai2010/webp"
+)
+
+// фикстуры создает testdata/gen.go: изображения 16x8 с красной левой верхней четвертью на синем фоне,
+// в метаданных автор, права и координаты GPS
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %s", err)
+	}
+	return data
+}
+
+// readAll читает результат Rewrite целиком и проверяет, что его размер объявлен верно
+func readAll(t *testing.T, rw *images.Rewrite) []byte {
+	t.Helper()
+	data, err := io.ReadAll(rw.Reader())
+	if err != nil {
+		t.Fatalf("read rewrite: %s", err)
+	}
+	if int64(len(data)) != rw.Size {
+		t.Fatalf("size = %d, declared %d", len(data), rw.Size)
+	}
+	return data
+}
+
+func readMetadata(data []byte, contentType string) images.Metadata {
+	return images.ReadMetadata(bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+func stripWebP(t *testing.T, data []byte, meta images.Metadata) []byte {
+	t.Helper()
+	rw, err := images.StripWebP(bytes.NewReader(data), int64(len(data)), meta)
+	if err != nil {
+		t.Fatalf("strip webp: %s", err)
+	}
+	return readAll(t, rw)
+}
+
+func TestReadMetadata(t *testing.T) {
+	tests := []struct {
+		file        string
+		contentType string
+		want        images.Metadata
+	}{
+		{"orientation6.jpg", "image/jpeg", images.Metadata{Orientation: 6, Artist: "Jane Doe", Copyright: "(c) 2024 Jane Doe"}},
+		{"orientation3.png", "image/png", images.Metadata{Orientation: 3, Copyright: "(c) PNG"}},
+		{"gps.webp", "image/webp", images.Metadata{Orientation: 1, Artist: "Jane Doe", Copyright: "(c) 2024 Jane Doe"}},
+		{"animated.gif", "image/gif", images.Metadata{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := readMetadata(fixture(t, tt.file), tt.contentType)
+			if got != tt.want {
+				t.Errorf("metadata = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	tests := []struct {
+		file        string
+		contentType string
+		width       int
+		height      int
+		// цвета в точках, которые после поворота должны оказаться красными и синими
+		redAt, blueAt image.Point
+	}{
+		// поворот на 90° по часовой стрелке: красная четверть уходит в правый верхний угол
+		{"orientation6.jpg", "image/jpeg", 8, 16, image.Pt(6, 3), image.Pt(1, 3)},
+		// поворот на 180°: красная четверть уходит в правый нижний угол
+		{"orientation3.png", "image/png", 16, 8, image.Pt(12, 6), image.Pt(3, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data := fixture(t, tt.file)
+			img, err := images.Decode(bytes.NewReader(data), tt.contentType)
+			if err != nil {
+				t.Fatalf("decode: %s", err)
+			}
+			img = images.Orient(img, readMetadata(data, tt.contentType).Orientation)
+
+			if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+				t.Fatalf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.width, tt.height)
+			}
+			if !near(img.At(tt.redAt.X, tt.redAt.Y), color.RGBA{R: 255, A: 255}) {
+				t.Errorf("pixel %v = %v, want red", tt.redAt, img.At(tt.redAt.X, tt.redAt.Y))
+			}
+			if !near(img.At(tt.blueAt.X, tt.blueAt.Y), color.RGBA{B: 255, A: 255}) {
+				t.Errorf("pixel %v = %v, want blue", tt.blueAt, img.At(tt.blueAt.X, tt.blueAt.Y))
+			}
+		})
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	data := fixture(t, "gps.webp")
+
+	stripped := stripWebP(t, data, images.Metadata{})
+	if meta := readMetadata(stripped, "image/webp"); meta != (images.Metadata{}) {
+		t.Errorf("metadata left after strip: %+v", meta)
+	}
+	if bytes.Contains(stripped, []byte("GPSLatitude")) {
+		t.Errorf("xmp left after strip")
+	}
+	mustDecodeWebP(t, stripped, 16, 8)
+
+	kept := stripWebP(t, data, readMetadata(data, "image/webp").OnlyCopyright())
+	want := images.Metadata{Artist: "Jane Doe", Copyright: "(c) 2024 Jane Doe"}
+	if meta := readMetadata(kept, "image/webp"); meta != want {
+		t.Errorf("metadata = %+v, want %+v", meta, want)
+	}
+	mustDecodeWebP(t, kept, 16, 8)
+}
+
+// у WebP в простом формате (так его сохраняет EncodeOriginal) для метаданных добавляется заголовок VP8X
+func TestStripWebPSimpleFormat(t *testing.T) {
+	img, err := images.Decode(bytes.NewReader(fixture(t, "orientation3.png")), "image/png")
+	if err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	data, err := images.EncodeOriginal(img)
+	if err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+
+	want := images.Metadata{Copyright: "(c) PNG"}
+	kept := stripWebP(t, data, want)
+	if meta := readMetadata(kept, "image/webp"); meta != want {
+		t.Errorf("metadata = %+v, want %+v", meta, want)
+	}
+	mustDecodeWebP(t, kept, 16, 8)
+
+	if same := stripWebP(t, data, images.Metadata{}); !bytes.Equal(same, data) {
+		t.Errorf("webp without metadata changed")
+	}
+}
+
+func TestReadGIF(t *testing.T) {
+	data := fixture(t, "animated.gif")
+	info, err := images.ReadGIF(bytes.NewReader(data), int64(len(data)), 0)
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if !info.Animated || info.Width != 4 || info.Height != 4 {
+		t.Errorf("info = %+v", info)
+	}
+	stripped := readAll(t, info.Stripped)
+	for _, leaked := range []string{"55.75N", "XMP DataXMP", "GPSLatitude"} {
+		if bytes.Contains(stripped, []byte(leaked)) {
+			t.Errorf("%q left after strip", leaked)
+		}
+	}
+	if !bytes.Contains(stripped, []byte("NETSCAPE2.0")) {
+		t.Errorf("loop settings removed")
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(stripped))
+	if err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	if len(g.Image) != 2 {
+		t.Errorf("frames = %d, want 2", len(g.Image))
+	}
+	// два кадра 4x4 не помещаются в ограничение на один такой кадр
+	if _, err := images.ReadGIF(bytes.NewReader(data), int64(len(data)), 4*4); !errors.Is(err, images.ErrTooLarge) {
+		t.Errorf("frames over the pixel limit: got %v, want ErrTooLarge", err)
+	}
+}
+
+func TestReadAVIF(t *testing.T) {
+	data := fixture(t, "exif.avif")
+	info, err := images.ReadAVIF(bytes.NewReader(data), int64(len(data)), 0)
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if info.Width != 640 || info.Height != 480 {
+		t.Errorf("size = %dx%d, want 640x480", info.Width, info.Height)
+	}
+	stripped := readAll(t, info.Stripped)
+	if len(stripped) != len(data) {
+		t.Errorf("size changed: %d != %d", len(stripped), len(data))
+	}
+	if bytes.Contains(stripped, []byte("MM\x00*")) {
+		t.Errorf("exif left after strip")
+	}
+	if !bytes.Contains(stripped, []byte("fake av01 bitstream")) {
+		t.Errorf("image data was damaged")
+	}
+	if _, err := images.ReadAVIF(bytes.NewReader(stripped), int64(len(stripped)), 0); err != nil {
+		t.Errorf("read stripped: %s", err)
+	}
+}
+
+func mustDecodeWebP(t *testing.T, data []byte, width, height int) {
+	t.Helper()
+	img, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode webp: %s", err)
+	}
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		t.Errorf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), width, height)
+	}
+}
+
+// near сравнивает цвета с допуском: JPEG сжимает с потерями
+func near(c color.Color, want color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+	diff := func(a uint32, b uint8) bool {
+		d := int(a>>8) - int(b)
+		return d > -40 && d < 40
+	}
+	return diff(r, want.R) && diff(g, want.G) && diff(b, want.B)
+}
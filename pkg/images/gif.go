@@ -0,0 +1,156 @@
+package images
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrInvalidGIF = errors.New("invalid gif")
+
+// GIF - сведения о GIF, собранные без распаковки кадров
+type GIF struct {
+	Animated      bool
+	Width, Height int
+	// Stripped - тот же GIF без комментариев и XMP. Остальные блоки, включая настройки повтора анимации, сохраняются.
+	Stripped *Rewrite
+}
+
+// ReadGIF разбирает структуру GIF. Кодировщик WebP не умеет анимацию, поэтому анимированные GIF хранятся
+// как есть, без метаданных. maxPixels ограничивает суммарную площадь кадров, нулевое значение отключает проверку.
+func ReadGIF(src io.ReaderAt, size int64, maxPixels int) (*GIF, error) {
+	r := &positionReader{r: bufio.NewReader(io.NewSectionReader(src, 0, size))}
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(r, header); err != nil || (string(header[:6]) != "GIF87a" && string(header[:6]) != "GIF89a") {
+		return nil, ErrInvalidGIF
+	}
+	info := &GIF{
+		Width:    int(binary.LittleEndian.Uint16(header[6:8])),
+		Height:   int(binary.LittleEndian.Uint16(header[8:10])),
+		Stripped: newRewrite(src),
+	}
+	if err := checkPixels(info.Width, info.Height, maxPixels); err != nil {
+		return nil, err
+	}
+	if err := r.discard(colorTableSize(header[10])); err != nil {
+		return nil, ErrInvalidGIF
+	}
+	info.Stripped.copy(0, r.pos)
+
+	var frames int
+	var pixels int64
+	for {
+		start := r.pos
+		kind, err := r.ReadByte()
+		if err == io.EOF && frames > 0 {
+			// часть кодировщиков не пишет завершающий байт, браузеры показывают такие файлы
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidGIF
+		}
+
+		drop := false
+		switch kind {
+		case 0x21: // расширение: метка и подблоки
+			label, err := r.ReadByte()
+			if err != nil {
+				return nil, ErrInvalidGIF
+			}
+			if label == 0xfe {
+				drop = true
+			} else if label == 0xff {
+				// у блока приложения первый подблок - идентификатор
+				id, err := r.peek(12)
+				if err == nil && id[0] == 11 && string(id[1:12]) == "XMP DataXMP" {
+					drop = true
+				}
+			}
+		case 0x2c: // кадр: дескриптор, локальная палитра, минимальный размер кода LZW и подблоки данных
+			descriptor := make([]byte, 9)
+			if _, err := io.ReadFull(r, descriptor); err != nil {
+				return nil, ErrInvalidGIF
+			}
+			frames++
+			pixels += int64(binary.LittleEndian.Uint16(descriptor[4:])) * int64(binary.LittleEndian.Uint16(descriptor[6:]))
+			if maxPixels > 0 && pixels > int64(maxPixels) {
+				return nil, ErrTooLarge
+			}
+			if err := r.discard(colorTableSize(descriptor[8]) + 1); err != nil {
+				return nil, ErrInvalidGIF
+			}
+		case 0x3b: // конец файла
+			if frames == 0 {
+				return nil, ErrInvalidGIF
+			}
+			info.Stripped.copy(start, size-start)
+			info.Animated = frames > 1
+			return info, nil
+		default:
+			return nil, ErrInvalidGIF
+		}
+
+		if err := r.skipSubBlocks(); err != nil {
+			return nil, ErrInvalidGIF
+		}
+		if !drop {
+			info.Stripped.copy(start, r.pos-start)
+		}
+	}
+	info.Animated = frames > 1
+	return info, nil
+}
+
+func colorTableSize(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << (flags&0x07 + 1)
+}
+
+// positionReader считает прочитанные байты, чтобы знать смещения блоков в исходном файле
+type positionReader struct {
+	r   *bufio.Reader
+	pos int64
+}
+
+func (r *positionReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *positionReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.pos++
+	}
+	return b, err
+}
+
+func (r *positionReader) peek(n int) ([]byte, error) {
+	return r.r.Peek(n)
+}
+
+func (r *positionReader) discard(n int) error {
+	discarded, err := r.r.Discard(n)
+	r.pos += int64(discarded)
+	return err
+}
+
+// skipSubBlocks пропускает цепочку подблоков, которая заканчивается блоком нулевой длины
+func (r *positionReader) skipSubBlocks() error {
+	for {
+		size, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			return nil
+		}
+		if err := r.discard(int(size)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package images декодирует загружаемые изображения и готовит из них WebP-варианты разных размеров
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/chai2010/webp"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image dimensions exceed the limit")
+)
+
+// Preset описывает вариант изображения: картинка вписывается в MaxWidth x MaxHeight
+// (нулевое значение снимает ограничение) и сжимается с потерями с качеством Quality
+type Preset struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+	Quality   float32
+}
+
+// Variant - готовый вариант изображения в формате WebP
+type Variant struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// Decodable сообщает, умеет ли Decode разбирать изображения такого типа
+func Decodable(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/jpg", "image/webp", "image/gif", "image/bmp":
+		return true
+	}
+	return false
+}
+
+// Decode разбирает изображение по его MIME-типу
+func Decode(r io.Reader, contentType string) (image.Image, error) {
+	switch contentType {
+	case "image/png":
+		return png.Decode(r)
+	case "image/jpeg", "image/jpg":
+		return jpeg.Decode(r)
+	case "image/webp":
+		return webp.Decode(r)
+	case "image/gif":
+		return gif.Decode(r)
+	case "image/bmp":
+		return bmp.Decode(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// CheckSize читает из заголовка изображения его размеры и не дает декодировать картинки больше maxPixels:
+// маленький файл может распаковаться в гигабайты памяти. Нулевой maxPixels отключает проверку.
+// Размеры AVIF возвращает ReadAVIF.
+func CheckSize(r io.Reader, contentType string, maxPixels int) (int, int, error) {
+	var (
+		cfg image.Config
+		err error
+	)
+	switch contentType {
+	case "image/png":
+		cfg, err = png.DecodeConfig(r)
+	case "image/jpeg", "image/jpg":
+		cfg, err = jpeg.DecodeConfig(r)
+	case "image/webp":
+		cfg, err = webp.DecodeConfig(r)
+	case "image/gif":
+		cfg, err = gif.DecodeConfig(r)
+	case "image/bmp":
+		cfg, err = bmp.DecodeConfig(r)
+	default:
+		return 0, 0, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := checkPixels(cfg.Width, cfg.Height, maxPixels); err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+func checkPixels(width, height, maxPixels int) error {
+	if maxPixels > 0 && int64(width)*int64(height) > int64(maxPixels) {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// EncodeOriginal сохраняет изображение в полном размере без потерь
+func EncodeOriginal(img image.Image) ([]byte, error) {
+	var out bytes.Buffer
+	if err := webp.Encode(&out, img, &webp.Options{Lossless: true, Exact: true}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// EncodeVariant строит один вариант изображения по пресету. Варианты строятся по одному, чтобы в памяти
+// не держать их все сразу. Изображение только уменьшается, поэтому варианты небольших картинок могут
+// совпадать по размеру с оригиналом.
+func EncodeVariant(img image.Image, preset Preset) (*Variant, error) {
+	resized := Fit(img, preset.MaxWidth, preset.MaxHeight)
+
+	var out bytes.Buffer
+	if err := webp.Encode(&out, resized, &webp.Options{Quality: preset.Quality}); err != nil {
+		return nil, err
+	}
+	bounds := resized.Bounds()
+	return &Variant{
+		Name:   preset.Name,
+		Data:   out.Bytes(),
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}, nil
+}
+
+// Fit уменьшает изображение с сохранением пропорций так, чтобы оно поместилось в maxWidth x maxHeight
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale == 1 {
+		return img
+	}
+
+	w, h := int(float64(width)*scale+0.5), int(float64(height)*scale+0.5)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
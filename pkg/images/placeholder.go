@@ -0,0 +1,173 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// размер копии, по которой считается заглушка: больше деталей blurhash все равно не передает
+const placeholderSize = 32
+
+// Placeholder - то, что фронтенд показывает на месте изображения, пока оно загружается
+type Placeholder struct {
+	// Blurhash - размытая копия изображения в формате https://blurha.sh
+	Blurhash string
+	// Color - преобладающий цвет в виде #rrggbb
+	Color string
+}
+
+// NewPlaceholder считает заглушку по уменьшенной копии изображения. Прозрачные области считаются белыми.
+func NewPlaceholder(img image.Image) Placeholder {
+	small := Fit(img, placeholderSize, placeholderSize)
+	bounds := small.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return Placeholder{}
+	}
+
+	pixels := make([][3]float64, 0, width*height)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := small.At(x, y).RGBA()
+			// цвета RGBA() умножены на альфу, добавка 0xffff-a накладывает пиксель на белый фон
+			white := 0xffff - a
+			pixels = append(pixels, [3]float64{
+				float64(r+white) / 0xffff,
+				float64(g+white) / 0xffff,
+				float64(b+white) / 0xffff,
+			})
+		}
+	}
+
+	// горизонтальные картинки раскладываются на больше компонент по ширине, вертикальные - по высоте
+	xComponents, yComponents := 4, 3
+	if height > width {
+		xComponents, yComponents = 3, 4
+	}
+	return Placeholder{
+		Blurhash: blurhash(pixels, width, height, xComponents, yComponents),
+		Color:    dominantColor(pixels),
+	}
+}
+
+// dominantColor находит самую частую группу близких цветов и возвращает их средний цвет
+func dominantColor(pixels [][3]float64) string {
+	// 4 бита на канал: близкие оттенки попадают в одну группу
+	type bucket struct {
+		count   int
+		r, g, b float64
+	}
+	buckets := map[int]*bucket{}
+	var best *bucket
+	for _, p := range pixels {
+		key := int(p[0]*15+0.5)<<8 | int(p[1]*15+0.5)<<4 | int(p[2]*15+0.5)
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		b.count++
+		b.r, b.g, b.b = b.r+p[0], b.g+p[1], b.b+p[2]
+		if best == nil || b.count > best.count {
+			best = b
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	n := float64(best.count)
+	return fmt.Sprintf("#%02x%02x%02x", toByte(best.r/n), toByte(best.g/n), toByte(best.b/n))
+}
+
+// blurhash раскладывает изображение по косинусам и кодирует первые компоненты, как описано
+// в https://github.com/woltapp/blurhash/blob/master/Algorithm.md
+func blurhash(pixels [][3]float64, width, height, xComponents, yComponents int) string {
+	linear := make([][3]float64, len(pixels))
+	for i, p := range pixels {
+		linear[i] = [3]float64{srgbToLinear(p[0]), srgbToLinear(p[1]), srgbToLinear(p[2])}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var f [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := math.Cos(math.Pi*float64(i*x)/float64(width)) * math.Cos(math.Pi*float64(j*y)/float64(height))
+					p := linear[y*width+x]
+					f[0] += basis * p[0]
+					f[1] += basis * p[1]
+					f[2] += basis * p[2]
+				}
+			}
+			scale := normalisation / float64(width*height)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	encode83(&hash, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			for _, v := range f {
+				actualMax = math.Max(actualMax, math.Abs(v))
+			}
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		encode83(&hash, quantisedMax, 1)
+	} else {
+		encode83(&hash, 0, 1)
+	}
+
+	encode83(&hash, int(linearToSrgb(dc[0]))<<16|int(linearToSrgb(dc[1]))<<8|int(linearToSrgb(dc[2])), 4)
+	for _, f := range ac {
+		quant := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		encode83(&hash, quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2)
+	}
+	return hash.String()
+}
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+func encode83(sb *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := value / int(math.Pow(83, float64(length-i))) % 83
+		sb.WriteByte(base83[digit])
+	}
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) uint8 {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return toByte(v * 12.92)
+	}
+	return toByte(1.055*math.Pow(v, 1/2.4) - 0.055)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+func toByte(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v*255))))
+}
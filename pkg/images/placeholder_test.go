@@ -0,0 +1,76 @@
+package images_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/images"
+)
+
+func filled(width, height int, fill func(x, y int) color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill(x, y))
+		}
+	}
+	return img
+}
+
+func TestPlaceholderSolid(t *testing.T) {
+	img := filled(64, 48, func(x, y int) color.Color { return color.RGBA{R: 255, A: 255} })
+
+	p := images.NewPlaceholder(img)
+	// символ L - 4x3 компоненты, следующие за символом максимума четыре - средний цвет #ff0000
+	if len(p.Blurhash) != 28 || p.Blurhash[0] != 'L' || p.Blurhash[2:6] != "TI:j" {
+		t.Errorf("blurhash = %q, want 4x3 components with DC TI:j", p.Blurhash)
+	}
+	if p.Color != "#ff0000" {
+		t.Errorf("color = %q, want #ff0000", p.Color)
+	}
+}
+
+func TestPlaceholderDominantColor(t *testing.T) {
+	// красная четверть на синем фоне, как в фикстурах
+	img := filled(16, 8, func(x, y int) color.Color {
+		if x < 8 && y < 4 {
+			return color.RGBA{R: 255, A: 255}
+		}
+		return color.RGBA{B: 255, A: 255}
+	})
+
+	p := images.NewPlaceholder(img)
+	if p.Color != "#0000ff" {
+		t.Errorf("color = %q, want #0000ff", p.Color)
+	}
+	if len(p.Blurhash) != 28 || p.Blurhash[0] != 'L' {
+		t.Errorf("blurhash = %q, want 4x3 components", p.Blurhash)
+	}
+	if p.Blurhash == images.NewPlaceholder(filled(16, 8, func(x, y int) color.Color { return color.RGBA{B: 255, A: 255} })).Blurhash {
+		t.Errorf("blurhash = %q does not depend on the red quarter", p.Blurhash)
+	}
+}
+
+func TestPlaceholderPortrait(t *testing.T) {
+	img := filled(8, 16, func(x, y int) color.Color { return color.Gray{Y: uint8(y * 16)} })
+
+	p := images.NewPlaceholder(img)
+	// 3x4 компоненты кодируются символом T
+	if len(p.Blurhash) != 28 || p.Blurhash[0] != 'T' {
+		t.Errorf("blurhash = %q, want 3x4 components", p.Blurhash)
+	}
+}
+
+func TestPlaceholderTransparent(t *testing.T) {
+	img := filled(8, 8, func(x, y int) color.Color { return color.Transparent })
+
+	p := images.NewPlaceholder(img)
+	if p.Color != "#ffffff" {
+		t.Errorf("color = %q, want #ffffff", p.Color)
+	}
+	// прозрачное изображение кодируется как белое
+	if len(p.Blurhash) != 28 || p.Blurhash[2:6] != "TSUA" {
+		t.Errorf("blurhash = %q, want white DC TSUA", p.Blurhash)
+	}
+}
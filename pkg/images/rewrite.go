@@ -0,0 +1,94 @@
+package images
+
+import (
+	"bytes"
+	"io"
+)
+
+// Rewrite описывает новый файл как последовательность кусков исходного файла и новых данных.
+// Размер результата известен заранее, а сам он читается потоком, без загрузки исходного файла в память.
+type Rewrite struct {
+	src   io.ReaderAt
+	parts []rewritePart
+	Size  int64
+}
+
+type rewritePart struct {
+	offset, length int64
+	data           []byte
+	zero           bool
+}
+
+func newRewrite(src io.ReaderAt) *Rewrite {
+	return &Rewrite{src: src}
+}
+
+// Unchanged возвращает Rewrite, который отдает исходный файл как есть
+func Unchanged(src io.ReaderAt, size int64) *Rewrite {
+	rw := newRewrite(src)
+	rw.copy(0, size)
+	return rw
+}
+
+// copy добавляет кусок исходного файла, соседние куски склеиваются
+func (rw *Rewrite) copy(offset, length int64) {
+	if length <= 0 {
+		return
+	}
+	rw.Size += length
+	if n := len(rw.parts); n > 0 {
+		last := &rw.parts[n-1]
+		if last.data == nil && !last.zero && last.offset+last.length == offset {
+			last.length += length
+			return
+		}
+	}
+	rw.parts = append(rw.parts, rewritePart{offset: offset, length: length})
+}
+
+func (rw *Rewrite) write(data []byte) {
+	rw.Size += int64(len(data))
+	rw.parts = append(rw.parts, rewritePart{data: data, length: int64(len(data))})
+}
+
+func (rw *Rewrite) zero(length int64) {
+	rw.Size += length
+	rw.parts = append(rw.parts, rewritePart{length: length, zero: true})
+}
+
+// Reader возвращает содержимое нового файла
+func (rw *Rewrite) Reader() io.Reader {
+	readers := make([]io.Reader, 0, len(rw.parts))
+	for _, p := range rw.parts {
+		switch {
+		case p.data != nil:
+			readers = append(readers, bytes.NewReader(p.data))
+		case p.zero:
+			readers = append(readers, io.LimitReader(zeroReader{}, p.length))
+		default:
+			readers = append(readers, io.NewSectionReader(rw.src, p.offset, p.length))
+		}
+	}
+	return io.MultiReader(readers...)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// readAt читает ровно n байт с позиции offset
+func readAt(src io.ReaderAt, offset int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := src.ReadAt(buf, offset); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,63 @@
+package images
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// SniffLen - сколько байт от начала файла нужно DetectType
+const SniffLen = 1024
+
+// DetectType определяет MIME-тип файла по его содержимому. Заголовку Content-Type от клиента верить нельзя:
+// по нему в хранилище мог бы попасть, например, HTML под видом картинки.
+func DetectType(data []byte) string {
+	head := data
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+
+	// AVIF и SVG http.DetectContentType не распознает
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" && (string(head[8:12]) == "avif" || string(head[8:12]) == "avis") {
+		return "image/avif"
+	}
+	contentType := http.DetectContentType(head)
+	switch contentType {
+	case "text/xml; charset=utf-8", "text/plain; charset=utf-8":
+		if isSVG(head) {
+			return "image/svg+xml"
+		}
+	}
+	if i := bytes.IndexByte([]byte(contentType), ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+// isSVG проверяет, что документ начинается с элемента svg, пропуская пролог, комментарии и DOCTYPE
+func isSVG(head []byte) bool {
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	for {
+		head = bytes.TrimLeft(head, " \t\r\n")
+		switch {
+		case bytes.HasPrefix(head, []byte("<?")):
+			head = skipPast(head, "?>")
+		case bytes.HasPrefix(head, []byte("<!--")):
+			head = skipPast(head, "-->")
+		case bytes.HasPrefix(head, []byte("<!")):
+			head = skipPast(head, ">")
+		default:
+			return bytes.HasPrefix(head, []byte("<svg")) && len(head) > 4 && bytes.IndexByte([]byte(" \t\r\n>/"), head[4]) >= 0
+		}
+		if head == nil {
+			return false
+		}
+	}
+}
+
+func skipPast(data []byte, end string) []byte {
+	i := bytes.Index(data, []byte(end))
+	if i < 0 {
+		return nil
+	}
+	return data[i+len(end):]
+}
@@ -0,0 +1,192 @@
+package images
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSVG = errors.New("invalid svg")
+
+// svgElements - элементы, которые остаются в SVG. Скрипты, foreignObject, анимации (ими можно подменить ссылку)
+// и все незнакомые элементы удаляются вместе с содержимым.
+var svgElements = map[string]bool{
+	"svg": true, "g": true, "defs": true, "symbol": true, "use": true, "title": true, "desc": true, "switch": true,
+	"path": true, "rect": true, "circle": true, "ellipse": true, "line": true, "polyline": true, "polygon": true,
+	"text": true, "tspan": true, "textPath": true, "image": true, "style": true, "marker": true, "pattern": true,
+	"linearGradient": true, "radialGradient": true, "stop": true, "clipPath": true, "mask": true, "filter": true,
+	"feBlend": true, "feColorMatrix": true, "feComponentTransfer": true, "feComposite": true, "feConvolveMatrix": true,
+	"feDiffuseLighting": true, "feDisplacementMap": true, "feDistantLight": true, "feDropShadow": true, "feFlood": true,
+	"feFuncA": true, "feFuncB": true, "feFuncG": true, "feFuncR": true, "feGaussianBlur": true, "feImage": true,
+	"feMerge": true, "feMergeNode": true, "feMorphology": true, "feOffset": true, "fePointLight": true,
+	"feSpecularLighting": true, "feSpotLight": true, "feTile": true, "feTurbulence": true,
+}
+
+var (
+	// в CSS разрешены только ссылки на элементы того же документа
+	externalCSS = regexp.MustCompile(`(?i)@import|expression\s*\(|image-set\s*\(|src\s*\(|url\s*\(\s*['"]?\s*[^'"#\s)]`)
+	safeImage   = regexp.MustCompile(`^data:image/(png|jpeg|gif|webp);base64,`)
+)
+
+// SanitizeSVG оставляет в SVG только разрешенные элементы и атрибуты без обработчиков событий и внешних ссылок
+// и возвращает очищенный документ с его размерами (из width/height или viewBox корневого элемента).
+func SanitizeSVG(r io.Reader) ([]byte, int, int, error) {
+	d := xml.NewDecoder(r)
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+
+	var (
+		depth, skip   int
+		width, height int
+		root          bool
+		// styleDepth - глубина открытого элемента style, css - его текст целиком: комментарии между частями
+		// текста отбрасываются, и проверять части по отдельности нельзя
+		styleDepth int
+		css        strings.Builder
+		// RawToken не сверяет закрывающие теги с открытыми, поэтому открытые элементы отслеживаются здесь
+		open []xml.Name
+	)
+	for {
+		token, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, 0, ErrInvalidSVG
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			open = append(open, t.Name)
+			// внутри style допускается только текст, вложенные элементы удаляются вместе с содержимым
+			if skip > 0 || styleDepth > 0 || !svgElements[t.Name.Local] || (t.Name.Space != "" && t.Name.Space != "svg") {
+				if skip == 0 {
+					skip = depth
+				}
+				continue
+			}
+			if !root {
+				if t.Name.Local != "svg" {
+					return nil, 0, 0, ErrInvalidSVG
+				}
+				root = true
+				width, height = svgSize(t.Attr)
+			}
+			if t.Name.Local == "style" {
+				styleDepth = depth
+				css.Reset()
+			}
+			out.WriteString("<" + qualifiedName(t.Name))
+			for _, attr := range t.Attr {
+				if safeAttr(attr) {
+					out.WriteString(" " + qualifiedName(attr.Name) + `="`)
+					xml.EscapeText(&out, []byte(attr.Value))
+					out.WriteString(`"`)
+				}
+			}
+			out.WriteString(">")
+		case xml.EndElement:
+			if depth == 0 || open[depth-1] != t.Name {
+				return nil, 0, 0, ErrInvalidSVG
+			}
+			open = open[:depth-1]
+			if skip == 0 {
+				out.WriteString("</" + qualifiedName(t.Name) + ">")
+			}
+			if skip == depth {
+				skip = 0
+			}
+			if styleDepth == depth {
+				if unsafeCSS(css.String()) {
+					return nil, 0, 0, ErrInvalidSVG
+				}
+				styleDepth = 0
+			}
+			depth--
+		case xml.CharData:
+			if skip > 0 || depth == 0 {
+				continue
+			}
+			if styleDepth > 0 {
+				css.Write(t)
+			}
+			xml.EscapeText(&out, t)
+		}
+		// комментарии, DOCTYPE с сущностями и инструкции обработки отбрасываются
+	}
+	if !root || depth != 0 {
+		return nil, 0, 0, ErrInvalidSVG
+	}
+	return out.Bytes(), width, height, nil
+}
+
+func safeAttr(attr xml.Attr) bool {
+	name := strings.ToLower(attr.Name.Local)
+	value := strings.TrimSpace(attr.Value)
+	switch {
+	case strings.HasPrefix(name, "on"):
+		return false
+	case attr.Name.Space == "xmlns" || (attr.Name.Space == "" && name == "xmlns"):
+		return true
+	case attr.Name.Space != "" && attr.Name.Space != "xlink" && attr.Name.Space != "xml":
+		return false
+	case name == "href":
+		return strings.HasPrefix(value, "#") || safeImage.MatchString(value)
+	case strings.Contains(strings.ToLower(strings.Join(strings.Fields(value), "")), "javascript:"):
+		return false
+	}
+	// style и атрибуты оформления (fill, filter, mask и другие) разбираются как CSS
+	return !unsafeCSS(value)
+}
+
+// unsafeCSS сообщает, может ли CSS загрузить внешний ресурс. Экранирование вида \75rl( позволяет записать
+// любую функцию в обход проверки, поэтому CSS с обратной косой чертой не принимается.
+func unsafeCSS(css string) bool {
+	return strings.Contains(css, `\`) || externalCSS.MatchString(css)
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// svgSize читает размеры корневого элемента. Проценты и пустые значения заменяются размерами viewBox.
+func svgSize(attrs []xml.Attr) (int, int) {
+	var width, height int
+	var viewBox []string
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case "width":
+			width = svgLength(attr.Value)
+		case "height":
+			height = svgLength(attr.Value)
+		case "viewBox":
+			viewBox = strings.Fields(strings.ReplaceAll(attr.Value, ",", " "))
+		}
+	}
+	if len(viewBox) == 4 {
+		if width == 0 {
+			width = svgLength(viewBox[2])
+		}
+		if height == 0 {
+			height = svgLength(viewBox[3])
+		}
+	}
+	return width, height
+}
+
+func svgLength(value string) int {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return int(n + 0.5)
+}
@@ -0,0 +1,138 @@
+package images_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/images"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		// keep должно остаться в результате, drop - исчезнуть
+		keep, drop []string
+	}{
+		{
+			name: "script",
+			svg:  `<svg><script>alert(1)</script><rect width="1"/></svg>`,
+			keep: []string{`<rect width="1">`},
+			drop: []string{"script", "alert"},
+		},
+		{
+			name: "event handlers",
+			svg:  `<svg onload="alert(1)"><rect ONCLICK="alert(2)" fill="red"/></svg>`,
+			keep: []string{`fill="red"`},
+			drop: []string{"alert", "onload", "ONCLICK"},
+		},
+		{
+			name: "href schemes",
+			svg: `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><use href="#icon"/><use xlink:href="javascript:alert(1)"/>` +
+				`<use href="http://evil/x.svg#a"/><image href="data:image/png;base64,AAAA"/><image href="data:image/svg+xml;base64,AAAA"/></svg>`,
+			keep: []string{`href="#icon"`, `href="data:image/png;base64,AAAA"`},
+			drop: []string{"javascript", "evil", "svg+xml"},
+		},
+		{
+			name: "javascript in other attributes",
+			svg:  `<svg><a><rect values="java&#x0A;script:alert(1)"/></a></svg>`,
+			drop: []string{"script"},
+		},
+		{
+			name: "foreignObject",
+			svg:  `<svg><foreignObject><div xmlns="http://www.w3.org/1999/xhtml"><iframe src="http://evil"/></div></foreignObject><circle r="1"/></svg>`,
+			keep: []string{`<circle r="1">`},
+			drop: []string{"foreignObject", "iframe", "evil"},
+		},
+		{
+			name: "namespaced elements",
+			svg:  `<svg xmlns:h="http://www.w3.org/1999/xhtml"><h:script>alert(1)</h:script><h:rect/><svg:rect width="2"/></svg>`,
+			keep: []string{`<svg:rect width="2">`},
+			drop: []string{"h:script", "alert", "h:rect"},
+		},
+		{
+			name: "external url in attributes",
+			svg:  `<svg><rect fill="url(http://evil/p)" stroke="url(#grad)" style="fill:\75rl(http://evil/p)"/></svg>`,
+			keep: []string{`stroke="url(#grad)"`},
+			drop: []string{"evil", "style"},
+		},
+		{
+			name: "local css",
+			svg:  `<svg><style>rect { fill: url(#grad) }</style><rect/></svg>`,
+			keep: []string{"<style>rect { fill: url(#grad) }</style>"},
+		},
+		{
+			name: "element inside style",
+			svg:  `<svg><style><title>x</title>rect { fill: red }</style></svg>`,
+			keep: []string{"<style>rect { fill: red }</style>"},
+			drop: []string{"title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clean, _, _, err := images.SanitizeSVG(strings.NewReader(tt.svg))
+			if err != nil {
+				t.Fatalf("sanitize: %s", err)
+			}
+			for _, s := range tt.keep {
+				if !strings.Contains(string(clean), s) {
+					t.Errorf("%s: %q was removed", clean, s)
+				}
+			}
+			for _, s := range tt.drop {
+				if strings.Contains(string(clean), s) {
+					t.Errorf("%s: %q was kept", clean, s)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeSVGRejects(t *testing.T) {
+	tests := []struct {
+		name, svg string
+	}{
+		{"not svg", `<html><body/></html>`},
+		{"unclosed", `<svg><rect>`},
+		{"mismatched tags", `<svg><style></rect>@import url(http://evil/x.css);</style></svg>`},
+		{"css import", `<svg><style>@import url(http://evil/x.css);</style></svg>`},
+		{"css url", `<svg><style>rect { fill: url( 'http://evil/p') }</style></svg>`},
+		// вложенный элемент не должен сбрасывать проверку текста, который идет после него
+		{"css after nested element", `<svg><style><title/>@import url(http://evil/x.css); rect{fill:url(http://evil/p)}</style></svg>`},
+		{"css escape", `<svg><style>rect { fill: \75rl(http://evil/p) }</style></svg>`},
+		// комментарий отбрасывается, и части текста склеиваются в @import
+		{"css split by comment", `<svg><style>@imp<!-- -->ort 'http://evil/x.css';</style></svg>`},
+		{"css in cdata", `<svg><style><![CDATA[@import url(http://evil/x.css);]]></style></svg>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := images.SanitizeSVG(strings.NewReader(tt.svg)); !errors.Is(err, images.ErrInvalidSVG) {
+				t.Errorf("err = %v, want %v", err, images.ErrInvalidSVG)
+			}
+		})
+	}
+}
+
+func TestSanitizeSVGSize(t *testing.T) {
+	tests := []struct {
+		svg           string
+		width, height int
+	}{
+		{`<svg width="120px" height="80.4"/>`, 120, 80},
+		{`<svg width="100%" viewBox="0 0 64,32"/>`, 64, 32},
+		{`<svg/>`, 0, 0},
+	}
+
+	for _, tt := range tests {
+		_, width, height, err := images.SanitizeSVG(strings.NewReader(tt.svg))
+		if err != nil {
+			t.Fatalf("%s: %s", tt.svg, err)
+		}
+		if width != tt.width || height != tt.height {
+			t.Errorf("%s: size %dx%d, want %dx%d", tt.svg, width, height, tt.width, tt.height)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+//go:build ignore
+
+// gen создает изображения для тестов пакета images: go run gen.go в каталоге testdata.
+// Блоки EXIF собираются здесь вручную, независимо от кода пакета, чтобы тесты не проверяли код им же самим.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+
+	"github.com/chai2010/webp"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+func main() {
+	write("orientation6.jpg", jpegWithExif(exif(binary.BigEndian, 6, "Jane Doe", "(c) 2024 Jane Doe")))
+	write("orientation3.png", pngWithExif(exif(binary.LittleEndian, 3, "", "(c) PNG")))
+	write("gps.webp", webpWithMetadata(exif(binary.LittleEndian, 1, "Jane Doe", "(c) 2024 Jane Doe")))
+	write("animated.gif", animatedGif())
+	write("exif.avif", avif(exif(binary.BigEndian, 1, "", "")))
+}
+
+func write(name string, data []byte) {
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// quadrant - 16x8: левая верхняя четверть красная, остальное синее. По положению красной четверти
+// различаются все восемь вариантов ориентации.
+func quadrant() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			if x < 8 && y < 4 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// exif собирает TIFF с IFD0 (Orientation, Artist, Copyright, ссылка на GPS) и GPS IFD с широтой
+func exif(order binary.ByteOrder, orientation uint16, artist, copyright string) []byte {
+	type field struct {
+		tag, typ uint16
+		count    uint32
+		value    []byte
+	}
+	short := func(v uint16) []byte { b := make([]byte, 4); order.PutUint16(b, v); return b }
+	long := func(v uint32) []byte { b := make([]byte, 4); order.PutUint32(b, v); return b }
+	ascii := func(s string) []byte { return append([]byte(s), 0) }
+
+	fields := []field{{0x0112, 3, 1, short(orientation)}}
+	if artist != "" {
+		fields = append(fields, field{0x013b, 2, uint32(len(artist) + 1), ascii(artist)})
+	}
+	if copyright != "" {
+		fields = append(fields, field{0x8298, 2, uint32(len(copyright) + 1), ascii(copyright)})
+	}
+	fields = append(fields, field{0x8825, 4, 1, nil})
+
+	ifdSize := 2 + 12*len(fields) + 4
+	var values []byte
+	valuesAt := 8 + ifdSize
+	for _, f := range fields {
+		if len(f.value) > 4 {
+			values = append(values, f.value...)
+		}
+	}
+	gpsAt := valuesAt + len(values)
+
+	var buf bytes.Buffer
+	if order == binary.BigEndian {
+		buf.WriteString("MM")
+	} else {
+		buf.WriteString("II")
+	}
+	buf.Write(short(42)[:2])
+	buf.Write(long(8))
+	buf.Write(short(uint16(len(fields)))[:2])
+	offset := valuesAt
+	for _, f := range fields {
+		buf.Write(short(f.tag)[:2])
+		buf.Write(short(f.typ)[:2])
+		buf.Write(long(f.count))
+		switch {
+		case f.tag == 0x8825:
+			buf.Write(long(uint32(gpsAt)))
+		case len(f.value) > 4:
+			buf.Write(long(uint32(offset)))
+			offset += len(f.value)
+		default:
+			v := make([]byte, 4)
+			copy(v, f.value)
+			buf.Write(v)
+		}
+	}
+	buf.Write(long(0))
+	buf.Write(values)
+
+	// GPS IFD: GPSLatitudeRef = "N"
+	buf.Write(short(1)[:2])
+	buf.Write(short(0x0001)[:2])
+	buf.Write(short(2)[:2])
+	buf.Write(long(2))
+	buf.Write([]byte{'N', 0, 0, 0})
+	buf.Write(long(0))
+	return buf.Bytes()
+}
+
+func jpegWithExif(tiff []byte) []byte {
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, quadrant(), &jpeg.Options{Quality: 100}); err != nil {
+		log.Fatal(err)
+	}
+	data := img.Bytes()
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func pngWithExif(tiff []byte) []byte {
+	var img bytes.Buffer
+	if err := png.Encode(&img, quadrant()); err != nil {
+		log.Fatal(err)
+	}
+	data := img.Bytes()
+	// eXIf должен идти до IDAT, сразу после IHDR (8 байт сигнатуры + 25 байт чанка)
+	chunk := make([]byte, 8, 12+len(tiff))
+	binary.BigEndian.PutUint32(chunk, uint32(len(tiff)))
+	copy(chunk[4:], "eXIf")
+	chunk = append(chunk, tiff...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk[4:]))
+	chunk = append(chunk, crc...)
+
+	out := append([]byte{}, data[:33]...)
+	out = append(out, chunk...)
+	return append(out, data[33:]...)
+}
+
+func webpWithMetadata(tiff []byte) []byte {
+	var img bytes.Buffer
+	if err := webp.Encode(&img, quadrant(), &webp.Options{Lossless: true}); err != nil {
+		log.Fatal(err)
+	}
+	// простой WebP: RIFF, размер, WEBP и один чанк VP8L
+	vp8l := img.Bytes()[12:]
+	xmp := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><exif:GPSLatitude>55,45N</exif:GPSLatitude></x:xmpmeta>`)
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x04 | 0x10
+	w, h := 16-1, 8-1
+	vp8x[4], vp8x[5], vp8x[6] = byte(w), byte(w>>8), byte(w>>16)
+	vp8x[7], vp8x[8], vp8x[9] = byte(h), byte(h>>8), byte(h>>16)
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	riffChunk(&body, "VP8X", vp8x)
+	body.Write(vp8l)
+	riffChunk(&body, "EXIF", tiff)
+	riffChunk(&body, "XMP ", xmp)
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func riffChunk(buf *bytes.Buffer, id string, data []byte) {
+	buf.WriteString(id)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+// animatedGif - два кадра, настройки повтора NETSCAPE2.0, комментарий и XMP
+func animatedGif() []byte {
+	palette := color.Palette{red, blue}
+	g := &gif.GIF{LoopCount: 0}
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		for p := range frame.Pix {
+			frame.Pix[p] = uint8(i)
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		log.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	comment := []byte{0x21, 0xfe}
+	comment = append(comment, subBlocks([]byte("shot at 55.75N 37.61E"))...)
+	xmp := []byte{0x21, 0xff, 11}
+	xmp = append(xmp, "XMP DataXMP"...)
+	xmp = append(xmp, subBlocks([]byte(`<x:xmpmeta><exif:GPSLatitude>55,45N</exif:GPSLatitude></x:xmpmeta>`))...)
+
+	// метаданные вставляются перед завершающим байтом
+	out := append([]byte{}, data[:len(data)-1]...)
+	out = append(out, comment...)
+	out = append(out, xmp...)
+	return append(out, 0x3b)
+}
+
+func subBlocks(data []byte) []byte {
+	var out []byte
+	for len(data) > 0 {
+		n := len(data)
+		if n > 255 {
+			n = 255
+		}
+		out = append(out, byte(n))
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return append(out, 0)
+}
+
+// avif собирает контейнер AVIF без настоящего кодированного изображения: элемент 1 (av01) и элемент 2 (Exif).
+// Для проверки разбора боксов декодер не нужен.
+func avif(tiff []byte) []byte {
+	box := func(typ string, payload ...[]byte) []byte {
+		var body []byte
+		for _, p := range payload {
+			body = append(body, p...)
+		}
+		out := make([]byte, 8, 8+len(body))
+		binary.BigEndian.PutUint32(out, uint32(8+len(body)))
+		copy(out[4:], typ)
+		return append(out, body...)
+	}
+	full := func(version byte) []byte { return []byte{version, 0, 0, 0} }
+	u16 := func(v uint16) []byte { b := make([]byte, 2); binary.BigEndian.PutUint16(b, v); return b }
+	u32 := func(v uint32) []byte { b := make([]byte, 4); binary.BigEndian.PutUint32(b, v); return b }
+
+	pixels := []byte("fake av01 bitstream")
+	exifItem := append(u32(0), tiff...)
+
+	ftyp := box("ftyp", []byte("avif"), u32(0), []byte("avifmif1miaf"))
+	hdlr := box("hdlr", full(0), u32(0), []byte("pict"), make([]byte, 12), []byte{0})
+	iinf := box("iinf", full(0), u16(2),
+		box("infe", full(2), u16(1), u16(0), []byte("av01"), []byte{0}),
+		box("infe", full(2), u16(2), u16(0), []byte("Exif"), []byte{0}),
+	)
+	ispe := box("ispe", full(0), u32(640), u32(480))
+	iprp := box("iprp", box("ipco", ispe))
+
+	// смещения данных известны только после сборки meta, поэтому iloc строится дважды
+	ilocFor := func(pixelsAt, exifAt uint32) []byte {
+		return box("iloc", full(0), []byte{0x44, 0x00}, u16(2),
+			u16(1), u16(0), u16(1), u32(pixelsAt), u32(uint32(len(pixels))),
+			u16(2), u16(0), u16(1), u32(exifAt), u32(uint32(len(exifItem))),
+		)
+	}
+	meta := box("meta", full(0), hdlr, iinf, ilocFor(0, 0), iprp)
+	dataAt := uint32(len(ftyp) + len(meta) + 8)
+	meta = box("meta", full(0), hdlr, iinf, ilocFor(dataAt, dataAt+uint32(len(pixels))), iprp)
+
+	out := append(ftyp, meta...)
+	return append(out, box("mdat", pixels, exifItem)...)
+}
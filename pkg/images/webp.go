@@ -0,0 +1,184 @@
+package images
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrInvalidWebP = errors.New("invalid webp")
+
+// флаги чанка VP8X
+const (
+	vp8xAlpha = 0x10
+	vp8xExif  = 0x08
+	vp8xXMP   = 0x04
+)
+
+type riffChunk struct {
+	id string
+	// offset - смещение данных чанка в файле, заголовок из 8 байт идет перед ними
+	offset, size int64
+}
+
+// riffChunks читает заголовки чанков контейнера WebP, сами данные чанков не читаются
+func riffChunks(src io.ReaderAt, size int64) ([]riffChunk, error) {
+	header, err := readAt(src, 0, 12)
+	if err != nil || string(header[:4]) != "RIFF" || string(header[8:12]) != "WEBP" {
+		return nil, ErrInvalidWebP
+	}
+	var chunks []riffChunk
+	for pos := int64(12); pos+8 <= size; {
+		header, err := readAt(src, pos, 8)
+		if err != nil {
+			return nil, ErrInvalidWebP
+		}
+		c := riffChunk{id: string(header[:4]), offset: pos + 8, size: int64(binary.LittleEndian.Uint32(header[4:]))}
+		if c.offset+c.size > size {
+			return nil, ErrInvalidWebP
+		}
+		chunks = append(chunks, c)
+		// чанки выравниваются по четной границе
+		pos = c.offset + c.size + c.size%2
+	}
+	if len(chunks) == 0 {
+		return nil, ErrInvalidWebP
+	}
+	return chunks, nil
+}
+
+// StripWebP убирает из WebP метаданные EXIF и XMP и, если в meta есть автор или права, записывает их
+// новым блоком EXIF. Ориентация не записывается: к этому моменту изображение уже повернуто.
+// Данные изображения не читаются, а копируются из src при чтении результата.
+func StripWebP(src io.ReaderAt, size int64, meta Metadata) (*Rewrite, error) {
+	chunks, err := riffChunks(src, size)
+	if err != nil {
+		return nil, err
+	}
+	exif := buildExif(meta)
+
+	var kept []riffChunk
+	for _, c := range chunks {
+		if c.id != "EXIF" && c.id != "XMP " {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, ErrInvalidWebP
+	}
+
+	var header []byte
+	if kept[0].id == "VP8X" {
+		if kept[0].size != 10 {
+			return nil, ErrInvalidWebP
+		}
+		if header, err = readAt(src, kept[0].offset, 10); err != nil {
+			return nil, ErrInvalidWebP
+		}
+		header[0] &^= vp8xExif | vp8xXMP
+		if exif != nil {
+			header[0] |= vp8xExif
+		}
+		kept = kept[1:]
+	} else if exif != nil {
+		// в простом формате метаданных нет, для них нужен расширенный заголовок VP8X
+		head, err := readAt(src, kept[0].offset, int(minSize(kept[0].size, 10)))
+		if err != nil {
+			return nil, ErrInvalidWebP
+		}
+		if header, err = vp8xHeader(kept[0].id, head); err != nil {
+			return nil, err
+		}
+		header[0] |= vp8xExif
+	}
+
+	body := int64(4)
+	if header != nil {
+		body += 8 + int64(len(header))
+	}
+	for _, c := range kept {
+		body += 8 + c.size + c.size%2
+	}
+	if exif != nil {
+		body += 8 + int64(len(exif)) + int64(len(exif)%2)
+	}
+
+	rw := newRewrite(src)
+	out := append([]byte("RIFF"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[4:], uint32(body))
+	out = append(out, "WEBP"...)
+	if header != nil {
+		out = appendChunk(out, "VP8X", header)
+	}
+	rw.write(out)
+	for _, c := range kept {
+		if c.offset+c.size+c.size%2 <= size {
+			rw.copy(c.offset-8, 8+c.size+c.size%2)
+		} else {
+			// у последнего чанка нечетной длины может не быть выравнивающего байта
+			rw.copy(c.offset-8, 8+c.size)
+			rw.write([]byte{0})
+		}
+	}
+	if exif != nil {
+		rw.write(appendChunk(nil, "EXIF", exif))
+	}
+	return rw, nil
+}
+
+func appendChunk(b []byte, id string, data []byte) []byte {
+	b = append(b, id...)
+	b = appendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func minSize(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// vp8xHeader строит заголовок VP8X по размерам из начала потока VP8 или VP8L
+func vp8xHeader(id string, data []byte) ([]byte, error) {
+	var width, height int
+	var flags byte
+	switch id {
+	case "VP8L":
+		if len(data) < 5 || data[0] != 0x2f {
+			return nil, ErrInvalidWebP
+		}
+		bits := binary.LittleEndian.Uint32(data[1:])
+		width = int(bits&0x3fff) + 1
+		height = int(bits>>14&0x3fff) + 1
+		if bits>>28&1 == 1 {
+			flags |= vp8xAlpha
+		}
+	case "VP8 ":
+		if len(data) < 10 || data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+			return nil, ErrInvalidWebP
+		}
+		width = int(binary.LittleEndian.Uint16(data[6:]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(data[8:]) & 0x3fff)
+	default:
+		return nil, ErrInvalidWebP
+	}
+
+	header := make([]byte, 10)
+	header[0] = flags
+	putUint24(header[4:], uint32(width-1))
+	putUint24(header[7:], uint32(height-1))
+	return header, nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
@@ -0,0 +1,197 @@
+// Package markup превращает пользовательский текст в безопасный HTML. Поддерживается небольшое
+// подмножество Markdown: абзацы, переносы строк, **жирный**, *курсив* и _курсив_, `код`,
+// ссылки [текст](https://...) и адреса http(s), которые становятся ссылками сами. Все остальное,
+// включая HTML, выводится как текст.
+package markup
+
+import (
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// атрибуты ссылок из пользовательского текста: поисковики не учитывают их, а страница не передается по ссылке
+const linkAttrs = ` rel="nofollow ugc noopener noreferrer" target="_blank"`
+
+// Normalize приводит текст к виду, в котором он хранится: переводы строк \n, без управляющих символов
+// и символов смены направления текста, без пробелов в конце строк и по краям, не больше одной пустой строки подряд
+func Normalize(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case unicode.IsControl(r), r >= '\u202a' && r <= '\u202e', r >= '\u2066' && r <= '\u2069':
+			return -1
+		}
+		return r
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	res := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		res = append(res, line)
+	}
+	return strings.TrimSpace(strings.Join(res, "\n"))
+}
+
+// Render возвращает HTML нормализованного текста: абзацы <p>, переносы <br> и строчная разметка
+func Render(text string) string {
+	var sb strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if paragraph == "" {
+			continue
+		}
+		sb.WriteString("<p>")
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				sb.WriteString("<br>")
+			}
+			renderInline(&sb, line, true)
+		}
+		sb.WriteString("</p>")
+	}
+	return sb.String()
+}
+
+// renderInline выводит строку со строчной разметкой, links запрещает ссылки внутри текста ссылки
+func renderInline(sb *strings.Builder, s string, links bool) {
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				sb.WriteString("<code>")
+				sb.WriteString(html.EscapeString(s[i+1 : i+1+end]))
+				sb.WriteString("</code>")
+				i += end + 2
+				continue
+			}
+		case s[i] == '[' && links:
+			if text, href, n, ok := parseLink(s[i:]); ok {
+				sb.WriteString(`<a href="` + html.EscapeString(href) + `"` + linkAttrs + ">")
+				renderInline(sb, text, false)
+				sb.WriteString("</a>")
+				i += n
+				continue
+			}
+		case strings.HasPrefix(s[i:], "**"):
+			if inner, ok := span(s[i+2:], "**"); ok {
+				sb.WriteString("<strong>")
+				renderInline(sb, inner, links)
+				sb.WriteString("</strong>")
+				i += len(inner) + 4
+				continue
+			}
+		case s[i] == '*' || s[i] == '_':
+			// _ внутри слова, как в snake_case, курсивом не считается
+			if s[i] == '*' || !wordBefore(s[:i]) {
+				if inner, ok := span(s[i+1:], s[i:i+1]); ok && (s[i] == '*' || !wordAfter(s[i+len(inner)+2:])) {
+					sb.WriteString("<em>")
+					renderInline(sb, inner, links)
+					sb.WriteString("</em>")
+					i += len(inner) + 2
+					continue
+				}
+			}
+		case links && (strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")) && !wordBefore(s[:i]):
+			if href := bareURL(s[i:]); href != "" {
+				escaped := html.EscapeString(href)
+				sb.WriteString(`<a href="` + escaped + `"` + linkAttrs + ">" + escaped + "</a>")
+				i += len(href)
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		sb.WriteString(html.EscapeString(s[i : i+size]))
+		i += size
+	}
+}
+
+// span находит текст до закрывающего delim. Текст не пустой и не начинается и не заканчивается пробелом,
+// иначе это не разметка, а, например, умножение 2 * 3 * 4.
+func span(s, delim string) (string, bool) {
+	end := strings.Index(s, delim)
+	if end <= 0 {
+		return "", false
+	}
+	inner := s[:end]
+	first, _ := utf8.DecodeRuneInString(inner)
+	last, _ := utf8.DecodeLastRuneInString(inner)
+	if unicode.IsSpace(first) || unicode.IsSpace(last) {
+		return "", false
+	}
+	return inner, true
+}
+
+// parseLink разбирает [текст](адрес) в начале s и возвращает длину разметки
+func parseLink(s string) (text, href string, n int, ok bool) {
+	closeText := strings.Index(s, "](")
+	if closeText <= 1 {
+		return "", "", 0, false
+	}
+	closeHref := strings.IndexByte(s[closeText+2:], ')')
+	if closeHref < 0 {
+		return "", "", 0, false
+	}
+	text = s[1:closeText]
+	href = strings.TrimSpace(s[closeText+2 : closeText+2+closeHref])
+	if strings.ContainsAny(text, "[]") || !allowedURL(href) {
+		return "", "", 0, false
+	}
+	return text, href, closeText + 3 + closeHref, true
+}
+
+// bareURL возвращает адрес до первого пробела без знаков препинания в конце, они обычно относятся к предложению
+func bareURL(s string) string {
+	end := strings.IndexFunc(s, unicode.IsSpace)
+	if end < 0 {
+		end = len(s)
+	}
+	href := strings.TrimRight(s[:end], ".,:;!?'\")")
+	if !allowedURL(href) {
+		return ""
+	}
+	return href
+}
+
+// allowedURL пропускает только абсолютные ссылки http, https и mailto: javascript: и подобные схемы отбрасываются
+func allowedURL(href string) bool {
+	if href == "" || strings.IndexFunc(href, unicode.IsSpace) >= 0 {
+		return false
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
+
+func wordBefore(s string) bool {
+	r, size := utf8.DecodeLastRuneInString(s)
+	return size > 0 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func wordAfter(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size > 0 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
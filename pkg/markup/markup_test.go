@@ -0,0 +1,64 @@
+package markup_test
+
+import (
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/markup"
+)
+
+const attrs = ` rel="nofollow ugc noopener noreferrer" target="_blank"`
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"trim", "  hello  \n", "hello"},
+		{"line endings", "a\r\nb\rc", "a\nb\nc"},
+		{"blank lines", "a\n\n\n\n b", "a\n\n b"},
+		{"trailing spaces", "a   \n\t\nb", "a\n\nb"},
+		{"control chars", "a\x00b\x1bc\td", "abc\td"},
+		{"bidi override", "abc\u202edcba\u2066", "abcdcba"},
+		{"invalid utf8", "a\xffb", "ab"},
+		{"emoji kept", "👩‍💻 ok", "👩‍💻 ok"},
+	}
+	for _, tt := range tests {
+		if got := markup.Normalize(tt.in); got != tt.want {
+			t.Errorf("%s: Normalize(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"paragraphs", "one\ntwo\n\nthree", "<p>one<br>two</p><p>three</p>"},
+		{"html escaped", `<script>alert("x")</script>`, "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>"},
+		{"bold", "a **b** c", "<p>a <strong>b</strong> c</p>"},
+		{"italic", "*a* and _b_", "<p><em>a</em> and <em>b</em></p>"},
+		{"nested", "**a *b* c**", "<p><strong>a <em>b</em> c</strong></p>"},
+		{"snake case", "snake_case_name", "<p>snake_case_name</p>"},
+		{"multiplication", "2 * 3 * 4", "<p>2 * 3 * 4</p>"},
+		{"unclosed", "**a", "<p>**a</p>"},
+		{"code", "run `<b>**x**</b>`", "<p>run <code>&lt;b&gt;**x**&lt;/b&gt;</code></p>"},
+		{"link", "[site](https://example.com/a?b=1&c=2)",
+			`<p><a href="https://example.com/a?b=1&amp;c=2"` + attrs + `>site</a></p>`},
+		{"link text formatting", "[**site**](http://example.com)",
+			`<p><a href="http://example.com"` + attrs + `><strong>site</strong></a></p>`},
+		{"mailto", "[mail](mailto:me@example.com)", `<p><a href="mailto:me@example.com"` + attrs + `>mail</a></p>`},
+		{"javascript link", "[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>"},
+		{"relative link", "[x](/admin)", "<p>[x](/admin)</p>"},
+		{"quote in href", `[x](https://example.com/"onclick=")`,
+			`<p><a href="https://example.com/&#34;onclick=&#34;"` + attrs + `>x</a></p>`},
+		{"bare url", "see https://example.com/page.",
+			`<p>see <a href="https://example.com/page"` + attrs + `>https://example.com/page</a>.</p>`},
+		{"url inside word", "xhttps://example.com", "<p>xhttps://example.com</p>"},
+		{"no nested links", "[https://a.com](https://b.com)",
+			`<p><a href="https://b.com"` + attrs + `>https://a.com</a></p>`},
+	}
+	for _, tt := range tests {
+		if got := markup.Render(tt.in); got != tt.want {
+			t.Errorf("%s: Render(%q) =\n%s\nwant\n%s", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
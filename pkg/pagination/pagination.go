@@ -0,0 +1,141 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+type Order int
+
+const (
+	Asc  Order = 1
+	Desc Order = -1
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort key")
+)
+
+// Params описывает запрос одной страницы списка
+type Params struct {
+	Limit  int
+	Sort   string
+	Order  Order
+	Cursor string
+}
+
+// Page содержит курсоры для перехода на соседние страницы
+type Page struct {
+	Limit int
+	Sort  string
+	Order Order
+	Next  string
+	Prev  string
+}
+
+// Cursor указывает на граничный документ страницы. Value хранит значение ключа сортировки,
+// Id нужен для стабильного порядка при совпадающих значениях.
+type Cursor struct {
+	Sort     string             `bson:"s"`
+	Order    Order              `bson:"o"`
+	Value    interface{}        `bson:"v"`
+	Id       primitive.ObjectID `bson:"id"`
+	Backward bool               `bson:"b,omitempty"`
+}
+
+func ParseQuery(query url.Values) (Params, error) {
+	params := Params{
+		Sort:   query.Get("sort"),
+		Cursor: query.Get("cursor"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 {
+			return Params{}, errors.New("invalid limit")
+		}
+		params.Limit = l
+	}
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		params.Order = Asc
+	case "desc":
+		params.Order = Desc
+	default:
+		return Params{}, errors.New("invalid order")
+	}
+	return params, nil
+}
+
+// Normalize ограничивает лимит и подставляет сортировку по умолчанию.
+// sorts - допустимые ключи сортировки, defaultSort должен входить в их число.
+func (p Params) Normalize(sorts map[string]string, defaultSort string, defaultOrder Order) (Params, error) {
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+	if p.Sort == "" {
+		p.Sort = defaultSort
+	}
+	if _, ok := sorts[p.Sort]; !ok {
+		return Params{}, ErrInvalidSort
+	}
+	if p.Order == 0 {
+		p.Order = defaultOrder
+	}
+	return p, nil
+}
+
+func (c Cursor) Encode() (string, error) {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Id.IsZero() || (cursor.Order != Asc && cursor.Order != Desc) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// Links строит абсолютные ссылки на соседние страницы на основе текущего запроса
+func (p *Page) Links(u *url.URL) (next, prev string) {
+	build := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		query := u.Query()
+		query.Set("cursor", cursor)
+		query.Set("limit", strconv.Itoa(p.Limit))
+		query.Del("sort")
+		query.Del("order")
+		link := *u
+		link.RawQuery = query.Encode()
+		return link.String()
+	}
+	return build(p.Next), build(p.Prev)
+}
@@ -0,0 +1,136 @@
+package pagination_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var sorts = map[string]string{"createdAt": "createdAt", "name": "name"}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    pagination.Params
+		wantErr bool
+	}{
+		{"empty", "", pagination.Params{}, false},
+		{"all params", "limit=5&sort=name&order=asc&cursor=abc",
+			pagination.Params{Limit: 5, Sort: "name", Order: pagination.Asc, Cursor: "abc"}, false},
+		{"desc", "order=desc", pagination.Params{Order: pagination.Desc}, false},
+		{"large limit is kept for normalize", "limit=1000", pagination.Params{Limit: 1000}, false},
+		{"zero limit", "limit=0", pagination.Params{}, true},
+		{"negative limit", "limit=-1", pagination.Params{}, true},
+		{"text limit", "limit=ten", pagination.Params{}, true},
+		{"bad order", "order=up", pagination.Params{}, true},
+		{"order case", "order=DESC", pagination.Params{}, true},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		got, err := pagination.ParseQuery(query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ParseQuery(%q) error = %v, want error %t", tt.name, tt.query, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: ParseQuery(%q) = %+v, want %+v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  pagination.Params
+		want    pagination.Params
+		wantErr error
+	}{
+		{"defaults", pagination.Params{},
+			pagination.Params{Limit: pagination.DefaultLimit, Sort: "createdAt", Order: pagination.Desc}, nil},
+		{"limit clamped", pagination.Params{Limit: pagination.MaxLimit + 1},
+			pagination.Params{Limit: pagination.MaxLimit, Sort: "createdAt", Order: pagination.Desc}, nil},
+		{"limit kept", pagination.Params{Limit: 7, Sort: "name", Order: pagination.Asc},
+			pagination.Params{Limit: 7, Sort: "name", Order: pagination.Asc}, nil},
+		{"unknown sort", pagination.Params{Sort: "password"}, pagination.Params{}, pagination.ErrInvalidSort},
+		{"document field is not a sort key", pagination.Params{Sort: "registeredAt"}, pagination.Params{}, pagination.ErrInvalidSort},
+	}
+	for _, tt := range tests {
+		got, err := tt.params.Normalize(sorts, "createdAt", pagination.Desc)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Normalize(%+v) error = %v, want %v", tt.name, tt.params, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Normalize(%+v) = %+v, want %+v", tt.name, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		name   string
+		cursor pagination.Cursor
+	}{
+		{"string value", pagination.Cursor{Sort: "name", Order: pagination.Asc, Value: "Project", Id: id}},
+		{"number value", pagination.Cursor{Sort: "likes", Order: pagination.Desc, Value: int64(42), Id: id}},
+		{"empty value", pagination.Cursor{Sort: "order", Order: pagination.Asc, Value: nil, Id: id}},
+		{"backward", pagination.Cursor{Sort: "name", Order: pagination.Desc, Value: "a", Id: id, Backward: true}},
+	}
+	for _, tt := range tests {
+		encoded, err := tt.cursor.Encode()
+		if err != nil {
+			t.Fatalf("%s: Encode: %s", tt.name, err)
+		}
+		got, err := pagination.DecodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("%s: DecodeCursor: %s", tt.name, err)
+		}
+		if *got != tt.cursor {
+			t.Errorf("%s: DecodeCursor(Encode()) = %+v, want %+v", tt.name, *got, tt.cursor)
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	valid, err := pagination.Cursor{Sort: "name", Order: pagination.Asc, Value: "a", Id: primitive.NewObjectID()}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(doc bson.M) string {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	tests := []struct {
+		name, cursor string
+	}{
+		{"garbage", "not a cursor!"},
+		{"not bson", base64.RawURLEncoding.EncodeToString([]byte("hello world"))},
+		{"truncated", valid[:len(valid)/2]},
+		{"tampered byte", valid[:10] + "A" + valid[11:]},
+		{"padded base64", valid + "=="},
+		{"empty document", encode(bson.M{})},
+		{"no id", encode(bson.M{"s": "name", "o": 1, "v": "a"})},
+		{"bad order", encode(bson.M{"s": "name", "o": 5, "v": "a", "id": primitive.NewObjectID()})},
+		{"wrong id type", encode(bson.M{"s": "name", "o": 1, "v": "a", "id": "abc"})},
+		{"wrong sort type", encode(bson.M{"s": 1, "o": 1, "v": "a", "id": primitive.NewObjectID()})},
+	}
+	for _, tt := range tests {
+		if _, err := pagination.DecodeCursor(tt.cursor); !errors.Is(err, pagination.ErrInvalidCursor) {
+			t.Errorf("%s: DecodeCursor(%q) error = %v, want %v", tt.name, tt.cursor, err, pagination.ErrInvalidCursor)
+		}
+	}
+}
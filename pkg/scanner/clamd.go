@@ -0,0 +1,133 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// размер части, которой файл передается clamd. StreamMaxLength в clamd.conf ограничивает весь файл, а не часть.
+const clamdChunkSize = 64 << 10
+
+// Clamd проверяет файлы демоном ClamAV по протоколу clamd: файл передается командой INSTREAM,
+// поэтому демону не нужен доступ к файловой системе приложения
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamd создает клиент clamd. network - tcp или unix, timeout ограничивает проверку одного файла,
+// нулевое значение снимает ограничение.
+func NewClamd(network, address string, timeout time.Duration) *Clamd {
+	return &Clamd{
+		network: network,
+		address: address,
+		timeout: timeout,
+	}
+}
+
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, fmt.Errorf("clamd: %w", err)
+		}
+	}
+	// отмена контекста прерывает ожидание ответа
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	if err := sendStream(conn, r); err != nil {
+		// clamd обрывает соединение, если файл больше StreamMaxLength, и перед этим пишет причину
+		if reply, readErr := readReply(conn); readErr == nil {
+			if _, replyErr := parseReply(reply); replyErr != nil {
+				return nil, replyErr
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("clamd: %w", ctx.Err())
+		}
+		return nil, err
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("clamd: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	return parseReply(reply)
+}
+
+// sendStream передает файл частями: 4 байта длины части в сетевом порядке и сама часть, в конце - часть нулевой длины
+func sendStream(conn net.Conn, r io.Reader) error {
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("clamd: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	return nil
+}
+
+// readReply читает ответ clamd, на команды с префиксом z он заканчивается нулевым байтом
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", err
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseReply разбирает ответ вида "stream: OK", "stream: <сигнатура> FOUND" или "<причина> ERROR"
+func parseReply(reply string) (*Result, error) {
+	switch {
+	case strings.HasSuffix(reply, " ERROR"):
+		return nil, fmt.Errorf("clamd: %s", strings.TrimSuffix(reply, " ERROR"))
+	case reply == "stream: OK":
+		return &Result{}, nil
+	case strings.HasPrefix(reply, "stream: ") && strings.HasSuffix(reply, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")}, nil
+	}
+	return nil, fmt.Errorf("clamd: unexpected reply %q", reply)
+}
@@ -0,0 +1,168 @@
+package scanner_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/pkg/scanner"
+)
+
+// тестовая сигнатура EICAR, ее находит любой антивирус
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd отвечает на INSTREAM как clamd: находит EICAR и обрывает соединение, если файл больше maxLength
+type fakeClamd struct {
+	listener  net.Listener
+	maxLength int
+	// hang - не отвечать совсем
+	hang bool
+}
+
+func newFakeClamd(t *testing.T) *fakeClamd {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	f := &fakeClamd{listener: listener, maxLength: 1 << 20}
+	t.Cleanup(func() { listener.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeClamd) client(timeout time.Duration) *scanner.Clamd {
+	return scanner.NewClamd("tcp", f.listener.Addr().String(), timeout)
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	if command != "zINSTREAM\x00" {
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var data bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if data.Len()+int(size) > f.maxLength {
+			io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+		if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+			return
+		}
+	}
+
+	if f.hang {
+		io.Copy(io.Discard, r)
+		return
+	}
+	if strings.Contains(data.String(), eicar) {
+		io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+		return
+	}
+	io.WriteString(conn, "stream: OK\x00")
+}
+
+func TestClamdClean(t *testing.T) {
+	f := newFakeClamd(t)
+
+	res, err := f.client(time.Second).Scan(context.Background(), strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("scan: %s", err)
+	}
+	if res.Infected {
+		t.Errorf("clean file reported as infected with %q", res.Signature)
+	}
+}
+
+func TestClamdEmpty(t *testing.T) {
+	f := newFakeClamd(t)
+
+	res, err := f.client(time.Second).Scan(context.Background(), strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("scan: %s", err)
+	}
+	if res.Infected {
+		t.Error("empty file reported as infected")
+	}
+}
+
+func TestClamdInfected(t *testing.T) {
+	f := newFakeClamd(t)
+	// сигнатура на границе частей: clamd должен получить файл целиком
+	data := strings.Repeat("a", 64<<10-10) + eicar + strings.Repeat("b", 100)
+
+	res, err := f.client(time.Second).Scan(context.Background(), strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("scan: %s", err)
+	}
+	if !res.Infected || res.Signature != "Eicar-Test-Signature" {
+		t.Errorf("result = %+v, want Eicar-Test-Signature", res)
+	}
+}
+
+func TestClamdSizeLimit(t *testing.T) {
+	f := newFakeClamd(t)
+	f.maxLength = 100 << 10
+
+	_, err := f.client(time.Second).Scan(context.Background(), bytes.NewReader(make([]byte, 4<<20)))
+	if err == nil || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Fatalf("err = %v, want size limit error", err)
+	}
+}
+
+func TestClamdTimeout(t *testing.T) {
+	f := newFakeClamd(t)
+	f.hang = true
+
+	start := time.Now()
+	_, err := f.client(100*time.Millisecond).Scan(context.Background(), strings.NewReader("hello"))
+	if err == nil {
+		t.Fatal("scan without reply succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("scan took %s, want about the timeout", elapsed)
+	}
+}
+
+func TestClamdUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	if _, err := scanner.NewClamd("tcp", addr, time.Second).Scan(context.Background(), strings.NewReader("hello")); err == nil {
+		t.Fatal("scan without clamd succeeded")
+	}
+}
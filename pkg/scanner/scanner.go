@@ -0,0 +1,18 @@
+// Package scanner проверяет загружаемые файлы антивирусом
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// Result - результат проверки. Signature - название найденной угрозы.
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner проверяет содержимое файла. Ошибка означает, что проверить файл не удалось, а не то, что он заражен.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// privateDir - каталог внутри root для приватных файлов. Файлы и каталоги с точкой в начале имени не раздаются.
+const privateDir = ".private"
+
+// DiskStorage хранит файлы в каталоге root и сам раздает их по адресу url (см. статический маршрут в delivery)
+type DiskStorage struct {
+	root   string
+	url    string
+	signer urlSigner
+}
+
+// NewDiskStorage создает хранилище в каталоге root. signingKey подписывает ссылки на приватные файлы,
+// если он пустой, ключ генерируется при запуске.
+func NewDiskStorage(root, url, signingKey string) (*DiskStorage, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	signer, err := newURLSigner(signingKey)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskStorage{
+		root:   root,
+		url:    strings.TrimSuffix(url, "/"),
+		signer: signer,
+	}, nil
+}
+
+func (ds *DiskStorage) Upload(ctx context.Context, body io.Reader, size int64, contentType, path, filename string, private bool) (*File, error) {
+	key := objectKey(path, filename)
+
+	full := ds.fullPath(key, private)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return nil, err
+	}
+	// файл пишется во временный и затем переименовывается, чтобы читатели не увидели его недописанным
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := copyBody(tmp, body, size); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), full); err != nil {
+		return nil, err
+	}
+	// файл с тем же именем мог раньше храниться с другим доступом
+	ds.removeFile(ds.fullPath(key, !private))
+
+	return &File{Name: filename, Url: ds.url + "/" + key, Key: key}, nil
+}
+
+func (ds *DiskStorage) Remove(ctx context.Context, path, filename string) error {
+	if filename != "" {
+		key := objectKey(path, filename)
+		if err := ds.removeFile(ds.fullPath(key, false)); err != nil {
+			return err
+		}
+		return ds.removeFile(ds.fullPath(key, true))
+	}
+
+	// как и в облачных хранилищах, path - это префикс имени, а не обязательно каталог
+	prefix := strings.TrimPrefix(path, "/")
+	if err := ds.removePrefix(ds.root, prefix); err != nil {
+		return err
+	}
+	return ds.removePrefix(filepath.Join(ds.root, privateDir), prefix)
+}
+
+func (ds *DiskStorage) SetPrivate(ctx context.Context, key string, private bool) error {
+	from, to := ds.fullPath(key, !private), ds.fullPath(key, private)
+	if _, err := os.Stat(to); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	ds.removeEmptyDirs(filepath.Dir(from))
+	return nil
+}
+
+func (ds *DiskStorage) List(ctx context.Context, prefix string, fn func(obj Object) error) error {
+	prefix = strings.TrimPrefix(prefix, "/")
+	if err := ds.listPrefix(ctx, ds.root, prefix, fn); err != nil {
+		return err
+	}
+	return ds.listPrefix(ctx, filepath.Join(ds.root, privateDir), prefix, fn)
+}
+
+func (ds *DiskStorage) SignedUrl(ctx context.Context, key string, expires time.Duration) (string, error) {
+	key = cleanKey(key)
+	return ds.url + "/" + key + "?" + ds.signer.sign(key, time.Now().Add(expires)).Encode(), nil
+}
+
+// ServeHTTP раздает файлы по ключу из пути запроса. Приватные файлы отдаются только по действующей подписи.
+func (ds *DiskStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := cleanKey(r.URL.Path)
+	for _, part := range strings.Split(key, "/") {
+		if strings.HasPrefix(part, ".") {
+			notFound(w, r)
+			return
+		}
+	}
+
+	private := false
+	if query := r.URL.Query(); query.Get("signature") != "" {
+		if !ds.signer.verify(key, query, time.Now()) {
+			w.Header().Set("Cache-Control", "no-store")
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+		private = true
+	}
+
+	f, err := os.Open(ds.fullPath(key, private))
+	if err != nil && private {
+		// подписанная ссылка остается рабочей и после того, как файл стал публичным
+		f, err = os.Open(ds.fullPath(key, false))
+	}
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		notFound(w, r)
+		return
+	}
+
+	if private {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", signedMaxAge(r.URL.Query().Get("expires"))))
+	}
+	setContentHeaders(w)
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// copyBody копирует ровно size байт из body в w
+func copyBody(w io.Writer, body io.Reader, size int64) error {
+	n, err := io.Copy(w, io.LimitReader(body, size))
+	if err != nil {
+		return err
+	}
+	if n < size {
+		return ErrShortBody
+	}
+	return nil
+}
+
+// setContentHeaders запрещает браузеру исполнять раздаваемые файлы: SVG открывается с того же адреса, что и API
+func setContentHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src data:; style-src 'unsafe-inline'; sandbox")
+}
+
+// notFound отвечает 404 без кэширования: файл может появиться позже
+func notFound(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	http.NotFound(w, r)
+}
+
+// fullPath переводит ключ объекта в путь на диске, не выходя за пределы root
+func (ds *DiskStorage) fullPath(key string, private bool) string {
+	if private {
+		return filepath.Join(ds.root, privateDir, filepath.FromSlash(cleanKey(key)))
+	}
+	return filepath.Join(ds.root, filepath.FromSlash(cleanKey(key)))
+}
+
+func (ds *DiskStorage) removeFile(full string) error {
+	if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	ds.removeEmptyDirs(filepath.Dir(full))
+	return nil
+}
+
+func (ds *DiskStorage) removePrefix(base, prefix string) error {
+	var removed []string
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == "." {
+				return nil
+			}
+			if strings.HasPrefix(key, ".") {
+				return filepath.SkipDir
+			}
+			if !strings.HasPrefix(prefix, key+"/") && !strings.HasPrefix(key, prefix) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(key, prefix) {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			removed = append(removed, filepath.Dir(p))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, dir := range removed {
+		ds.removeEmptyDirs(dir)
+	}
+	return nil
+}
+
+// listPrefix обходит файлы с префиксом prefix в каталоге base так же, как removePrefix. Временные файлы
+// незавершенных загрузок начинаются с точки и пропускаются.
+func (ds *DiskStorage) listPrefix(ctx context.Context, base, prefix string, fn func(obj Object) error) error {
+	return filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == "." {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") || !strings.HasPrefix(prefix, key+"/") && !strings.HasPrefix(key, prefix) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		return fn(Object{Key: key, Size: info.Size(), UpdatedAt: info.ModTime()})
+	})
+}
+
+// removeEmptyDirs удаляет опустевшие каталоги от dir вверх до root
+func (ds *DiskStorage) removeEmptyDirs(dir string) {
+	private := filepath.Join(ds.root, privateDir)
+	for dir != ds.root && dir != private && strings.HasPrefix(dir, ds.root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func objectKey(dir, filename string) string {
+	return strings.TrimPrefix(path.Join(dir, filename), "/")
+}
+
+func cleanKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
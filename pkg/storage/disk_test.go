@@ -0,0 +1,92 @@
+package storage_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"github.com/Alexander272/my-portfolio/pkg/storage/storagetest"
+)
+
+func TestDiskStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (storage.Provider, storagetest.Fetcher) {
+		ds, err := storage.NewDiskStorage(t.TempDir(), "/files", "secret")
+		if err != nil {
+			t.Fatalf("new disk storage: %s", err)
+		}
+		return ds, storagetest.HandlerFetcher(ds, "/files")
+	})
+}
+
+func TestDiskStorageStaysInRoot(t *testing.T) {
+	root := t.TempDir()
+	ds, err := storage.NewDiskStorage(filepath.Join(root, "files"), "/files", "secret")
+	if err != nil {
+		t.Fatalf("new disk storage: %s", err)
+	}
+	outside := filepath.Join(root, "outside.webp")
+	if err := os.WriteFile(outside, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ds.Remove(context.Background(), "../", "outside.webp"); err != nil {
+		t.Fatalf("remove: %s", err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("file outside root was removed: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ds.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/../outside.webp", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("file outside root served with status %d", rec.Code)
+	}
+}
+
+func TestDiskStorageSignature(t *testing.T) {
+	ctx := context.Background()
+	ds, err := storage.NewDiskStorage(t.TempDir(), "/files", "secret")
+	if err != nil {
+		t.Fatalf("new disk storage: %s", err)
+	}
+	file, err := ds.Upload(ctx, strings.NewReader("doc"), 3, "image/webp", "u1/projects/p1", "doc.webp", true)
+	if err != nil {
+		t.Fatalf("upload: %s", err)
+	}
+
+	other, err := storage.NewDiskStorage(t.TempDir(), "/files", "other secret")
+	if err != nil {
+		t.Fatalf("new disk storage: %s", err)
+	}
+	foreign, _ := other.SignedUrl(ctx, file.Key, time.Minute)
+	expired, _ := ds.SignedUrl(ctx, file.Key, -time.Minute)
+	valid, _ := ds.SignedUrl(ctx, file.Key, time.Minute)
+
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"unsigned", file.Url, http.StatusNotFound},
+		{"hidden dir", "/files/.private/" + file.Key, http.StatusNotFound},
+		{"foreign key", foreign, http.StatusForbidden},
+		{"expired", expired, http.StatusForbidden},
+		{"valid", valid, http.StatusOK},
+	}
+	handler := http.StripPrefix("/files", ds)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+		})
+	}
+}
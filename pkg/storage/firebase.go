@@ -1,28 +1,30 @@
 package storage
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
-	"mime/multipart"
+	"net/http"
+	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
 	firebase "firebase.google.com/go/v4"
-	"github.com/chai2010/webp"
 	"github.com/google/uuid"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
 type FileStorage struct {
 	storage    *storage.BucketHandle
 	bucketName string
+	// учетные данные сервисного аккаунта для подписи ссылок
+	accessId   string
+	privateKey []byte
 }
 
 func NewFileStorage(bucketName, pathToCredentials string) (*FileStorage, error) {
@@ -44,50 +46,56 @@ func NewFileStorage(bucketName, pathToCredentials string) (*FileStorage, error)
 	if err != nil {
 		return nil, err
 	}
-	return &FileStorage{
-		storage:    bucket,
-		bucketName: bucketName,
-	}, nil
-}
 
-func (fs *FileStorage) Upload(ctx context.Context, file multipart.File, header *multipart.FileHeader, path, name string) (*File, error) {
-	fileBytes, err := io.ReadAll(file)
+	credentials, err := os.ReadFile(pathToCredentials)
 	if err != nil {
 		return nil, err
 	}
-
-	newFile, err := fs.imageCompressing(fileBytes, 85, header.Header.Get("Content-Type"))
+	jwt, err := google.JWTConfigFromJSON(credentials)
 	if err != nil {
 		return nil, err
 	}
-	var filename string
-	if name != "" {
-		filename = name + ".webp"
-	} else {
-		filename = strings.Split(header.Filename, ".")[0] + fmt.Sprintf("_%d.webp", time.Now().Unix())
-	}
+
+	return &FileStorage{
+		storage:    bucket,
+		bucketName: bucketName,
+		accessId:   jwt.Email,
+		privateKey: jwt.PrivateKey,
+	}, nil
+}
+
+func (fs *FileStorage) Upload(ctx context.Context, body io.Reader, size int64, contentType, path, filename string, private bool) (*File, error) {
 	id := uuid.New()
 
 	wc := fs.storage.Object(filepath.Join(path, filename)).NewWriter(ctx)
+	wc.ObjectAttrs.ContentType = contentType
 	wc.ObjectAttrs.Metadata = map[string]string{"firebaseStorageDownloadTokens": id.String()}
 	wc.ObjectAttrs.MediaLink = fmt.Sprintf("https://storage.cloud.google.com/%s.appspot.com/%s", fs.bucketName, filepath.Join(path, filename))
-	_, err = io.Copy(wc, bytes.NewReader(newFile))
-	if err != nil {
+	// по умолчанию Writer буферизует каждую загрузку кусками по 16 МБ, файлы у нас меньше и пишутся одним запросом
+	wc.ChunkSize = 0
+	if err := copyBody(wc, body, size); err != nil {
+		wc.CloseWithError(err)
 		return nil, err
 	}
 	if err := wc.Close(); err != nil {
 		return nil, err
 	}
-	if err := fs.storage.Object(filepath.Join(path, filename)).ACL().Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
-		return nil, err
+	if !private {
+		if err := fs.storage.Object(filepath.Join(path, filename)).ACL().Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
+			return nil, err
+		}
 	}
 
-	return &File{Name: filename, Url: wc.MediaLink}, nil
+	return &File{Name: filename, Url: wc.MediaLink, Key: filepath.Join(path, filename)}, nil
 }
 
 func (fs *FileStorage) Remove(ctx context.Context, path, filename string) error {
 	if filename != "" {
-		return fs.storage.Object(filepath.Join(path, filename+".webp")).Delete(ctx)
+		err := fs.storage.Object(filepath.Join(path, filename)).Delete(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil
+		}
+		return err
 	}
 
 	objects := fs.storage.Objects(ctx, &storage.Query{Prefix: path})
@@ -97,7 +105,7 @@ func (fs *FileStorage) Remove(ctx context.Context, path, filename string) error
 	}
 	for obj != nil {
 		err = fs.storage.Object(obj.Name).Delete(ctx)
-		if err != nil {
+		if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
 			return err
 		}
 		obj, err = objects.Next()
@@ -108,40 +116,47 @@ func (fs *FileStorage) Remove(ctx context.Context, path, filename string) error
 	return nil
 }
 
-func (fs *FileStorage) imageCompressing(buffer []byte, quality float32, contentType string) ([]byte, error) {
-	var img image.Image
+func (fs *FileStorage) SetPrivate(ctx context.Context, key string, private bool) error {
+	acl := fs.storage.Object(key).ACL()
 	var err error
-	switch contentType {
-	case "image/png":
-		img, err = png.Decode(bytes.NewReader(buffer))
-		if err != nil {
-			return nil, err
-		}
-	case "image/jpeg", "image/jpg":
-		img, err = jpeg.Decode(bytes.NewReader(buffer))
-		if err != nil {
-			return nil, err
-		}
-	case "image/webp":
-		return buffer, nil
+	if private {
+		err = acl.Delete(ctx, storage.AllUsers)
+	} else {
+		err = acl.Set(ctx, storage.AllUsers, storage.RoleReader)
 	}
 
-	var out bytes.Buffer
-
-	if err = webp.Encode(&out, img, &webp.Options{Lossless: true, Exact: true, Quality: quality}); err != nil {
-		return nil, err
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		// 404 приходит и для отсутствующего объекта, и для уже удаленной записи ACL
+		if _, err := fs.storage.Object(key).Attrs(ctx); errors.Is(err, storage.ErrObjectNotExist) {
+			return ErrNotFound
+		}
+		return nil
 	}
-	return out.Bytes(), nil
-
-	// converted, err := bimg.NewImage(buffer).Convert(bimg.WEBP)
-	// if err != nil {
-	// 	return nil, err
-	// }
+	return err
+}
 
-	// processed, err := bimg.NewImage(converted).Process(bimg.Options{Quality: quality})
-	// if err != nil {
-	// 	return nil, err
-	// }
+func (fs *FileStorage) List(ctx context.Context, prefix string, fn func(obj Object) error) error {
+	objects := fs.storage.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(Object{Key: attrs.Name, Size: attrs.Size, UpdatedAt: attrs.Updated}); err != nil {
+			return err
+		}
+	}
+}
 
-	// return processed, nil
+func (fs *FileStorage) SignedUrl(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return storage.SignedURL(fs.bucketName+".appspot.com", key, &storage.SignedURLOptions{
+		GoogleAccessID: fs.accessId,
+		PrivateKey:     fs.privateKey,
+		Method:         http.MethodGet,
+		Expires:        time.Now().Add(expires),
+	})
 }
@@ -0,0 +1,33 @@
+package storage_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"github.com/Alexander272/my-portfolio/pkg/storage/storagetest"
+)
+
+// Проверка работает с настоящим бакетом: STORAGE_TEST_FIREBASE_BUCKET и STORAGE_TEST_FIREBASE_CREDENTIALS
+// (путь к файлу сервисного аккаунта)
+func TestFirebaseStorage(t *testing.T) {
+	bucket := os.Getenv("STORAGE_TEST_FIREBASE_BUCKET")
+	if bucket == "" {
+		t.Skip("STORAGE_TEST_FIREBASE_BUCKET is not set")
+	}
+
+	storagetest.Run(t, func(t *testing.T) (storage.Provider, storagetest.Fetcher) {
+		fs, err := storage.NewFileStorage(bucket, os.Getenv("STORAGE_TEST_FIREBASE_CREDENTIALS"))
+		if err != nil {
+			t.Fatalf("new firebase storage: %s", err)
+		}
+		fetch := storagetest.HTTPFetcher(http.DefaultClient)
+		return fs, func(ctx context.Context, url string) ([]byte, error) {
+			// storage.cloud.google.com требует входа в аккаунт, публичные объекты отдаются через API-хост
+			return fetch(ctx, strings.Replace(url, "storage.cloud.google.com", "storage.googleapis.com", 1))
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStorage хранит файлы в памяти процесса. Подходит для тестов и локального запуска без внешних сервисов.
+type MemoryStorage struct {
+	mu     sync.RWMutex
+	files  map[string]memoryObject
+	url    string
+	signer urlSigner
+}
+
+type memoryObject struct {
+	data      []byte
+	private   bool
+	updatedAt time.Time
+}
+
+func NewMemoryStorage(url string) *MemoryStorage {
+	// случайный ключ нужен только для подписи, crypto/rand на практике не возвращает ошибок
+	signer, _ := newURLSigner("")
+	return &MemoryStorage{
+		files:  make(map[string]memoryObject),
+		url:    strings.TrimSuffix(url, "/"),
+		signer: signer,
+	}
+}
+
+func (ms *MemoryStorage) Upload(ctx context.Context, body io.Reader, size int64, contentType, path, filename string, private bool) (*File, error) {
+	key := objectKey(path, filename)
+	var data bytes.Buffer
+	data.Grow(int(size))
+	if err := copyBody(&data, body, size); err != nil {
+		return nil, err
+	}
+
+	ms.mu.Lock()
+	ms.files[key] = memoryObject{data: data.Bytes(), private: private, updatedAt: time.Now()}
+	ms.mu.Unlock()
+
+	return &File{Name: filename, Url: ms.url + "/" + key, Key: key}, nil
+}
+
+func (ms *MemoryStorage) Remove(ctx context.Context, path, filename string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if filename != "" {
+		delete(ms.files, objectKey(path, filename))
+		return nil
+	}
+
+	prefix := strings.TrimPrefix(path, "/")
+	for key := range ms.files {
+		if strings.HasPrefix(key, prefix) {
+			delete(ms.files, key)
+		}
+	}
+	return nil
+}
+
+func (ms *MemoryStorage) SetPrivate(ctx context.Context, key string, private bool) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key = cleanKey(key)
+	obj, ok := ms.files[key]
+	if !ok {
+		return ErrNotFound
+	}
+	obj.private = private
+	ms.files[key] = obj
+	return nil
+}
+
+func (ms *MemoryStorage) List(ctx context.Context, prefix string, fn func(obj Object) error) error {
+	prefix = strings.TrimPrefix(prefix, "/")
+	// fn вызывается без блокировки, чтобы из нее можно было удалять файлы
+	var objects []Object
+	ms.mu.RLock()
+	for key, obj := range ms.files {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, Object{Key: key, Size: int64(len(obj.data)), UpdatedAt: obj.updatedAt})
+		}
+	}
+	ms.mu.RUnlock()
+
+	for _, obj := range objects {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MemoryStorage) SignedUrl(ctx context.Context, key string, expires time.Duration) (string, error) {
+	key = cleanKey(key)
+	return ms.url + "/" + key + "?" + ms.signer.sign(key, time.Now().Add(expires)).Encode(), nil
+}
+
+// ServeHTTP раздает файлы так же, как DiskStorage: приватные только по действующей подписи
+func (ms *MemoryStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := cleanKey(r.URL.Path)
+
+	ms.mu.RLock()
+	obj, ok := ms.files[key]
+	ms.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if obj.private {
+		if !ms.signer.verify(key, r.URL.Query(), time.Now()) {
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+	}
+	setContentHeaders(w)
+	http.ServeContent(w, r, path.Base(key), obj.updatedAt, bytes.NewReader(obj.data))
+}
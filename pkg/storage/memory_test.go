@@ -0,0 +1,15 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"github.com/Alexander272/my-portfolio/pkg/storage/storagetest"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (storage.Provider, storagetest.Fetcher) {
+		ms := storage.NewMemoryStorage("/files")
+		return ms, storagetest.HandlerFetcher(ms, "/files")
+	})
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Бакет остается приватным: ACL объектов MinIO не поддерживает, а бакеты AWS с BucketOwnerEnforced их отклоняют.
+// Публичные объекты хранятся под префиксом public/, который открывает политика бакета, приватные - под private/.
+// Ключи File.Key и List префиксов не содержат.
+const (
+	publicPrefix  = "public/"
+	privatePrefix = "private/"
+)
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+	// PathStyle включает адреса вида endpoint/bucket/key, их требуют MinIO и большинство S3-совместимых хранилищ
+	PathStyle bool
+	// Private отключает публичное чтение всех объектов, файлы тогда доступны только по подписанным ссылкам.
+	// Иначе при запуске бакету назначается политика чтения префикса public/, в AWS для этого нужно
+	// разрешить публичные политики в Block Public Access.
+	Private bool
+	// PublicUrl - адрес, по которому объекты доступны снаружи (CDN или прокси). По умолчанию строится из Endpoint.
+	// К нему добавляется префикс public/ и ключ объекта.
+	PublicUrl string
+}
+
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	private   bool
+	publicUrl string
+}
+
+func NewS3Storage(ctx context.Context, conf S3Config) (*S3Storage, error) {
+	lookup := minio.BucketLookupDNS
+	if conf.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(conf.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(conf.AccessKey, conf.SecretKey, ""),
+		Secure:       conf.UseSSL,
+		Region:       conf.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, conf.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, conf.Bucket, minio.MakeBucketOptions{Region: conf.Region}); err != nil {
+			return nil, err
+		}
+	}
+
+	if !conf.Private {
+		if err := client.SetBucketPolicy(ctx, conf.Bucket, publicReadPolicy(conf.Bucket)); err != nil {
+			return nil, fmt.Errorf("failed to allow public read of %s: %w", publicPrefix, err)
+		}
+	}
+
+	publicUrl := conf.PublicUrl
+	if publicUrl == "" {
+		u := *client.EndpointURL()
+		if conf.PathStyle {
+			u.Path = "/" + conf.Bucket
+		} else {
+			u.Host = conf.Bucket + "." + u.Host
+		}
+		publicUrl = u.String()
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    conf.Bucket,
+		private:   conf.Private,
+		publicUrl: strings.TrimSuffix(publicUrl, "/"),
+	}, nil
+}
+
+func (ss *S3Storage) Upload(ctx context.Context, body io.Reader, size int64, contentType, path, filename string, private bool) (*File, error) {
+	key := objectKey(path, filename)
+
+	// при известном размере minio отправляет файл частями по PartSize и не держит его в памяти целиком
+	_, err := ss.client.PutObject(ctx, ss.bucket, ss.objectName(key, private), body, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrShortBody
+		}
+		return nil, err
+	}
+	// перезапись с другим доступом не должна оставлять прежнюю версию под вторым префиксом
+	if other := ss.objectName(key, !private); other != ss.objectName(key, private) {
+		if err := ss.client.RemoveObject(ctx, ss.bucket, other, minio.RemoveObjectOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &File{Name: filename, Url: ss.publicUrl + "/" + publicPrefix + key, Key: key}, nil
+}
+
+func (ss *S3Storage) Remove(ctx context.Context, path, filename string) error {
+	if filename != "" {
+		key := objectKey(path, filename)
+		for _, prefix := range []string{publicPrefix, privatePrefix} {
+			if err := ss.client.RemoveObject(ctx, ss.bucket, prefix+key, minio.RemoveObjectOptions{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	toRemove := make(chan minio.ObjectInfo)
+	var listErr error
+	go func() {
+		defer close(toRemove)
+		for _, prefix := range []string{publicPrefix, privatePrefix} {
+			// ListObjects закрывает канал сам, при отмене контекста тоже
+			objects := ss.client.ListObjects(ctx, ss.bucket, minio.ListObjectsOptions{Prefix: prefix + strings.TrimPrefix(path, "/"), Recursive: true})
+			for obj := range objects {
+				if obj.Err != nil {
+					listErr = obj.Err
+					continue
+				}
+				select {
+				case toRemove <- obj:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var removeErr error
+	for res := range ss.client.RemoveObjects(ctx, ss.bucket, toRemove, minio.RemoveObjectsOptions{}) {
+		if removeErr == nil {
+			removeErr = res.Err
+		}
+	}
+	if listErr != nil {
+		return listErr
+	}
+	return removeErr
+}
+
+// SetPrivate переносит объект под префикс с нужным доступом: копирует его и удаляет прежний.
+// Во время переноса объект может быть виден под обоими префиксами.
+func (ss *S3Storage) SetPrivate(ctx context.Context, key string, private bool) error {
+	name, found, err := ss.locate(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	target := ss.objectName(key, private)
+	if name == target {
+		return nil
+	}
+
+	// копия сохраняет метаданные, в том числе Content-Type
+	_, err = ss.client.CopyObject(ctx, minio.CopyDestOptions{Bucket: ss.bucket, Object: target}, minio.CopySrcOptions{Bucket: ss.bucket, Object: name})
+	if err != nil {
+		return err
+	}
+	return ss.client.RemoveObject(ctx, ss.bucket, name, minio.RemoveObjectOptions{})
+}
+
+func (ss *S3Storage) List(ctx context.Context, prefix string, fn func(obj Object) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	// отмена останавливает листинг, если fn прервала обход
+	defer cancel()
+
+	for _, p := range []string{publicPrefix, privatePrefix} {
+		objects := ss.client.ListObjects(ctx, ss.bucket, minio.ListObjectsOptions{Prefix: p + strings.TrimPrefix(prefix, "/"), Recursive: true})
+		for obj := range objects {
+			if obj.Err != nil {
+				return obj.Err
+			}
+			if err := fn(Object{Key: strings.TrimPrefix(obj.Key, p), Size: obj.Size, UpdatedAt: obj.LastModified}); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+func (ss *S3Storage) SignedUrl(ctx context.Context, key string, expires time.Duration) (string, error) {
+	// ссылка на отсутствующий файл не ошибка, она просто вернет 404
+	name, _, err := ss.locate(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	u, err := ss.client.PresignedGetObject(ctx, ss.bucket, name, expires, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (ss *S3Storage) objectName(key string, private bool) string {
+	if private || ss.private {
+		return privatePrefix + key
+	}
+	return publicPrefix + key
+}
+
+// locate возвращает имя, под которым хранится объект key. Если объекта нет, возвращается приватное имя и found false.
+func (ss *S3Storage) locate(ctx context.Context, key string) (name string, found bool, err error) {
+	for _, private := range []bool{true, false} {
+		candidate := ss.objectName(key, private)
+		_, err := ss.client.StatObject(ctx, ss.bucket, candidate, minio.StatObjectOptions{})
+		if err == nil {
+			return candidate, true, nil
+		}
+		if minio.ToErrorResponse(err).Code != "NoSuchKey" {
+			return "", false, err
+		}
+	}
+	return ss.objectName(key, true), false, nil
+}
+
+// publicReadPolicy разрешает анонимное чтение объектов под префиксом public/
+func publicReadPolicy(bucket string) string {
+	return fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},`+
+		`"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::%s/%s*"]}]}`, bucket, publicPrefix)
+}
@@ -0,0 +1,41 @@
+package storage_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+	"github.com/Alexander272/my-portfolio/pkg/storage/storagetest"
+)
+
+// Для проверки нужен S3-совместимый сервер, например MinIO из docker-compose:
+// STORAGE_TEST_S3_ENDPOINT=localhost:9000 STORAGE_TEST_S3_ACCESS_KEY=minioadmin STORAGE_TEST_S3_SECRET_KEY=minioadmin.
+// Бакет остается приватным, чтение префикса public/ хранилище открывает само, поэтому ключу нужно право s3:PutBucketPolicy.
+func TestS3Storage(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_S3_ENDPOINT is not set")
+	}
+	bucket := os.Getenv("STORAGE_TEST_S3_BUCKET")
+	if bucket == "" {
+		bucket = "storagetest"
+	}
+
+	storagetest.Run(t, func(t *testing.T) (storage.Provider, storagetest.Fetcher) {
+		ss, err := storage.NewS3Storage(context.Background(), storage.S3Config{
+			Endpoint:  endpoint,
+			Region:    os.Getenv("STORAGE_TEST_S3_REGION"),
+			AccessKey: os.Getenv("STORAGE_TEST_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("STORAGE_TEST_S3_SECRET_KEY"),
+			Bucket:    bucket,
+			UseSSL:    os.Getenv("STORAGE_TEST_S3_SSL") == "true",
+			PathStyle: true,
+		})
+		if err != nil {
+			t.Fatalf("new s3 storage: %s", err)
+		}
+		return ss, storagetest.HTTPFetcher(http.DefaultClient)
+	})
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// urlSigner подписывает ссылки на приватные файлы для хранилищ, которые раздают файлы сами
+type urlSigner struct {
+	key []byte
+}
+
+// newURLSigner создает подписчика со случайным ключом, если ключ не задан.
+// Такие ссылки перестают действовать после перезапуска приложения.
+func newURLSigner(key string) (urlSigner, error) {
+	if key != "" {
+		return urlSigner{key: []byte(key)}, nil
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return urlSigner{}, err
+	}
+	return urlSigner{key: random}, nil
+}
+
+func (s urlSigner) sign(key string, expires time.Time) url.Values {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return url.Values{
+		"expires":   {exp},
+		"signature": {s.signature(key, exp)},
+	}
+}
+
+func (s urlSigner) verify(key string, query url.Values, now time.Time) bool {
+	exp := query.Get("expires")
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(query.Get("signature")), []byte(s.signature(key, exp)))
+}
+
+func (s urlSigner) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedMaxAge возвращает, сколько секунд подписанная ссылка еще действует
+func signedMaxAge(expires string) int64 {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return 0
+	}
+	if left := unix - time.Now().Unix(); left > 0 {
+		return left
+	}
+	return 0
+}
@@ -2,15 +2,41 @@ package storage
 
 import (
 	"context"
-	"mime/multipart"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound  = errors.New("file not found")
+	ErrShortBody = errors.New("body is shorter than declared size")
 )
 
 type File struct {
 	Name string
 	Url  string
+	// Key - путь объекта в хранилище, по нему меняется доступ и выдаются подписанные ссылки
+	Key string
+}
+
+// Object - сведения об объекте, которые возвращает List
+type Object struct {
+	Key       string
+	Size      int64
+	UpdatedAt time.Time
 }
 
 type Provider interface {
-	Upload(ctx context.Context, file multipart.File, header *multipart.FileHeader, path, name string) (*File, error)
+	// Upload сохраняет size байт из body как есть под именем path/filename, не загружая их в память целиком.
+	// Если body заканчивается раньше, возвращается ErrShortBody. Приватный файл недоступен по Url,
+	// прочитать его можно только по подписанной ссылке.
+	Upload(ctx context.Context, body io.Reader, size int64, contentType, path, filename string, private bool) (*File, error)
+	// Remove удаляет файл path/filename, а при пустом filename - все файлы с префиксом path
 	Remove(ctx context.Context, path, filename string) error
+	SetPrivate(ctx context.Context, key string, private bool) error
+	// SignedUrl возвращает ссылку на файл, которая действует в течение expires
+	SignedUrl(ctx context.Context, key string, expires time.Duration) (string, error)
+	// List вызывает fn для каждого файла с префиксом prefix, публичного или приватного. Ошибка fn прерывает обход.
+	// Файлы, загруженные или удаленные во время обхода, могут как попасть в него, так и нет.
+	List(ctx context.Context, prefix string, fn func(obj Object) error) error
 }
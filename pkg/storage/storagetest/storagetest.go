@@ -0,0 +1,366 @@
+// Package storagetest содержит общий набор проверок, который должен проходить каждый storage.Provider
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alexander272/my-portfolio/pkg/storage"
+)
+
+// ErrNotFound возвращает Fetcher, если файла нет в хранилище или он недоступен без подписи
+var ErrNotFound = storage.ErrNotFound
+
+// Fetcher читает загруженный файл по адресу из storage.File
+type Fetcher func(ctx context.Context, url string) ([]byte, error)
+
+// Factory создает проверяемое хранилище и способ читать из него файлы
+type Factory func(t *testing.T) (storage.Provider, Fetcher)
+
+// HTTPFetcher читает файлы по их адресу. Ответы 403 и 404 считаются отсутствием файла.
+func HTTPFetcher(client *http.Client) Fetcher {
+	return func(ctx context.Context, url string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		// проверки перезаписывают и удаляют файлы, ответ из кэша CDN их бы не увидел
+		req.Header.Set("Cache-Control", "no-cache")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return readResponse(resp, url)
+	}
+}
+
+// HandlerFetcher читает файлы у хранилищ, которые раздают их сами. prefix - адрес, под которым смонтирован handler.
+func HandlerFetcher(handler http.Handler, prefix string) Fetcher {
+	handler = http.StripPrefix(strings.TrimSuffix(prefix, "/"), handler)
+	return func(ctx context.Context, url string) ([]byte, error) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil).WithContext(ctx))
+		return readResponse(rec.Result(), url)
+	}
+}
+
+func readResponse(resp *http.Response, url string) ([]byte, error) {
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %d for %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Run прогоняет набор проверок. Каждая проверка работает в отдельном префиксе,
+// который удаляется по ее завершении, поэтому внешние хранилища можно проверять на общем бакете.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, p storage.Provider, fetch Fetcher, root string)
+	}{
+		{"Upload", testUpload},
+		{"Overwrite", testOverwrite},
+		{"RemoveFile", testRemoveFile},
+		{"RemovePrefix", testRemovePrefix},
+		{"RemoveMissing", testRemoveMissing},
+		{"ConcurrentUploads", testConcurrentUploads},
+		{"StreamedUpload", testStreamedUpload},
+		{"ShortBody", testShortBody},
+		{"PrivateUpload", testPrivateUpload},
+		{"SetPrivate", testSetPrivate},
+		{"List", testList},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, fetch := factory(t)
+			root := fmt.Sprintf("storagetest-%d", time.Now().UnixNano())
+			t.Cleanup(func() {
+				if err := p.Remove(context.Background(), root+"/", ""); err != nil {
+					t.Errorf("cleanup %s: %s", root, err)
+				}
+			})
+			tt.fn(t, p, fetch, root)
+		})
+	}
+}
+
+func testUpload(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	data := content("avatar")
+	file := upload(t, p, root+"/user", "avatar.webp", data)
+
+	if file.Name != "avatar.webp" {
+		t.Errorf("name = %q, want %q", file.Name, "avatar.webp")
+	}
+	if file.Url == "" || !strings.Contains(file.Url, file.Name) {
+		t.Fatalf("url %q does not contain name %q", file.Url, file.Name)
+	}
+	if !bytes.Equal(mustFetch(t, ctx, fetch, file.Url), data) {
+		t.Errorf("stored file differs from uploaded")
+	}
+}
+
+func testOverwrite(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	first := upload(t, p, root+"/user", "avatar.webp", content("first"))
+	second := upload(t, p, root+"/user", "avatar.webp", content("second"))
+	if second.Url != first.Url {
+		t.Errorf("url changed on overwrite: %q != %q", second.Url, first.Url)
+	}
+	if !bytes.Equal(mustFetch(t, ctx, fetch, second.Url), content("second")) {
+		t.Errorf("file was not overwritten")
+	}
+}
+
+func testRemoveFile(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	removed := upload(t, p, root+"/user", "avatar.webp", content("a"))
+	kept := upload(t, p, root+"/user", "avatar_thumbnail.webp", content("b"))
+
+	if err := p.Remove(ctx, root+"/user", "avatar.webp"); err != nil {
+		t.Fatalf("remove: %s", err)
+	}
+	mustMiss(t, ctx, fetch, removed.Url)
+	mustFetch(t, ctx, fetch, kept.Url)
+}
+
+func testRemovePrefix(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	removed := []*storage.File{
+		upload(t, p, root+"/u1", "avatar.webp", content("a")),
+		upload(t, p, root+"/u1/projects/p1", "one.webp", content("b")),
+		upload(t, p, root+"/u1/projects/p2", "two.webp", content("c")),
+	}
+	// u10 начинается с u1, но префикс с разделителем его не затрагивает
+	kept := upload(t, p, root+"/u10", "avatar.webp", content("d"))
+
+	if err := p.Remove(ctx, root+"/u1/", ""); err != nil {
+		t.Fatalf("remove prefix: %s", err)
+	}
+	for _, f := range removed {
+		mustMiss(t, ctx, fetch, f.Url)
+	}
+	mustFetch(t, ctx, fetch, kept.Url)
+}
+
+func testRemoveMissing(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	if err := p.Remove(ctx, root+"/missing", "avatar.webp"); err != nil {
+		t.Errorf("remove missing file: %s", err)
+	}
+	if err := p.Remove(ctx, root+"/missing/", ""); err != nil {
+		t.Errorf("remove missing prefix: %s", err)
+	}
+}
+
+func testConcurrentUploads(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	const n = 8
+
+	same := content("same")
+	var wg sync.WaitGroup
+	files := make([]*storage.File, n)
+	errs := make([]error, n)
+	sameErrs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			files[i], errs[i] = p.Upload(ctx, bytes.NewReader(content(fmt.Sprint(i))), int64(len(content(fmt.Sprint(i)))), contentType, root+"/many", fmt.Sprintf("file%d.webp", i), false)
+		}(i)
+		// одновременная запись в один и тот же файл не должна портить его содержимое
+		go func(i int) {
+			defer wg.Done()
+			_, sameErrs[i] = p.Upload(ctx, bytes.NewReader(same), int64(len(same)), contentType, root+"/same", "avatar.webp", false)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("upload %d: %s", i, errs[i])
+		}
+		if sameErrs[i] != nil {
+			t.Fatalf("upload %d to the same name: %s", i, sameErrs[i])
+		}
+		if !bytes.Equal(mustFetch(t, ctx, fetch, files[i].Url), content(fmt.Sprint(i))) {
+			t.Errorf("file %d differs from uploaded", i)
+		}
+	}
+	file := upload(t, p, root+"/same", "avatar.webp", same)
+	if !bytes.Equal(mustFetch(t, ctx, fetch, file.Url), same) {
+		t.Errorf("file written concurrently is corrupted")
+	}
+}
+
+// testStreamedUpload загружает файл из потока без Seek и Len, который отдает данные маленькими кусками
+func testStreamedUpload(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	data := bytes.Repeat(content("stream"), 64<<10)
+	body := &chunkedReader{r: bytes.NewReader(data), chunk: 1000}
+	file, err := p.Upload(ctx, body, int64(len(data)), contentType, root+"/stream", "big.webp", false)
+	if err != nil {
+		t.Fatalf("upload: %s", err)
+	}
+	if !bytes.Equal(mustFetch(t, ctx, fetch, file.Url), data) {
+		t.Errorf("streamed file differs from uploaded")
+	}
+}
+
+func testShortBody(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	data := content("short")
+	_, err := p.Upload(ctx, bytes.NewReader(data), int64(len(data))+10, contentType, root+"/short", "doc.webp", false)
+	if err == nil {
+		t.Errorf("upload of a body shorter than its size succeeded")
+	}
+}
+
+type chunkedReader struct {
+	r     io.Reader
+	chunk int
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(p) > r.chunk {
+		p = p[:r.chunk]
+	}
+	return r.r.Read(p)
+}
+
+func testPrivateUpload(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	data := content("private")
+	file := uploadAccess(t, p, root+"/private", "doc.webp", data, true)
+	if file.Key == "" {
+		t.Fatal("empty key")
+	}
+	mustMiss(t, ctx, fetch, file.Url)
+
+	signed, err := p.SignedUrl(ctx, file.Key, time.Minute)
+	if err != nil {
+		t.Fatalf("signed url: %s", err)
+	}
+	if !bytes.Equal(mustFetch(t, ctx, fetch, signed), data) {
+		t.Errorf("file from signed url differs from uploaded")
+	}
+}
+
+func testSetPrivate(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	file := upload(t, p, root+"/access", "doc.webp", content("doc"))
+	mustFetch(t, ctx, fetch, file.Url)
+
+	if err := p.SetPrivate(ctx, file.Key, true); err != nil {
+		t.Fatalf("set private: %s", err)
+	}
+	mustMiss(t, ctx, fetch, file.Url)
+	signed, err := p.SignedUrl(ctx, file.Key, time.Minute)
+	if err != nil {
+		t.Fatalf("signed url: %s", err)
+	}
+	mustFetch(t, ctx, fetch, signed)
+
+	if err := p.SetPrivate(ctx, file.Key, false); err != nil {
+		t.Fatalf("set public: %s", err)
+	}
+	mustFetch(t, ctx, fetch, file.Url)
+
+	if err := p.SetPrivate(ctx, root+"/access/missing.webp", true); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("set private on missing file: got %v, want ErrNotFound", err)
+	}
+}
+
+func testList(t *testing.T, p storage.Provider, fetch Fetcher, root string) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Minute)
+	public := upload(t, p, root+"/u1", "avatar.webp", content("public"))
+	private := uploadAccess(t, p, root+"/u1/projects/p1", "one.webp", content("private file"), true)
+	upload(t, p, root+"/u10", "avatar.webp", content("other"))
+	want := map[string]int64{
+		public.Key:  int64(len(content("public"))),
+		private.Key: int64(len(content("private file"))),
+	}
+
+	got := map[string]int64{}
+	err := p.List(ctx, root+"/u1/", func(obj storage.Object) error {
+		if obj.UpdatedAt.Before(start) {
+			t.Errorf("%s: updated at %s, want after %s", obj.Key, obj.UpdatedAt, start)
+		}
+		got[obj.Key] = obj.Size
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("list: %s", err)
+	}
+	if len(got) != len(want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+	for key, size := range want {
+		if got[key] != size {
+			t.Errorf("%s: size %d, want %d", key, got[key], size)
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = p.List(ctx, root+"/", func(obj storage.Object) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("list stopped by fn: err %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
+
+func upload(t *testing.T, p storage.Provider, path, filename string, data []byte) *storage.File {
+	t.Helper()
+	return uploadAccess(t, p, path, filename, data, false)
+}
+
+func uploadAccess(t *testing.T, p storage.Provider, path, filename string, data []byte, private bool) *storage.File {
+	t.Helper()
+	file, err := p.Upload(context.Background(), bytes.NewReader(data), int64(len(data)), contentType, path, filename, private)
+	if err != nil {
+		t.Fatalf("upload %s/%s: %s", path, filename, err)
+	}
+	return file
+}
+
+func mustFetch(t *testing.T, ctx context.Context, fetch Fetcher, url string) []byte {
+	t.Helper()
+	data, err := fetch(ctx, url)
+	if err != nil {
+		t.Fatalf("fetch %s: %s", url, err)
+	}
+	return data
+}
+
+func mustMiss(t *testing.T, ctx context.Context, fetch Fetcher, url string) {
+	t.Helper()
+	if _, err := fetch(ctx, url); !errors.Is(err, ErrNotFound) {
+		t.Errorf("fetch %s: got %v, want ErrNotFound", url, err)
+	}
+}
+
+// хранилища не разбирают содержимое файлов, поэтому проверкам хватает произвольных байтов
+const contentType = "image/webp"
+
+func content(seed string) []byte {
+	return []byte("storagetest " + seed)
+}
@@ -0,0 +1,27 @@
+package useragent
+
+import "strings"
+
+// botMarkers - подстроки User-Agent поисковых роботов, превью ссылок, мониторинга и HTTP библиотек
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "archiver", "facebookexternalhit", "embedly", "preview",
+	"headless", "phantomjs", "selenium", "puppeteer", "playwright", "lighthouse",
+	"monitor", "pingdom", "uptime", "statuscake",
+	"curl", "wget", "httpie", "python-requests", "python-urllib", "aiohttp", "go-http-client", "java/",
+	"okhttp", "axios", "node-fetch", "undici", "libwww", "scrapy", "httpclient",
+}
+
+// IsBot сообщает, что запрос, скорее всего, отправлен программой, а не человеком в браузере.
+// Пустой User-Agent браузеры не отправляют, поэтому он тоже считается ботом.
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
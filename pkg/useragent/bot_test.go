@@ -0,0 +1,32 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/Alexander272/my-portfolio/pkg/useragent"
+)
+
+func TestIsBot(t *testing.T) {
+	tests := []struct {
+		ua  string
+		bot bool
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36", false},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", false},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/119.0", false},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", true},
+		{"Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)", true},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/118.0.0.0 Safari/537.36", true},
+		{"curl/8.4.0", true},
+		{"python-requests/2.31.0", true},
+		{"Go-http-client/1.1", true},
+		{"", true},
+		{"   ", true},
+	}
+	for _, tt := range tests {
+		if got := useragent.IsBot(tt.ua); got != tt.bot {
+			t.Errorf("IsBot(%q) = %v, want %v", tt.ua, got, tt.bot)
+		}
+	}
+}